@@ -0,0 +1,62 @@
+package cache
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Cache stores opaque values under string keys. Values are bytes so that a
+// shared backend such as Redis can implement it without knowing the types
+// being cached.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value under key. A ttl of zero keeps the value until it is
+	// deleted or evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+}
+
+type Stats struct {
+	Hits     int64   `json:"hits"`
+	Misses   int64   `json:"misses"`
+	Errors   int64   `json:"errors"`
+	HitRatio float64 `json:"hit_ratio"`
+}
+
+// Metrics counts cache lookups. It is safe for concurrent use.
+type Metrics struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+func (m *Metrics) Hit() {
+	m.hits.Add(1)
+}
+
+func (m *Metrics) Miss() {
+	m.misses.Add(1)
+}
+
+func (m *Metrics) Error() {
+	m.errors.Add(1)
+}
+
+func (m *Metrics) Stats() Stats {
+	stats := Stats{
+		Hits:   m.hits.Load(),
+		Misses: m.misses.Load(),
+		Errors: m.errors.Load(),
+	}
+
+	if total := stats.Hits + stats.Misses; total > 0 {
+		stats.HitRatio = float64(stats.Hits) / float64(total)
+	}
+	return stats
+}
+
+// StatsProvider is implemented by anything that exposes cache metrics.
+type StatsProvider interface {
+	CacheStats() Stats
+}
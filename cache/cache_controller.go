@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/utils"
+)
+
+type (
+	ICacheController interface {
+		GetCacheStats(ctx *gin.Context)
+	}
+
+	CacheController struct {
+		providers map[string]StatsProvider
+	}
+)
+
+// NewCacheController reports the metrics of every named cache in providers.
+func NewCacheController(providers map[string]StatsProvider) *CacheController {
+	return &CacheController{
+		providers: providers,
+	}
+}
+
+func (cc *CacheController) GetCacheStats(ctx *gin.Context) {
+	result := make(map[string]Stats, len(cc.providers))
+	for name, provider := range cc.providers {
+		result[name] = provider.CacheStats()
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_CACHE_STATS, result)
+	ctx.JSON(http.StatusOK, res)
+}
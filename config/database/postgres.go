@@ -7,6 +7,7 @@ import (
 
 	"github.com/joho/godotenv"
 	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/audit"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -35,6 +36,10 @@ func SetUpPostgreSQLConnection() *gorm.DB {
 		panic(fmt.Errorf("failed to connect postgres: %v", err))
 	}
 
+	if err := audit.RegisterCallbacks(db, "users", "products"); err != nil {
+		panic(fmt.Errorf("failed to register audit callbacks: %v", err))
+	}
+
 	log.Println("postgres connection established")
 	return db
 }
@@ -10,22 +10,34 @@ import (
 
 type (
 	InterfaceJWTService interface {
-		GenerateToken(userID string, role string) (string, string, error)
+		GenerateToken(userID, role, sessionID string) (string, string, error)
 		ValidateToken(token string) (*jwt.Token, *jwtCustomClaims, error)
 	}
 
 	jwtCustomClaims struct {
-		UserID string `json:"id"`
-		Role   string `json:"role"`
+		UserID    string `json:"id"`
+		Role      string `json:"role"`
+		SessionID string `json:"sid"`
 		jwt.RegisteredClaims
 	}
 
+	// RevocationCheck reports whether the tokens of userID for sessionID
+	// issued at issuedAt have been revoked since, e.g. by a password reset
+	// or by signing the session out.
+	RevocationCheck func(userID, sessionID string, issuedAt time.Time) bool
+
 	JWTService struct {
 		secretKey string
 		issuer    string
+		revoked   RevocationCheck
 	}
 )
 
+const (
+	AccessTokenTTL  = 5 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
 func getSecretKey() string {
 	key := os.Getenv("JWT_SECRET")
 	if key == "" {
@@ -41,13 +53,21 @@ func NewJWTService() *JWTService {
 	}
 }
 
-func (j *JWTService) GenerateToken(userID, role string) (string, string, error) {
+// SetRevocationCheck makes ValidateToken reject tokens that check reports as
+// revoked. Call it before the service is used.
+func (j *JWTService) SetRevocationCheck(check RevocationCheck) {
+	j.revoked = check
+}
+
+// GenerateToken signs the token pair of a session, see user.Session.
+func (j *JWTService) GenerateToken(userID, role, sessionID string) (string, string, error) {
 	// Access token
 	accessClaims := jwtCustomClaims{
-		UserID: userID,
-		Role:   role,
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(5 * time.Minute)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			Issuer:    j.issuer,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -60,10 +80,11 @@ func (j *JWTService) GenerateToken(userID, role string) (string, string, error)
 
 	// Refresh token
 	refreshClaims := jwtCustomClaims{
-		UserID: userID,
-		Role:   role,
+		UserID:    userID,
+		Role:      role,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenTTL)),
 			Issuer:    j.issuer,
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
@@ -94,5 +115,9 @@ func (j *JWTService) ValidateToken(tokenString string) (*jwt.Token, *jwtCustomCl
 		return nil, nil, constants.ErrTokenInvalid
 	}
 
+	if j.revoked != nil && claims.IssuedAt != nil && j.revoked(claims.UserID, claims.SessionID, claims.IssuedAt.Time) {
+		return nil, nil, constants.ErrTokenRevoked
+	}
+
 	return token, claims, nil
 }
@@ -3,36 +3,141 @@ package constants
 import "errors"
 
 const (
-	MESSAGE_FAILED_PROSES_REQUEST      = "failed proses request"
-	MESSAGE_FAILED_ACCESS_DENIED       = "failed access denied"
-	MESSAGE_FAILED_TOKEN_NOT_FOUND     = "failed token not found"
-	MESSAGE_FAILED_TOKEN_NOT_VALID     = "failed token not valid"
-	MESSAGE_FAILED_TOKEN_DENIED_ACCESS = "failed token denied access"
-	MESSAGE_FAILED_GET_DATA_FROM_BODY  = "failed get data from body"
-	MESSAGE_FAILED_CREATE_USER         = "failed create user"
-	MESSAGE_FAILED_GET_DETAIL_USER     = "failed get detail user"
-	MESSAGE_FAILED_GET_LIST_USER       = "failed get list user"
-	MESSAGE_FAILED_UPDATE_USER         = "failed update user"
-	MESSAGE_FAILED_DELETE_USER         = "failed delete user"
-	MESSAGE_FAILED_LOGIN_USER          = "failed login user"
-	MESSAGE_FAILED_UUID_FORMAT         = "failed uuid format"
-	MESSAGE_FAILED_REGISTER            = "failed register"
-	MESSAGE_SUCCESS_REGISTER           = "success register"
-	MESSAGE_FAILED_CREATE_PROPOSAL     = "failed create proposal"
-	MESSAGE_FAILED_CREATE_PRODUCT      = "failed create product"
-	MESSAGE_FAILED_GET_ALL_PRODUCTS    = "failed get all product"
-	MESSAGE_FAILED_UPDATE_PRODUCT      = "failed update product"
-	MESSAGE_FAILED_DELETE_PRODUCT      = "failed deleted product"
+	MESSAGE_FAILED_PROSES_REQUEST         = "failed proses request"
+	MESSAGE_FAILED_ACCESS_DENIED          = "failed access denied"
+	MESSAGE_FAILED_TOKEN_NOT_FOUND        = "failed token not found"
+	MESSAGE_FAILED_TOKEN_NOT_VALID        = "failed token not valid"
+	MESSAGE_FAILED_TOKEN_DENIED_ACCESS    = "failed token denied access"
+	MESSAGE_FAILED_GET_DATA_FROM_BODY     = "failed get data from body"
+	MESSAGE_FAILED_CREATE_USER            = "failed create user"
+	MESSAGE_FAILED_GET_DETAIL_USER        = "failed get detail user"
+	MESSAGE_FAILED_GET_DETAIL_PRODUCT     = "failed get detail product"
+	MESSAGE_FAILED_GET_LIST_USER          = "failed get list user"
+	MESSAGE_FAILED_UPDATE_USER            = "failed update user"
+	MESSAGE_FAILED_DELETE_USER            = "failed delete user"
+	MESSAGE_FAILED_GET_TRASHED_USER       = "failed get trashed user"
+	MESSAGE_FAILED_RESTORE_USER           = "failed restore user"
+	MESSAGE_FAILED_UNLOCK_USER            = "failed unlock user"
+	MESSAGE_FAILED_VERIFY_EMAIL           = "failed verify email"
+	MESSAGE_FAILED_RESEND_VERIFICATION    = "failed resend verification email"
+	MESSAGE_FAILED_SEND_EMAIL             = "failed send email"
+	MESSAGE_FAILED_FORGOT_PASSWORD        = "failed forgot password"
+	MESSAGE_FAILED_RESET_PASSWORD         = "failed reset password"
+	MESSAGE_FAILED_ENROLL_MFA             = "failed enroll two-factor authentication"
+	MESSAGE_FAILED_CONFIRM_MFA            = "failed confirm two-factor authentication"
+	MESSAGE_FAILED_DISABLE_MFA            = "failed disable two-factor authentication"
+	MESSAGE_FAILED_VERIFY_MFA             = "failed verify two-factor code"
+	MESSAGE_FAILED_GET_MFA_POLICY         = "failed get mfa policy"
+	MESSAGE_FAILED_UPDATE_MFA_POLICY      = "failed update mfa policy"
+	MESSAGE_FAILED_PURGE_USER             = "failed purge user"
+	MESSAGE_FAILED_LOGIN_USER             = "failed login user"
+	MESSAGE_FAILED_UUID_FORMAT            = "failed uuid format"
+	MESSAGE_FAILED_REGISTER               = "failed register"
+	MESSAGE_SUCCESS_REGISTER              = "success register"
+	MESSAGE_FAILED_CREATE_PROPOSAL        = "failed create proposal"
+	MESSAGE_FAILED_CREATE_PRODUCT         = "failed create product"
+	MESSAGE_FAILED_GET_ALL_PRODUCTS       = "failed get all product"
+	MESSAGE_FAILED_SEARCH_PRODUCT         = "failed search product"
+	MESSAGE_FAILED_SUGGEST_PRODUCT        = "failed suggest product"
+	MESSAGE_FAILED_UPDATE_PRODUCT         = "failed update product"
+	MESSAGE_FAILED_DELETE_PRODUCT         = "failed deleted product"
+	MESSAGE_FAILED_GET_TRASHED_PRODUCT    = "failed get trashed product"
+	MESSAGE_FAILED_RESTORE_PRODUCT        = "failed restore product"
+	MESSAGE_FAILED_PURGE_PRODUCT          = "failed purge product"
+	MESSAGE_FAILED_IMPORT_PRODUCT         = "failed import product"
+	MESSAGE_FAILED_GET_IMPORT_JOB         = "failed get import job"
+	MESSAGE_FAILED_EXPORT_PRODUCT         = "failed export product"
+	MESSAGE_FAILED_EXPORT_USER            = "failed export user"
+	MESSAGE_FAILED_BATCH_PRODUCT          = "failed batch product"
+	MESSAGE_FAILED_IDEMPOTENT_REQUEST     = "failed idempotent request"
+	MESSAGE_FAILED_RATE_LIMIT             = "too many requests"
+	MESSAGE_FAILED_ADD_WISHLIST           = "failed add wishlist"
+	MESSAGE_FAILED_REMOVE_WISHLIST        = "failed remove wishlist"
+	MESSAGE_FAILED_GET_WISHLIST           = "failed get wishlist"
+	MESSAGE_FAILED_GET_NOTIFICATION       = "failed get notification"
+	MESSAGE_FAILED_CREATE_NOTIFICATION    = "failed create notification"
+	MESSAGE_FAILED_GET_PRICE_HISTORY      = "failed get price history"
+	MESSAGE_FAILED_CREATE_PRICE_HISTORY   = "failed create price history"
+	MESSAGE_FAILED_CREATE_PRICE_SCHEDULE  = "failed create price schedule"
+	MESSAGE_FAILED_GET_PRICE_SCHEDULE     = "failed get price schedule"
+	MESSAGE_FAILED_CANCEL_PRICE_SCHEDULE  = "failed cancel price schedule"
+	MESSAGE_FAILED_GET_AUDIT_LOG          = "failed get audit log"
+	MESSAGE_FAILED_GET_ROLE               = "failed get role"
+	MESSAGE_FAILED_CREATE_ROLE            = "failed create role"
+	MESSAGE_FAILED_UPDATE_ROLE            = "failed update role"
+	MESSAGE_FAILED_DELETE_ROLE            = "failed delete role"
+	MESSAGE_FAILED_ASSIGN_ROLE            = "failed assign role"
+	MESSAGE_FAILED_GET_PERMISSION         = "failed get permission"
+	MESSAGE_FAILED_GET_API_KEY            = "failed get api key"
+	MESSAGE_FAILED_CREATE_API_KEY         = "failed create api key"
+	MESSAGE_FAILED_REVOKE_API_KEY         = "failed revoke api key"
+	MESSAGE_FAILED_API_KEY_NOT_VALID      = "failed api key not valid"
+	MESSAGE_FAILED_GET_SERVICE_ACCOUNT    = "failed get service account"
+	MESSAGE_FAILED_CREATE_SERVICE_ACCOUNT = "failed create service account"
+	MESSAGE_FAILED_GET_SESSION            = "failed get session"
+	MESSAGE_FAILED_REVOKE_SESSION         = "failed revoke session"
+	MESSAGE_FAILED_OIDC_LOGIN             = "failed sign in with identity provider"
 
-	MESSAGE_SUCCESS_CREATE_USER     = "success create user"
-	MESSAGE_SUCCESS_GET_DETAIL_USER = "success get detail user"
-	MESSAGE_SUCCESS_GET_LIST_USER   = "success get list user"
-	MESSAGE_SUCCESS_UPDATE_USER     = "success update user"
-	MESSAGE_SUCCESS_DELETE_USER     = "success delete user"
-	MESSAGE_SUCCESS_LOGIN_USER      = "success login user"
-	MESSAGE_SUCCESS_CREATE_PRODUCT  = "success create product"
-	MESSAGE_SUCCESS_GET_ALL_PRODUCT = "success get all product"
-	MESSAGE_SUCCESS_UPDATE_PRODUCT  = "success update product"
+	MESSAGE_SUCCESS_CREATE_USER             = "success create user"
+	MESSAGE_SUCCESS_GET_DETAIL_USER         = "success get detail user"
+	MESSAGE_SUCCESS_GET_DETAIL_PRODUCT      = "success get detail product"
+	MESSAGE_SUCCESS_GET_LIST_USER           = "success get list user"
+	MESSAGE_SUCCESS_UPDATE_USER             = "success update user"
+	MESSAGE_SUCCESS_DELETE_USER             = "success delete user"
+	MESSAGE_SUCCESS_GET_TRASHED_USER        = "success get trashed user"
+	MESSAGE_SUCCESS_RESTORE_USER            = "success restore user"
+	MESSAGE_SUCCESS_UNLOCK_USER             = "success unlock user"
+	MESSAGE_SUCCESS_VERIFY_EMAIL            = "success verify email"
+	MESSAGE_SUCCESS_RESEND_VERIFICATION     = "verification email sent if the account exists and is unverified"
+	MESSAGE_SUCCESS_FORGOT_PASSWORD         = "password reset link sent if the account exists"
+	MESSAGE_SUCCESS_RESET_PASSWORD          = "success reset password"
+	MESSAGE_SUCCESS_ENROLL_MFA              = "scan the qr code, then confirm with a code from the app"
+	MESSAGE_SUCCESS_CONFIRM_MFA             = "two-factor authentication enabled, store the recovery codes safely"
+	MESSAGE_SUCCESS_DISABLE_MFA             = "two-factor authentication disabled"
+	MESSAGE_SUCCESS_MFA_REQUIRED            = "enter the code from your authenticator app"
+	MESSAGE_SUCCESS_MFA_ENROLLMENT_REQUIRED = "two-factor authentication must be set up before signing in"
+	MESSAGE_SUCCESS_GET_MFA_POLICY          = "success get mfa policy"
+	MESSAGE_SUCCESS_UPDATE_MFA_POLICY       = "success update mfa policy"
+	MESSAGE_SUCCESS_PURGE_USER              = "success purge user"
+	MESSAGE_SUCCESS_LOGIN_USER              = "success login user"
+	MESSAGE_SUCCESS_CREATE_PRODUCT          = "success create product"
+	MESSAGE_SUCCESS_GET_ALL_PRODUCT         = "success get all product"
+	MESSAGE_SUCCESS_SEARCH_PRODUCT          = "success search product"
+	MESSAGE_SUCCESS_SUGGEST_PRODUCT         = "success suggest product"
+	MESSAGE_SUCCESS_UPDATE_PRODUCT          = "success update product"
+	MESSAGE_SUCCESS_GET_TRASHED_PRODUCT     = "success get trashed product"
+	MESSAGE_SUCCESS_RESTORE_PRODUCT         = "success restore product"
+	MESSAGE_SUCCESS_PURGE_PRODUCT           = "success purge product"
+	MESSAGE_SUCCESS_IMPORT_PRODUCT          = "success import product"
+	MESSAGE_SUCCESS_QUEUE_IMPORT_PRODUCT    = "import product queued"
+	MESSAGE_SUCCESS_GET_IMPORT_JOB          = "success get import job"
+	MESSAGE_SUCCESS_EXPORT_PRODUCT          = "success export product"
+	MESSAGE_SUCCESS_EXPORT_USER             = "success export user"
+	MESSAGE_SUCCESS_BATCH_PRODUCT           = "success batch product"
+	MESSAGE_SUCCESS_ADD_WISHLIST            = "success add wishlist"
+	MESSAGE_SUCCESS_REMOVE_WISHLIST         = "success remove wishlist"
+	MESSAGE_SUCCESS_GET_WISHLIST            = "success get wishlist"
+	MESSAGE_SUCCESS_GET_NOTIFICATION        = "success get notification"
+	MESSAGE_SUCCESS_GET_PRICE_HISTORY       = "success get price history"
+	MESSAGE_SUCCESS_CREATE_PRICE_SCHEDULE   = "success create price schedule"
+	MESSAGE_SUCCESS_GET_PRICE_SCHEDULE      = "success get price schedule"
+	MESSAGE_SUCCESS_CANCEL_PRICE_SCHEDULE   = "success cancel price schedule"
+	MESSAGE_SUCCESS_GET_AUDIT_LOG           = "success get audit log"
+	MESSAGE_SUCCESS_GET_CACHE_STATS         = "success get cache stats"
+	MESSAGE_SUCCESS_GET_ROLE                = "success get role"
+	MESSAGE_SUCCESS_CREATE_ROLE             = "success create role"
+	MESSAGE_SUCCESS_UPDATE_ROLE             = "success update role"
+	MESSAGE_SUCCESS_DELETE_ROLE             = "success delete role"
+	MESSAGE_SUCCESS_ASSIGN_ROLE             = "success assign role, the user must sign in again"
+	MESSAGE_SUCCESS_GET_PERMISSION          = "success get permission"
+	MESSAGE_SUCCESS_GET_API_KEY             = "success get api key"
+	MESSAGE_SUCCESS_CREATE_API_KEY          = "success create api key, store the key now as it is not shown again"
+	MESSAGE_SUCCESS_REVOKE_API_KEY          = "success revoke api key"
+	MESSAGE_SUCCESS_GET_SERVICE_ACCOUNT     = "success get service account"
+	MESSAGE_SUCCESS_CREATE_SERVICE_ACCOUNT  = "success create service account"
+	MESSAGE_SUCCESS_GET_SESSION             = "success get session"
+	MESSAGE_SUCCESS_REVOKE_SESSION          = "success revoke session"
+	MESSAGE_SUCCESS_OIDC_LOGIN              = "success sign in with identity provider"
 )
 
 var (
@@ -41,10 +146,18 @@ var (
 	ErrUnexpectedSigningMethod  = errors.New("unexpected signing method")
 	ErrDecryptToken             = errors.New("failed to decrypt token")
 	ErrTokenInvalid             = errors.New("token invalid")
+	ErrSecretNotConfigured      = errors.New("secret is not configured")
 	ErrValidateToken            = errors.New("failed to validate token")
+	ErrTokenExpired             = errors.New("token expired")
+	ErrTokenRevoked             = errors.New("token revoked")
 	ErrInvalidName              = errors.New("failed invalid name")
 	ErrInvalidEmail             = errors.New("failed invalid email")
 	ErrInvalidPassword          = errors.New("failed invalid password")
+	ErrPasswordTooShort         = errors.New("password is too short")
+	ErrPasswordTooLong          = errors.New("password is too long")
+	ErrPasswordTooSimple        = errors.New("password is too simple")
+	ErrPasswordCommon           = errors.New("password is too common")
+	ErrPasswordContainsEmail    = errors.New("password must not contain the email address")
 	ErrEmailAlreadyExists       = errors.New("email already exists")
 	ErrRegisterUser             = errors.New("failed to register user")
 	ErrGetAllUserWithPagination = errors.New("failed get list user with pagination")
@@ -70,7 +183,108 @@ var (
 	ErrInvalidPrice             = errors.New("invalid product price")
 	ErrCretaeProduct            = errors.New("error create product")
 	ErrGetAllProduct            = errors.New("error get all product")
+	ErrSearchProduct            = errors.New("error search product")
+	ErrSuggestProduct           = errors.New("error suggest product")
 	ErrGetProductByID           = errors.New("error get product")
 	ErrUpdateProduct            = errors.New("error update product")
 	ErrDeleteProduct            = errors.New("error delete product")
+	ErrTrashedRecordNotFound    = errors.New("record not found in trash")
+	ErrGetTrashedUser           = errors.New("failed get trashed user")
+	ErrGetTrashedProduct        = errors.New("failed get trashed product")
+	ErrRestoreUser              = errors.New("failed to restore user")
+	ErrUnlockUser               = errors.New("failed to unlock user")
+	ErrRestoreProduct           = errors.New("failed to restore product")
+	ErrRestoreEmailConflict     = errors.New("cannot restore: email is used by another active user")
+	ErrRestoreMerkConflict      = errors.New("cannot restore: merk is used by another active product")
+	ErrPurgeUser                = errors.New("failed to purge user")
+	ErrPurgeProduct             = errors.New("failed to purge product")
+	ErrImportFormat             = errors.New("unsupported import format")
+	ErrImportFile               = errors.New("failed to read import file")
+	ErrImportKey                = errors.New("invalid import key")
+	ErrImportProduct            = errors.New("failed to import product")
+	ErrImportJobNotFound        = errors.New("import job not found")
+	ErrInvalidMerk              = errors.New("invalid product merk")
+	ErrExportFormat             = errors.New("unsupported export format")
+	ErrExportProduct            = errors.New("failed to export product")
+	ErrExportUser               = errors.New("failed to export user")
+	ErrBatchEmpty               = errors.New("batch has no operations")
+	ErrBatchTooLarge            = errors.New("batch has too many operations")
+	ErrBatchMode                = errors.New("invalid batch mode")
+	ErrBatchOperation           = errors.New("invalid batch operation")
+	ErrBatchRolledBack          = errors.New("rolled back: another operation in the batch failed")
+	ErrBatchSkipped             = errors.New("not attempted: another operation in the batch failed")
+	ErrIdempotencyKeyInvalid    = errors.New("idempotency key must be 1 to 255 characters")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key was already used with a different request")
+	ErrIdempotencyInFlight      = errors.New("a request with this idempotency key is still in progress")
+	ErrIdempotencyStore         = errors.New("failed to store idempotency key")
+	ErrTooManyRequests          = errors.New("rate limit exceeded, retry later")
+	ErrTooManyLoginAttempts     = errors.New("too many failed login attempts, retry later")
+	ErrAccountLocked            = errors.New("account is temporarily locked after too many failed logins")
+	ErrEmailNotVerified         = errors.New("email address is not verified")
+	ErrEmailAlreadyVerified     = errors.New("email address is already verified")
+	ErrVerifyEmail              = errors.New("failed to verify email")
+	ErrResetTokenInvalid        = errors.New("password reset token is invalid or expired")
+	ErrResetPassword            = errors.New("failed to reset password")
+	ErrMFATokenInvalid          = errors.New("mfa token is invalid or expired")
+	ErrInvalidMFACode           = errors.New("invalid two-factor code")
+	ErrMFAAlreadyEnabled        = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnabled            = errors.New("two-factor authentication is not enabled")
+	ErrMFANotEnrolling          = errors.New("start two-factor enrollment first")
+	ErrMFARequiredForRole       = errors.New("two-factor authentication is required for this role")
+	ErrEnrollMFA                = errors.New("failed to enroll two-factor authentication")
+	ErrDisableMFA               = errors.New("failed to disable two-factor authentication")
+	ErrInvalidRole              = errors.New("invalid role")
+	ErrGetMFAPolicy             = errors.New("failed get mfa policy")
+	ErrUpdateMFAPolicy          = errors.New("failed to update mfa policy")
+	ErrGetRole                  = errors.New("failed get role")
+	ErrRoleNotFound             = errors.New("role not found")
+	ErrRoleAlreadyExists        = errors.New("role already exists")
+	ErrInvalidRoleName          = errors.New("role name must be 1 to 50 lowercase letters, digits, dashes or underscores")
+	ErrCreateRole               = errors.New("failed to create role")
+	ErrUpdateRole               = errors.New("failed to update role")
+	ErrDeleteRole               = errors.New("failed to delete role")
+	ErrSystemRole               = errors.New("system roles cannot be deleted")
+	ErrAdminPermissions         = errors.New("the admin role always has every permission")
+	ErrRoleInUse                = errors.New("role is still assigned to users")
+	ErrUnknownPermission        = errors.New("unknown permission")
+	ErrGetPermission            = errors.New("failed get permission")
+	ErrAssignOwnRole            = errors.New("you cannot change your own role")
+	ErrAssignRole               = errors.New("failed to assign role")
+	ErrAPIKeyInvalid            = errors.New("api key is invalid, expired or revoked")
+	ErrAPIKeyNotFound           = errors.New("api key not found")
+	ErrInvalidAPIKeyName        = errors.New("api key name must be 1 to 100 characters")
+	ErrInvalidScope             = errors.New("api key scopes must be permissions of the owner's role")
+	ErrInvalidExpiry            = errors.New("api key expiry must be in the future")
+	ErrGetAPIKey                = errors.New("failed get api key")
+	ErrCreateAPIKey             = errors.New("failed to create api key")
+	ErrRevokeAPIKey             = errors.New("failed to revoke api key")
+	ErrAPIKeyNotAllowed         = errors.New("this action needs a signed in user, not an api key")
+	ErrServiceAccountNotFound   = errors.New("service account not found")
+	ErrGetServiceAccount        = errors.New("failed get service account")
+	ErrCreateServiceAccount     = errors.New("failed to create service account")
+	ErrCreateSession            = errors.New("failed to create session")
+	ErrGetSession               = errors.New("failed get session")
+	ErrSessionNotFound          = errors.New("session not found")
+	ErrRevokeSession            = errors.New("failed to revoke session")
+	ErrOIDCProviderNotFound     = errors.New("identity provider not found")
+	ErrOIDCStateInvalid         = errors.New("sign in request is invalid or expired, please try again")
+	ErrOIDCExchange             = errors.New("identity provider rejected the sign in")
+	ErrOIDCEmailNotVerified     = errors.New("identity provider did not verify the email address")
+	ErrOIDCAccountNotVerified   = errors.New("an account with this email address exists but is not verified, check your email for a verification link")
+	ErrOIDCLogin                = errors.New("failed to sign in with identity provider")
+	ErrAddWishlist              = errors.New("failed to add wishlist")
+	ErrRemoveWishlist           = errors.New("failed to remove wishlist")
+	ErrWishlistNotFound         = errors.New("product not in wishlist")
+	ErrGetWishlist              = errors.New("failed get wishlist")
+	ErrGetNotification          = errors.New("failed get notification")
+	ErrInvalidDateRange         = errors.New("invalid date range")
+	ErrGetPriceHistory          = errors.New("failed get price history")
+	ErrCreatePriceSchedule      = errors.New("failed to create price schedule")
+	ErrGetPriceSchedule         = errors.New("failed get price schedule")
+	ErrCancelPriceSchedule      = errors.New("failed to cancel price schedule")
+	ErrPriceScheduleOverlap     = errors.New("price schedule overlaps an existing schedule")
+	ErrPriceScheduleClosed      = errors.New("price schedule already completed or cancelled")
+	ErrGetAuditLog              = errors.New("failed get audit log")
+	ErrVersionConflict          = errors.New("version conflict: the record was modified by another request")
+	ErrVersionRequired          = errors.New("version is required, send it in the body or as If-Match")
 )
@@ -0,0 +1,134 @@
+package apikey
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/utils"
+)
+
+type (
+	IAPIKeyController interface {
+		GetAPIKeys(ctx *gin.Context)
+		CreateAPIKey(ctx *gin.Context)
+		RevokeAPIKey(ctx *gin.Context)
+		GetServiceAccounts(ctx *gin.Context)
+		CreateServiceAccount(ctx *gin.Context)
+	}
+
+	APIKeyController struct {
+		apiKeyService IAPIKeyService
+	}
+)
+
+func NewAPIKeyController(apiKeyService IAPIKeyService) *APIKeyController {
+	return &APIKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (ac *APIKeyController) GetAPIKeys(ctx *gin.Context) {
+	result, err := ac.apiKeyService.GetAPIKeys(ctx.Request.Context(), ownerID(ctx))
+	if err != nil {
+		abortAPIKey(ctx, constants.MESSAGE_FAILED_GET_API_KEY, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_API_KEY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (ac *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var payload CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	payload.UserID = ownerID(ctx)
+
+	result, err := ac.apiKeyService.CreateAPIKey(ctx.Request.Context(), payload)
+	if err != nil {
+		abortAPIKey(ctx, constants.MESSAGE_FAILED_CREATE_API_KEY, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CREATE_API_KEY, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (ac *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	payload := RevokeAPIKeyRequest{
+		ID:     ctx.Param("key_id"),
+		UserID: ownerID(ctx),
+	}
+
+	result, err := ac.apiKeyService.RevokeAPIKey(ctx.Request.Context(), payload)
+	if err != nil {
+		abortAPIKey(ctx, constants.MESSAGE_FAILED_REVOKE_API_KEY, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_REVOKE_API_KEY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (ac *APIKeyController) GetServiceAccounts(ctx *gin.Context) {
+	result, err := ac.apiKeyService.GetServiceAccounts(ctx.Request.Context())
+	if err != nil {
+		abortAPIKey(ctx, constants.MESSAGE_FAILED_GET_SERVICE_ACCOUNT, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_SERVICE_ACCOUNT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (ac *APIKeyController) CreateServiceAccount(ctx *gin.Context) {
+	var payload CreateServiceAccountRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := ac.apiKeyService.CreateServiceAccount(ctx.Request.Context(), payload)
+	if err != nil {
+		abortAPIKey(ctx, constants.MESSAGE_FAILED_CREATE_SERVICE_ACCOUNT, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CREATE_SERVICE_ACCOUNT, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+// ownerID is the service account in the path on the admin routes, and the
+// caller on their own /me routes.
+func ownerID(ctx *gin.Context) string {
+	if id := ctx.Param("id"); id != "" {
+		return id
+	}
+	return ctx.GetString("id")
+}
+
+func abortAPIKey(ctx *gin.Context, message string, err error) {
+	logging.Log.WithError(err).Warn(message)
+	res := utils.BuildResponseFailed(message, err.Error(), nil)
+
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, constants.ErrAPIKeyNotFound), errors.Is(err, constants.ErrServiceAccountNotFound),
+		errors.Is(err, constants.ErrRoleNotFound), errors.Is(err, constants.ErrGetUserByID):
+		status = http.StatusNotFound
+	case errors.Is(err, constants.ErrGetAPIKey), errors.Is(err, constants.ErrCreateAPIKey), errors.Is(err, constants.ErrRevokeAPIKey),
+		errors.Is(err, constants.ErrGetServiceAccount), errors.Is(err, constants.ErrCreateServiceAccount), errors.Is(err, constants.ErrGetPermission):
+		status = http.StatusInternalServerError
+	}
+	ctx.JSON(status, res)
+}
@@ -0,0 +1,59 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	CreateAPIKeyRequest struct {
+		UserID    string     `json:"-"`
+		Name      string     `json:"name"`
+		Scopes    []string   `json:"scopes"`
+		ExpiresAt *time.Time `json:"expires_at"`
+	}
+
+	RevokeAPIKeyRequest struct {
+		ID     string `json:"-"`
+		UserID string `json:"-"`
+	}
+
+	APIKeyResponse struct {
+		ID         uuid.UUID  `json:"id"`
+		UserID     uuid.UUID  `json:"user_id"`
+		Name       string     `json:"name"`
+		Prefix     string     `json:"prefix"`
+		Scopes     []string   `json:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		RevokedAt  *time.Time `json:"revoked_at"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	// CreateAPIKeyResponse is the only response that carries the key itself.
+	CreateAPIKeyResponse struct {
+		APIKeyResponse
+		Key string `json:"key"`
+	}
+
+	CreateServiceAccountRequest struct {
+		Name string `json:"name"`
+		Role string `json:"role"`
+	}
+
+	ServiceAccountResponse struct {
+		ID        uuid.UUID `json:"id"`
+		Name      string    `json:"name"`
+		Role      string    `json:"role"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// Principal is who a valid API key acts as.
+	Principal struct {
+		KeyID  string
+		UserID string
+		Role   string
+		Scopes []string
+	}
+)
@@ -0,0 +1,30 @@
+package apikey
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey lets an integration call the API without signing in. It acts as
+// UserID, a person or a service account, limited to Scopes. Only the
+// SHA-256 of the key is stored; Prefix is kept so owners can tell their keys
+// apart.
+type APIKey struct {
+	ID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name    string    `gorm:"not null" json:"name"`
+	Prefix  string    `gorm:"not null" json:"prefix"`
+	KeyHash string    `gorm:"not null;uniqueIndex" json:"-"`
+
+	// Scopes are permission names, see rbac.Permission. A request made with
+	// the key needs both the scope and the owner's role to grant it.
+	Scopes []string `gorm:"serializer:json;not null" json:"scopes"`
+
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
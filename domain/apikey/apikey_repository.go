@@ -0,0 +1,152 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"gorm.io/gorm"
+)
+
+type (
+	IAPIKeyRepository interface {
+		CreateAPIKey(ctx context.Context, tx *gorm.DB, key APIKey) error
+		GetAPIKeysByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]APIKey, error)
+		GetAPIKeyByID(ctx context.Context, tx *gorm.DB, id, userID string) (APIKey, bool, error)
+		GetAPIKeyByHash(ctx context.Context, tx *gorm.DB, keyHash string) (APIKey, bool, error)
+		RevokeAPIKey(ctx context.Context, tx *gorm.DB, id string, now time.Time) error
+		TouchAPIKey(ctx context.Context, tx *gorm.DB, id string, now, before time.Time) error
+
+		GetOwner(ctx context.Context, tx *gorm.DB, userID string) (user.User, bool, error)
+		CreateServiceAccount(ctx context.Context, tx *gorm.DB, account user.User) error
+		GetServiceAccounts(ctx context.Context, tx *gorm.DB) ([]user.User, error)
+	}
+
+	APIKeyRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewAPIKeyRepository(db *gorm.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		db: db,
+	}
+}
+
+func (ar *APIKeyRepository) CreateAPIKey(ctx context.Context, tx *gorm.DB, key APIKey) error {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	return tx.WithContext(ctx).Create(&key).Error
+}
+
+func (ar *APIKeyRepository) GetAPIKeysByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]APIKey, error) {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	var keys []APIKey
+	if err := tx.WithContext(ctx).Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+func (ar *APIKeyRepository) GetAPIKeyByID(ctx context.Context, tx *gorm.DB, id, userID string) (APIKey, bool, error) {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	var key APIKey
+	if err := tx.WithContext(ctx).Where("id = ? AND user_id = ?", id, userID).Take(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return APIKey{}, false, nil
+		}
+		return APIKey{}, false, err
+	}
+
+	return key, true, nil
+}
+
+func (ar *APIKeyRepository) GetAPIKeyByHash(ctx context.Context, tx *gorm.DB, keyHash string) (APIKey, bool, error) {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	var key APIKey
+	if err := tx.WithContext(ctx).Where("key_hash = ?", keyHash).Take(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return APIKey{}, false, nil
+		}
+		return APIKey{}, false, err
+	}
+
+	return key, true, nil
+}
+
+func (ar *APIKeyRepository) RevokeAPIKey(ctx context.Context, tx *gorm.DB, id string, now time.Time) error {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	return tx.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		UpdateColumn("revoked_at", now).Error
+}
+
+// TouchAPIKey records a use of the key unless one was already recorded
+// since before, so busy keys do not cost a write on every request.
+func (ar *APIKeyRepository) TouchAPIKey(ctx context.Context, tx *gorm.DB, id string, now, before time.Time) error {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	return tx.WithContext(ctx).Model(&APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", id, before).
+		UpdateColumn("last_used_at", now).Error
+}
+
+// GetOwner looks up the user a key belongs to. Deleted users are not found,
+// so their keys stop working with them.
+func (ar *APIKeyRepository) GetOwner(ctx context.Context, tx *gorm.DB, userID string) (user.User, bool, error) {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	var owner user.User
+	err := tx.WithContext(ctx).Select("id", "name", "role", "service_account", "created_at").
+		Where("id = ?", userID).Take(&owner).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user.User{}, false, nil
+		}
+		return user.User{}, false, err
+	}
+
+	return owner, true, nil
+}
+
+func (ar *APIKeyRepository) CreateServiceAccount(ctx context.Context, tx *gorm.DB, account user.User) error {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	return tx.WithContext(ctx).Create(&account).Error
+}
+
+func (ar *APIKeyRepository) GetServiceAccounts(ctx context.Context, tx *gorm.DB) ([]user.User, error) {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	var accounts []user.User
+	err := tx.WithContext(ctx).Where("service_account = ?", true).Order("created_at ASC").Find(&accounts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
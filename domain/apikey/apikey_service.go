@@ -0,0 +1,364 @@
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const (
+	// Keys look like gqk_<43 characters>; the prefix makes leaked keys easy
+	// to spot and lets Authenticate reject anything else without a lookup.
+	apiKeyPrefix      = "gqk_"
+	apiKeyBytes       = 32
+	apiKeyShownPrefix = len(apiKeyPrefix) + 8
+
+	// Uses of a key are recorded at most once per apiKeyTouchInterval.
+	apiKeyTouchInterval = time.Minute
+
+	serviceAccountEmailDomain = "service-accounts.invalid"
+)
+
+type (
+	// Authenticator is what middleware.Authentication needs to accept an
+	// X-API-Key header.
+	Authenticator interface {
+		AuthenticateAPIKey(ctx context.Context, key string) (Principal, error)
+	}
+
+	IAPIKeyService interface {
+		Authenticator
+		GetAPIKeys(ctx context.Context, userID string) ([]APIKeyResponse, error)
+		CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (CreateAPIKeyResponse, error)
+		RevokeAPIKey(ctx context.Context, req RevokeAPIKeyRequest) (APIKeyResponse, error)
+
+		GetServiceAccounts(ctx context.Context) ([]ServiceAccountResponse, error)
+		CreateServiceAccount(ctx context.Context, req CreateServiceAccountRequest) (ServiceAccountResponse, error)
+	}
+
+	APIKeyService struct {
+		apiKeyRepo IAPIKeyRepository
+		rbacRepo   rbac.IRBACRepository
+	}
+)
+
+func NewAPIKeyService(apiKeyRepo IAPIKeyRepository, rbacRepo rbac.IRBACRepository) *APIKeyService {
+	return &APIKeyService{
+		apiKeyRepo: apiKeyRepo,
+		rbacRepo:   rbacRepo,
+	}
+}
+
+// AuthenticateAPIKey resolves key to the user it acts as. Unknown, expired
+// and revoked keys, and keys of deleted users, all get ErrAPIKeyInvalid.
+// The role is read from the owner on every request, so role changes apply
+// to existing keys right away.
+func (as *APIKeyService) AuthenticateAPIKey(ctx context.Context, key string) (Principal, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return Principal{}, constants.ErrAPIKeyInvalid
+	}
+
+	apiKey, found, err := as.apiKeyRepo.GetAPIKeyByHash(ctx, nil, hashAPIKey(key))
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_API_KEY)
+		return Principal{}, constants.ErrGetAPIKey
+	}
+
+	now := time.Now()
+	if !found || apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt)) {
+		return Principal{}, constants.ErrAPIKeyInvalid
+	}
+
+	owner, found, err := as.apiKeyRepo.GetOwner(ctx, nil, apiKey.UserID.String())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_API_KEY)
+		return Principal{}, constants.ErrGetAPIKey
+	}
+	if !found {
+		return Principal{}, constants.ErrAPIKeyInvalid
+	}
+
+	if apiKey.LastUsedAt == nil || now.Sub(*apiKey.LastUsedAt) >= apiKeyTouchInterval {
+		if err := as.apiKeyRepo.TouchAPIKey(ctx, nil, apiKey.ID.String(), now, now.Add(-apiKeyTouchInterval)); err != nil {
+			logging.Log.WithError(err).WithField("id", apiKey.ID).Warn("failed record api key use")
+		}
+	}
+
+	return Principal{
+		KeyID:  apiKey.ID.String(),
+		UserID: owner.ID.String(),
+		Role:   owner.Role,
+		Scopes: apiKey.Scopes,
+	}, nil
+}
+
+func (as *APIKeyService) GetAPIKeys(ctx context.Context, userID string) ([]APIKeyResponse, error) {
+	if _, err := as.getOwner(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	keys, err := as.apiKeyRepo.GetAPIKeysByUserID(ctx, nil, userID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_API_KEY)
+		return nil, constants.ErrGetAPIKey
+	}
+
+	datas := make([]APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		datas = append(datas, toAPIKeyResponse(key))
+	}
+
+	return datas, nil
+}
+
+// CreateAPIKey issues a key for req.UserID. The key is only returned here;
+// afterwards just its prefix is known. Scopes must all be granted by the
+// owner's role at the time.
+func (as *APIKeyService) CreateAPIKey(ctx context.Context, req CreateAPIKeyRequest) (CreateAPIKeyResponse, error) {
+	owner, err := as.getOwner(ctx, req.UserID)
+	if err != nil {
+		return CreateAPIKeyResponse{}, err
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > 100 {
+		return CreateAPIKeyResponse{}, constants.ErrInvalidAPIKeyName
+	}
+
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return CreateAPIKeyResponse{}, constants.ErrInvalidExpiry
+	}
+
+	scopes, err := as.checkScopes(ctx, owner.Role, req.Scopes)
+	if err != nil {
+		return CreateAPIKeyResponse{}, err
+	}
+
+	raw := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_API_KEY)
+		return CreateAPIKeyResponse{}, constants.ErrCreateAPIKey
+	}
+	key := apiKeyPrefix + base64.RawURLEncoding.EncodeToString(raw)
+
+	apiKey := APIKey{
+		ID:        uuid.New(),
+		UserID:    owner.ID,
+		Name:      req.Name,
+		Prefix:    key[:apiKeyShownPrefix],
+		KeyHash:   hashAPIKey(key),
+		Scopes:    scopes,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: now,
+	}
+	if actorID, err := uuid.Parse(helpers.GetUserID(ctx)); err == nil {
+		apiKey.CreatedBy = &actorID
+	}
+
+	if err := as.apiKeyRepo.CreateAPIKey(ctx, nil, apiKey); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_API_KEY)
+		return CreateAPIKeyResponse{}, constants.ErrCreateAPIKey
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_CREATE_API_KEY+": %s for %s", apiKey.Prefix, owner.ID)
+
+	return CreateAPIKeyResponse{
+		APIKeyResponse: toAPIKeyResponse(apiKey),
+		Key:            key,
+	}, nil
+}
+
+// RevokeAPIKey stops a key from working. Revoking a revoked key returns it
+// unchanged.
+func (as *APIKeyService) RevokeAPIKey(ctx context.Context, req RevokeAPIKeyRequest) (APIKeyResponse, error) {
+	if _, err := uuid.Parse(req.ID); err != nil {
+		return APIKeyResponse{}, constants.ErrInvalidUUID
+	}
+
+	if _, err := as.getOwner(ctx, req.UserID); err != nil {
+		return APIKeyResponse{}, err
+	}
+
+	apiKey, found, err := as.apiKeyRepo.GetAPIKeyByID(ctx, nil, req.ID, req.UserID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_REVOKE_API_KEY)
+		return APIKeyResponse{}, constants.ErrRevokeAPIKey
+	}
+	if !found {
+		return APIKeyResponse{}, constants.ErrAPIKeyNotFound
+	}
+
+	if apiKey.RevokedAt == nil {
+		now := time.Now()
+		if err := as.apiKeyRepo.RevokeAPIKey(ctx, nil, req.ID, now); err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_REVOKE_API_KEY)
+			return APIKeyResponse{}, constants.ErrRevokeAPIKey
+		}
+		apiKey.RevokedAt = &now
+
+		logging.Log.Infof(constants.MESSAGE_SUCCESS_REVOKE_API_KEY+": %s", apiKey.Prefix)
+	}
+
+	return toAPIKeyResponse(apiKey), nil
+}
+
+func (as *APIKeyService) GetServiceAccounts(ctx context.Context) ([]ServiceAccountResponse, error) {
+	accounts, err := as.apiKeyRepo.GetServiceAccounts(ctx, nil)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_SERVICE_ACCOUNT)
+		return nil, constants.ErrGetServiceAccount
+	}
+
+	datas := make([]ServiceAccountResponse, 0, len(accounts))
+	for _, account := range accounts {
+		datas = append(datas, toServiceAccountResponse(account))
+	}
+
+	return datas, nil
+}
+
+// CreateServiceAccount adds a user that cannot sign in, for integrations to
+// own API keys with. Its password is random and never shown.
+func (as *APIKeyService) CreateServiceAccount(ctx context.Context, req CreateServiceAccountRequest) (ServiceAccountResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || utf8.RuneCountInString(req.Name) > 100 {
+		return ServiceAccountResponse{}, constants.ErrInvalidName
+	}
+
+	_, found, err := as.rbacRepo.GetRoleByName(ctx, nil, req.Role)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_SERVICE_ACCOUNT)
+		return ServiceAccountResponse{}, constants.ErrCreateServiceAccount
+	}
+	if !found {
+		return ServiceAccountResponse{}, constants.ErrRoleNotFound
+	}
+
+	raw := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(raw); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_SERVICE_ACCOUNT)
+		return ServiceAccountResponse{}, constants.ErrCreateServiceAccount
+	}
+
+	id := uuid.New()
+	account := user.User{
+		ID:             id,
+		Name:           req.Name,
+		Email:          id.String() + "@" + serviceAccountEmailDomain,
+		Password:       base64.RawURLEncoding.EncodeToString(raw),
+		Role:           req.Role,
+		ServiceAccount: true,
+		CreatedAt:      time.Now(),
+		Version:        1,
+	}
+	if actorID, err := uuid.Parse(helpers.GetUserID(ctx)); err == nil {
+		account.CreatedBy = &actorID
+	}
+
+	if err := as.apiKeyRepo.CreateServiceAccount(ctx, nil, account); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_SERVICE_ACCOUNT)
+		return ServiceAccountResponse{}, constants.ErrCreateServiceAccount
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_CREATE_SERVICE_ACCOUNT+": %s (%s)", account.Name, account.ID)
+
+	return toServiceAccountResponse(account), nil
+}
+
+// getOwner loads the owner of the keys being managed. The actor may manage
+// their own keys; the keys of anyone else can only be managed when that is a
+// service account, which routes leave to admins.
+func (as *APIKeyService) getOwner(ctx context.Context, userID string) (user.User, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return user.User{}, constants.ErrInvalidUUID
+	}
+
+	owner, found, err := as.apiKeyRepo.GetOwner(ctx, nil, userID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_API_KEY)
+		return user.User{}, constants.ErrGetAPIKey
+	}
+
+	if userID == helpers.GetUserID(ctx) {
+		if !found {
+			return user.User{}, constants.ErrGetUserByID
+		}
+		return owner, nil
+	}
+
+	if !found || !owner.ServiceAccount {
+		return user.User{}, constants.ErrServiceAccountNotFound
+	}
+
+	return owner, nil
+}
+
+// checkScopes drops duplicate scopes and fails unless there is at least one
+// and role grants all of them.
+func (as *APIKeyService) checkScopes(ctx context.Context, role string, scopes []string) ([]string, error) {
+	permissions, err := as.rbacRepo.GetPermissionsByRoleName(ctx, nil, role)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_PERMISSION)
+		return nil, constants.ErrGetPermission
+	}
+
+	checked := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		if slices.Contains(checked, scope) {
+			continue
+		}
+		if !slices.Contains(permissions, scope) {
+			logging.Log.Warnf(constants.MESSAGE_FAILED_CREATE_API_KEY+": %s does not grant %s", role, scope)
+			return nil, constants.ErrInvalidScope
+		}
+		checked = append(checked, scope)
+	}
+
+	if len(checked) == 0 {
+		return nil, constants.ErrInvalidScope
+	}
+
+	return checked, nil
+}
+
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+func toAPIKeyResponse(key APIKey) APIKeyResponse {
+	return APIKeyResponse{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+func toServiceAccountResponse(account user.User) ServiceAccountResponse {
+	return ServiceAccountResponse{
+		ID:        account.ID,
+		Name:      account.Name,
+		Role:      account.Role,
+		CreatedAt: account.CreatedAt,
+	}
+}
@@ -0,0 +1,386 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"gorm.io/gorm"
+)
+
+const (
+	COLUMN_CREATED_BY = "created_by"
+	COLUMN_UPDATED_BY = "updated_by"
+	COLUMN_DELETED_BY = "deleted_by"
+
+	primaryKeyColumn = "id"
+	redactedValue    = "[REDACTED]"
+
+	settingSkip     = "audit:skip"
+	settingSnapshot = "audit:snapshot"
+	settingAction   = "audit:action"
+)
+
+// Columns that must never be copied into the audit trail. A change is still
+// recorded, but the values are replaced with redactedValue.
+var redactedColumns = map[string]bool{
+	"password":            true,
+	"totp_secret":         true,
+	"pending_totp_secret": true,
+}
+
+// Skip switches auditing off for statements run on the returned DB. Meant
+// for bookkeeping columns that would otherwise flood the trail.
+func Skip(tx *gorm.DB) *gorm.DB {
+	return tx.Set(settingSkip, true)
+}
+
+// WithAction records updates run on the returned DB under action instead of
+// ACTION_UPDATE, for changes that mean more than an edit.
+func WithAction(tx *gorm.DB, action string) *gorm.DB {
+	return tx.Set(settingAction, action)
+}
+
+type auditor struct {
+	tables map[string]bool
+}
+
+// RegisterCallbacks hooks into GORM so every create, update and delete on the
+// given tables fills created_by/updated_by/deleted_by from the actor stored in
+// the statement context and writes a before/after diff to audit_logs. The
+// audit rows are written in the same transaction as the change itself.
+func RegisterCallbacks(db *gorm.DB, tables ...string) error {
+	a := &auditor{tables: make(map[string]bool, len(tables))}
+	for _, table := range tables {
+		a.tables[table] = true
+	}
+
+	callback := db.Callback()
+
+	if err := callback.Create().Before("gorm:create").Register("audit:before_create", a.beforeCreate); err != nil {
+		return err
+	}
+	if err := callback.Create().After("gorm:create").Register("audit:after_create", a.afterCreate); err != nil {
+		return err
+	}
+	if err := callback.Update().Before("gorm:update").Register("audit:before_update", a.beforeUpdate); err != nil {
+		return err
+	}
+	if err := callback.Update().After("gorm:update").Register("audit:after_update", a.afterWrite(ACTION_UPDATE)); err != nil {
+		return err
+	}
+	if err := callback.Delete().Before("gorm:delete").Register("audit:before_delete", a.beforeDelete); err != nil {
+		return err
+	}
+	return callback.Delete().After("gorm:delete").Register("audit:after_delete", a.afterWrite(ACTION_DELETE))
+}
+
+func (a *auditor) tracked(db *gorm.DB) bool {
+	if db.Error != nil || !a.tables[db.Statement.Table] {
+		return false
+	}
+
+	_, skip := db.Get(settingSkip)
+	return !skip
+}
+
+func (a *auditor) beforeCreate(db *gorm.DB) {
+	if !a.tracked(db) {
+		return
+	}
+
+	if actor := actorID(db); actor != nil {
+		setColumn(db, COLUMN_CREATED_BY, actor)
+		setColumn(db, COLUMN_UPDATED_BY, actor)
+	}
+}
+
+func (a *auditor) afterCreate(db *gorm.DB) {
+	if !a.tracked(db) || db.Statement.RowsAffected == 0 {
+		return
+	}
+
+	ids := primaryKeys(db)
+	if len(ids) == 0 {
+		return
+	}
+
+	after, err := snapshot(session(db).Where(primaryKeyColumn+" IN ?", ids))
+	if err != nil {
+		db.AddError(err)
+		return
+	}
+
+	a.record(db, ACTION_CREATE, nil, after)
+}
+
+func (a *auditor) beforeUpdate(db *gorm.DB) {
+	if !a.tracked(db) {
+		return
+	}
+
+	if actor := actorID(db); actor != nil {
+		setColumn(db, COLUMN_UPDATED_BY, actor)
+	}
+
+	a.takeSnapshot(db)
+}
+
+func (a *auditor) beforeDelete(db *gorm.DB) {
+	if !a.tracked(db) {
+		return
+	}
+
+	if !a.takeSnapshot(db) {
+		return
+	}
+
+	// Soft deletes only touch deleted_at, so stamp deleted_by on the rows
+	// about to be trashed first. Runs inside the delete's own transaction.
+	actor := actorID(db)
+	if actor == nil || db.Statement.Unscoped || db.Statement.Schema == nil ||
+		db.Statement.Schema.LookUpField(COLUMN_DELETED_BY) == nil ||
+		db.Statement.Schema.LookUpField("deleted_at") == nil {
+		return
+	}
+
+	db.AddError(conditions(db).
+		Where("deleted_at IS NULL").
+		UpdateColumn(COLUMN_DELETED_BY, actor).Error)
+}
+
+// takeSnapshot loads the rows matched by the pending statement so the after
+// callback can diff against them.
+func (a *auditor) takeSnapshot(db *gorm.DB) bool {
+	tx := conditions(db)
+	if tx == nil {
+		return false
+	}
+
+	before, err := snapshot(tx)
+	if err != nil {
+		db.AddError(err)
+		return false
+	}
+
+	db.InstanceSet(settingSnapshot, before)
+	return true
+}
+
+func (a *auditor) afterWrite(action string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if !a.tracked(db) || db.Statement.RowsAffected == 0 {
+			return
+		}
+
+		value, ok := db.InstanceGet(settingSnapshot)
+		if !ok {
+			return
+		}
+
+		before := value.(map[string]map[string]any)
+		if len(before) == 0 {
+			return
+		}
+
+		ids := make([]string, 0, len(before))
+		for id := range before {
+			ids = append(ids, id)
+		}
+
+		after, err := snapshot(session(db).Where(primaryKeyColumn+" IN ?", ids))
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+
+		if override, ok := db.Get(settingAction); ok {
+			a.record(db, override.(string), before, after)
+			return
+		}
+		a.record(db, action, before, after)
+	}
+}
+
+// snapshot reads raw rows, keyed by primary key, without going through the
+// model so soft-deleted rows are visible too.
+func snapshot(tx *gorm.DB) (map[string]map[string]any, error) {
+	var rows []map[string]any
+	if err := tx.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]map[string]any, len(rows))
+	for _, row := range rows {
+		result[fmt.Sprint(row[primaryKeyColumn])] = row
+	}
+	return result, nil
+}
+
+func (a *auditor) record(db *gorm.DB, action string, before, after map[string]map[string]any) {
+	actor := actorID(db)
+
+	ids := make(map[string]bool, len(before)+len(after))
+	for id := range before {
+		ids[id] = true
+	}
+	for id := range after {
+		ids[id] = true
+	}
+
+	logs := make([]AuditLog, 0, len(ids))
+	for id := range ids {
+		oldRow, newRow, changed := diff(before[id], after[id])
+		if !changed {
+			continue
+		}
+
+		oldJSON, err := marshalRow(oldRow)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+		newJSON, err := marshalRow(newRow)
+		if err != nil {
+			db.AddError(err)
+			return
+		}
+
+		logs = append(logs, AuditLog{
+			ID:         uuid.New(),
+			EntityType: db.Statement.Table,
+			EntityID:   id,
+			Action:     action,
+			ActorID:    actor,
+			Before:     oldJSON,
+			After:      newJSON,
+		})
+	}
+
+	if len(logs) == 0 {
+		return
+	}
+
+	db.AddError(db.Session(&gorm.Session{NewDB: true}).Create(&logs).Error)
+}
+
+// diff keeps only the columns that changed. A missing side (create or hard
+// delete) keeps the full row of the other side.
+func diff(before, after map[string]any) (map[string]any, map[string]any, bool) {
+	switch {
+	case before == nil && after == nil:
+		return nil, nil, false
+	case before == nil:
+		return nil, redact(after), true
+	case after == nil:
+		return redact(before), nil, true
+	}
+
+	oldRow := make(map[string]any)
+	newRow := make(map[string]any)
+	for column, newValue := range after {
+		if column == "updated_at" || sameValue(before[column], newValue) {
+			continue
+		}
+		oldRow[column] = before[column]
+		newRow[column] = newValue
+	}
+
+	if len(newRow) == 0 {
+		return nil, nil, false
+	}
+	return redact(oldRow), redact(newRow), true
+}
+
+func redact(row map[string]any) map[string]any {
+	for column := range row {
+		if redactedColumns[column] {
+			row[column] = redactedValue
+		}
+	}
+	return row
+}
+
+func sameValue(a, b any) bool {
+	left, errLeft := json.Marshal(a)
+	right, errRight := json.Marshal(b)
+	if errLeft != nil || errRight != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	return bytes.Equal(left, right)
+}
+
+func marshalRow(row map[string]any) (JSON, error) {
+	if row == nil {
+		return nil, nil
+	}
+	return json.Marshal(row)
+}
+
+// session opens a fresh statement on the same connection and transaction as
+// db, against the raw table and with auditing switched off.
+func session(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{NewDB: true}).Set(settingSkip, true).Table(db.Statement.Table)
+}
+
+// conditions rebuilds the WHERE of the pending statement. Statements keyed
+// only by the primary key of their model value fall back to that key.
+func conditions(db *gorm.DB) *gorm.DB {
+	tx := session(db)
+
+	if where, ok := db.Statement.Clauses["WHERE"]; ok && where.Expression != nil {
+		return tx.Clauses(where.Expression)
+	}
+
+	if ids := primaryKeys(db); len(ids) > 0 {
+		return tx.Where(primaryKeyColumn+" IN ?", ids)
+	}
+
+	return nil
+}
+
+func primaryKeys(db *gorm.DB) []any {
+	if db.Statement.Schema == nil || db.Statement.Schema.PrioritizedPrimaryField == nil {
+		return nil
+	}
+
+	field := db.Statement.Schema.PrioritizedPrimaryField
+	value := reflect.Indirect(db.Statement.ReflectValue)
+
+	var ids []any
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if id, zero := field.ValueOf(db.Statement.Context, reflect.Indirect(value.Index(i))); !zero {
+				ids = append(ids, id)
+			}
+		}
+	case reflect.Struct:
+		if id, zero := field.ValueOf(db.Statement.Context, value); !zero {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+func setColumn(db *gorm.DB, column string, value any) {
+	if db.Statement.Schema == nil || db.Statement.Schema.LookUpField(column) == nil {
+		return
+	}
+	db.Statement.SetColumn(column, value, true)
+}
+
+func actorID(db *gorm.DB) *uuid.UUID {
+	if db.Statement.Context == nil {
+		return nil
+	}
+
+	id, err := uuid.Parse(helpers.GetUserID(db.Statement.Context))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
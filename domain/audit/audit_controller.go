@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/utils"
+)
+
+type (
+	IAuditController interface {
+		GetAuditLogs(ctx *gin.Context)
+		GetEntityAuditLogs(ctx *gin.Context)
+		GetActorAuditLogs(ctx *gin.Context)
+	}
+
+	AuditController struct {
+		auditService IAuditService
+	}
+)
+
+func NewAuditController(auditService IAuditService) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+	}
+}
+
+func (ac *AuditController) GetAuditLogs(ctx *gin.Context) {
+	ac.getAuditLogs(ctx, nil)
+}
+
+func (ac *AuditController) GetEntityAuditLogs(ctx *gin.Context) {
+	ac.getAuditLogs(ctx, func(query *AuditLogPaginationRequest) {
+		query.EntityType = ctx.Param("entity_type")
+		query.EntityID = ctx.Param("entity_id")
+	})
+}
+
+func (ac *AuditController) GetActorAuditLogs(ctx *gin.Context) {
+	ac.getAuditLogs(ctx, func(query *AuditLogPaginationRequest) {
+		query.ActorID = ctx.Param("actor_id")
+	})
+}
+
+func (ac *AuditController) getAuditLogs(ctx *gin.Context, fromPath func(query *AuditLogPaginationRequest)) {
+	var query AuditLogPaginationRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	if fromPath != nil {
+		fromPath(&query)
+	}
+
+	result, err := ac.auditService.GetAuditLogs(ctx.Request.Context(), query)
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_AUDIT_LOG, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.Response{
+		Status:   true,
+		Messsage: constants.MESSAGE_SUCCESS_GET_AUDIT_LOG,
+		Data:     result.Data,
+		Meta:     result.PaginationResponse,
+	}
+	ctx.JSON(http.StatusOK, res)
+}
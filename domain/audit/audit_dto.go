@@ -0,0 +1,54 @@
+package audit
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	AuditLogPaginationRequest struct {
+		PaginationRequest
+		EntityType string `form:"entity_type"`
+		EntityID   string `form:"entity_id"`
+		ActorID    string `form:"actor_id"`
+		Action     string `form:"action"`
+	}
+
+	AuditLogResponse struct {
+		ID         uuid.UUID  `json:"id"`
+		EntityType string     `json:"entity_type"`
+		EntityID   string     `json:"entity_id"`
+		Action     string     `json:"action"`
+		ActorID    *uuid.UUID `json:"actor_id"`
+		Before     JSON       `json:"before"`
+		After      JSON       `json:"after"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+
+	AuditLogPaginationResponse struct {
+		PaginationResponse
+		Data []AuditLogResponse `json:"data"`
+	}
+
+	AuditLogPaginationRepositoryResponse struct {
+		PaginationResponse
+		AuditLogs []AuditLog
+	}
+
+	PaginationRequest struct {
+		Page    int `form:"page"`
+		PerPage int `form:"per_page"`
+	}
+
+	PaginationResponse struct {
+		Page    int   `json:"page"`
+		PerPage int   `json:"per_page"`
+		MaxPage int64 `json:"max_page"`
+		Count   int64 `json:"count"`
+	}
+)
+
+func (p *PaginationRequest) GetOffset() int {
+	return (p.Page - 1) * p.PerPage
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ACTION_CREATE = "create"
+	ACTION_UPDATE = "update"
+	ACTION_DELETE = "delete"
+	ACTION_LOCK   = "lock"
+	ACTION_UNLOCK = "unlock"
+
+	ACTION_ENABLE_MFA  = "enable_mfa"
+	ACTION_DISABLE_MFA = "disable_mfa"
+
+	ACTION_ASSIGN_ROLE = "assign_role"
+)
+
+type AuditLog struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	EntityType string     `gorm:"not null;index:idx_audit_logs_entity" json:"entity_type"`
+	EntityID   string     `gorm:"not null;index:idx_audit_logs_entity" json:"entity_id"`
+	Action     string     `gorm:"not null" json:"action"`
+	ActorID    *uuid.UUID `gorm:"type:uuid;index" json:"actor_id"`
+	Before     JSON       `gorm:"type:jsonb" json:"before"`
+	After      JSON       `gorm:"type:jsonb" json:"after"`
+
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// JSON is a raw jsonb column that is passed through to API responses as-is.
+type JSON []byte
+
+func (j JSON) Value() (driver.Value, error) {
+	if len(j) == 0 {
+		return nil, nil
+	}
+	return string(j), nil
+}
+
+func (j *JSON) Scan(value any) error {
+	switch v := value.(type) {
+	case nil:
+		*j = nil
+	case []byte:
+		*j = append((*j)[:0], v...)
+	case string:
+		*j = JSON(v)
+	default:
+		return fmt.Errorf("unsupported jsonb value %T", value)
+	}
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if len(j) == 0 {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
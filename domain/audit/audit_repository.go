@@ -0,0 +1,87 @@
+package audit
+
+import (
+	"context"
+	"math"
+
+	"gorm.io/gorm"
+)
+
+type (
+	IAuditRepository interface {
+		GetAuditLogs(ctx context.Context, tx *gorm.DB, req AuditLogPaginationRequest) (AuditLogPaginationRepositoryResponse, error)
+	}
+
+	AuditRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{
+		db: db,
+	}
+}
+
+// Pagination
+func Paginate(page, perPage int) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		offset := (page - 1) * perPage
+		return db.Offset(offset).Limit(perPage)
+	}
+}
+
+func (ar *AuditRepository) GetAuditLogs(ctx context.Context, tx *gorm.DB, req AuditLogPaginationRequest) (AuditLogPaginationRepositoryResponse, error) {
+	if tx == nil {
+		tx = ar.db
+	}
+
+	var logs []AuditLog
+	var count int64
+
+	if req.PaginationRequest.PerPage == 0 {
+		req.PaginationRequest.PerPage = 10
+	}
+
+	if req.PaginationRequest.Page == 0 {
+		req.PaginationRequest.Page = 1
+	}
+
+	query := tx.WithContext(ctx).Model(&AuditLog{})
+
+	if req.EntityType != "" {
+		query = query.Where("entity_type = ?", req.EntityType)
+	}
+
+	if req.EntityID != "" {
+		query = query.Where("entity_id = ?", req.EntityID)
+	}
+
+	if req.ActorID != "" {
+		query = query.Where("actor_id = ?", req.ActorID)
+	}
+
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return AuditLogPaginationRepositoryResponse{}, err
+	}
+
+	if err := query.Order("created_at DESC").Scopes(Paginate(req.PaginationRequest.Page, req.PaginationRequest.PerPage)).Find(&logs).Error; err != nil {
+		return AuditLogPaginationRepositoryResponse{}, err
+	}
+
+	totalPage := int64(math.Ceil(float64(count) / float64(req.PaginationRequest.PerPage)))
+
+	return AuditLogPaginationRepositoryResponse{
+		AuditLogs: logs,
+		PaginationResponse: PaginationResponse{
+			Page:    req.PaginationRequest.Page,
+			PerPage: req.PaginationRequest.PerPage,
+			MaxPage: totalPage,
+			Count:   count,
+		},
+	}, nil
+}
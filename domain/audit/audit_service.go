@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+type (
+	IAuditService interface {
+		GetAuditLogs(ctx context.Context, req AuditLogPaginationRequest) (AuditLogPaginationResponse, error)
+	}
+
+	AuditService struct {
+		auditRepo IAuditRepository
+	}
+)
+
+func NewAuditService(auditRepo IAuditRepository) *AuditService {
+	return &AuditService{
+		auditRepo: auditRepo,
+	}
+}
+
+func (as *AuditService) GetAuditLogs(ctx context.Context, req AuditLogPaginationRequest) (AuditLogPaginationResponse, error) {
+	if req.ActorID != "" {
+		if _, err := uuid.Parse(req.ActorID); err != nil {
+			logging.Log.Warn(constants.MESSAGE_FAILED_GET_AUDIT_LOG + ": invalid actor UUID")
+			return AuditLogPaginationResponse{}, constants.ErrInvalidUUID
+		}
+	}
+
+	dataWithPaginate, err := as.auditRepo.GetAuditLogs(ctx, nil, req)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_AUDIT_LOG)
+		return AuditLogPaginationResponse{}, constants.ErrGetAuditLog
+	}
+
+	datas := make([]AuditLogResponse, 0, len(dataWithPaginate.AuditLogs))
+	for _, log := range dataWithPaginate.AuditLogs {
+		datas = append(datas, AuditLogResponse{
+			ID:         log.ID,
+			EntityType: log.EntityType,
+			EntityID:   log.EntityID,
+			Action:     log.Action,
+			ActorID:    log.ActorID,
+			Before:     log.Before,
+			After:      log.After,
+			CreatedAt:  log.CreatedAt,
+		})
+	}
+
+	return AuditLogPaginationResponse{
+		Data:               datas,
+		PaginationResponse: dataWithPaginate.PaginationResponse,
+	}, nil
+}
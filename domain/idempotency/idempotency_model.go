@@ -0,0 +1,34 @@
+package idempotency
+
+import "time"
+
+const (
+	STATUS_IN_PROGRESS = "in_progress"
+	STATUS_COMPLETED   = "completed"
+
+	// SCOPE_ANONYMOUS scopes keys sent on unauthenticated requests.
+	SCOPE_ANONYMOUS = "anonymous"
+)
+
+// IdempotencyKey remembers the outcome of an unsafe request sent with an
+// Idempotency-Key header. Keys are scoped per actor so two users cannot see
+// each other's responses by guessing a key.
+type IdempotencyKey struct {
+	Key         string `gorm:"primaryKey;size:255" json:"key"`
+	Scope       string `gorm:"primaryKey;size:64" json:"scope"`
+	Fingerprint string `gorm:"not null;size:64" json:"fingerprint"`
+	Status      string `gorm:"not null" json:"status"`
+
+	ResponseStatus      int    `json:"response_status"`
+	ResponseContentType string `json:"response_content_type"`
+	ResponseBody        []byte `gorm:"type:bytea" json:"-"`
+
+	// LockedUntil bounds how long an in-progress request holds the key; a
+	// retry after that takes it over, so a crashed request cannot block
+	// the key until it expires.
+	LockedUntil time.Time `gorm:"not null" json:"locked_until"`
+	ExpiresAt   time.Time `gorm:"not null;index" json:"expires_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
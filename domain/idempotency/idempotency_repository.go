@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	IIdempotencyRepository interface {
+		Acquire(ctx context.Context, tx *gorm.DB, record IdempotencyKey) (IdempotencyKey, bool, error)
+		Complete(ctx context.Context, tx *gorm.DB, record IdempotencyKey) error
+		Release(ctx context.Context, tx *gorm.DB, key, scope string) error
+		PurgeExpired(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error)
+	}
+
+	IdempotencyRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewIdempotencyRepository(db *gorm.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{
+		db: db,
+	}
+}
+
+// Acquire claims record.Key for the caller. It reports true when the key was
+// free, had expired, or was held by an in-progress request with the same
+// fingerprint whose lock has run out. Otherwise it returns the stored record
+// so the caller can replay or reject.
+func (ir *IdempotencyRepository) Acquire(ctx context.Context, tx *gorm.DB, record IdempotencyKey) (IdempotencyKey, bool, error) {
+	if tx == nil {
+		tx = ir.db
+	}
+
+	result := tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&record)
+	if result.Error != nil {
+		return IdempotencyKey{}, false, result.Error
+	}
+	if result.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	var existing IdempotencyKey
+	if err := tx.WithContext(ctx).Where("key = ? AND scope = ?", record.Key, record.Scope).Take(&existing).Error; err != nil {
+		return IdempotencyKey{}, false, err
+	}
+
+	now := time.Now()
+	expired := existing.ExpiresAt.Before(now)
+	abandoned := existing.Status == STATUS_IN_PROGRESS &&
+		existing.LockedUntil.Before(now) &&
+		existing.Fingerprint == record.Fingerprint
+	if !expired && !abandoned {
+		return existing, false, nil
+	}
+
+	// Compare-and-swap on the lock so only one of several retries racing
+	// for a stale key wins it.
+	result = tx.WithContext(ctx).Model(&IdempotencyKey{}).
+		Where("key = ? AND scope = ? AND status = ? AND locked_until = ?",
+			existing.Key, existing.Scope, existing.Status, existing.LockedUntil).
+		Updates(map[string]interface{}{
+			"fingerprint":           record.Fingerprint,
+			"status":                STATUS_IN_PROGRESS,
+			"response_status":       0,
+			"response_content_type": "",
+			"response_body":         nil,
+			"locked_until":          record.LockedUntil,
+			"expires_at":            record.ExpiresAt,
+			"created_at":            now,
+			"updated_at":            now,
+		})
+	if result.Error != nil {
+		return IdempotencyKey{}, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return existing, false, nil
+	}
+
+	return record, true, nil
+}
+
+// Complete stores the final response of an acquired key.
+func (ir *IdempotencyRepository) Complete(ctx context.Context, tx *gorm.DB, record IdempotencyKey) error {
+	if tx == nil {
+		tx = ir.db
+	}
+
+	return tx.WithContext(ctx).Model(&IdempotencyKey{}).
+		Where("key = ? AND scope = ?", record.Key, record.Scope).
+		Updates(map[string]interface{}{
+			"status":                STATUS_COMPLETED,
+			"response_status":       record.ResponseStatus,
+			"response_content_type": record.ResponseContentType,
+			"response_body":         record.ResponseBody,
+			"updated_at":            time.Now(),
+		}).Error
+}
+
+// Release drops an in-progress key so the request can be retried.
+func (ir *IdempotencyRepository) Release(ctx context.Context, tx *gorm.DB, key, scope string) error {
+	if tx == nil {
+		tx = ir.db
+	}
+
+	return tx.WithContext(ctx).
+		Where("key = ? AND scope = ? AND status = ?", key, scope, STATUS_IN_PROGRESS).
+		Delete(&IdempotencyKey{}).Error
+}
+
+func (ir *IdempotencyRepository) PurgeExpired(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error) {
+	if tx == nil {
+		tx = ir.db
+	}
+
+	result := tx.WithContext(ctx).Where("expires_at < ?", before).Delete(&IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,101 @@
+package pricing
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/utils"
+)
+
+type (
+	IPricingController interface {
+		GetPriceHistory(ctx *gin.Context)
+		CreatePriceSchedule(ctx *gin.Context)
+		GetPriceSchedules(ctx *gin.Context)
+		CancelPriceSchedule(ctx *gin.Context)
+	}
+
+	PricingController struct {
+		pricingService IPricingService
+	}
+)
+
+func NewPricingController(pricingService IPricingService) *PricingController {
+	return &PricingController{
+		pricingService: pricingService,
+	}
+}
+
+func (pc *PricingController) GetPriceHistory(ctx *gin.Context) {
+	var query PriceHistoryRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	query.ProductID = ctx.Param("id")
+
+	result, err := pc.pricingService.GetPriceHistory(ctx.Request.Context(), query)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_PRICE_HISTORY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_PRICE_HISTORY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_PRICE_HISTORY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *PricingController) CreatePriceSchedule(ctx *gin.Context) {
+	var payload CreatePriceScheduleRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	payload.ProductID = ctx.Param("id")
+
+	result, err := pc.pricingService.CreatePriceSchedule(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CREATE_PRICE_SCHEDULE, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (pc *PricingController) GetPriceSchedules(ctx *gin.Context) {
+	result, err := pc.pricingService.GetPriceSchedules(ctx.Request.Context(), ctx.Param("id"))
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_PRICE_SCHEDULE)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_PRICE_SCHEDULE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_PRICE_SCHEDULE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *PricingController) CancelPriceSchedule(ctx *gin.Context) {
+	payload := CancelPriceScheduleRequest{ScheduleID: ctx.Param("schedule_id")}
+
+	result, err := pc.pricingService.CancelPriceSchedule(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_CANCEL_PRICE_SCHEDULE)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_CANCEL_PRICE_SCHEDULE, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CANCEL_PRICE_SCHEDULE, result)
+	ctx.JSON(http.StatusOK, res)
+}
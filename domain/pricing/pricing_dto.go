@@ -0,0 +1,47 @@
+package pricing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	PriceHistoryRequest struct {
+		ProductID string     `json:"-"`
+		From      *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+		To        *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+	}
+
+	PriceHistoryResponse struct {
+		ID        uuid.UUID  `json:"id"`
+		ProductID uuid.UUID  `json:"product_id"`
+		OldPrice  float32    `json:"old_price"`
+		NewPrice  float32    `json:"new_price"`
+		ChangedBy *uuid.UUID `json:"changed_by"`
+		Reason    string     `json:"reason"`
+		ChangedAt time.Time  `json:"changed_at"`
+	}
+
+	CreatePriceScheduleRequest struct {
+		ProductID string     `json:"-"`
+		SalePrice float32    `json:"sale_price"`
+		StartAt   time.Time  `json:"start_at"`
+		EndAt     *time.Time `json:"end_at"`
+	}
+
+	CancelPriceScheduleRequest struct {
+		ScheduleID string `json:"-"`
+	}
+
+	PriceScheduleResponse struct {
+		ID            uuid.UUID  `json:"id"`
+		ProductID     uuid.UUID  `json:"product_id"`
+		SalePrice     float32    `json:"sale_price"`
+		OriginalPrice *float32   `json:"original_price"`
+		StartAt       time.Time  `json:"start_at"`
+		EndAt         *time.Time `json:"end_at"`
+		Status        string     `json:"status"`
+		CreatedBy     *uuid.UUID `json:"created_by"`
+	}
+)
@@ -0,0 +1,43 @@
+package pricing
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SCHEDULE_STATUS_PENDING   = "pending"
+	SCHEDULE_STATUS_ACTIVE    = "active"
+	SCHEDULE_STATUS_COMPLETED = "completed"
+	SCHEDULE_STATUS_CANCELLED = "cancelled"
+
+	REASON_MANUAL         = "manual"
+	REASON_SCHEDULE_START = "schedule_start"
+	REASON_SCHEDULE_END   = "schedule_end"
+)
+
+type PriceHistory struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ProductID uuid.UUID  `gorm:"type:uuid;not null;index:idx_price_history_product_created" json:"product_id"`
+	OldPrice  float32    `json:"old_price"`
+	NewPrice  float32    `json:"new_price"`
+	ChangedBy *uuid.UUID `gorm:"type:uuid" json:"changed_by"`
+	Reason    string     `json:"reason"`
+
+	CreatedAt time.Time `gorm:"index:idx_price_history_product_created" json:"created_at"`
+}
+
+type PriceSchedule struct {
+	ID            uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	ProductID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"product_id"`
+	SalePrice     float32    `json:"sale_price"`
+	OriginalPrice *float32   `json:"original_price"`
+	StartAt       time.Time  `gorm:"not null;index" json:"start_at"`
+	EndAt         *time.Time `gorm:"index" json:"end_at"`
+	Status        string     `gorm:"not null;default:pending;index" json:"status"`
+	CreatedBy     *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
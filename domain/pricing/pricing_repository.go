@@ -0,0 +1,174 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type (
+	IPricingRepository interface {
+		CreatePriceHistory(ctx context.Context, tx *gorm.DB, history PriceHistory) error
+		GetPriceHistory(ctx context.Context, tx *gorm.DB, productID string, from, to *time.Time) ([]PriceHistory, error)
+		CreatePriceSchedule(ctx context.Context, tx *gorm.DB, schedule PriceSchedule) error
+		GetPriceScheduleByID(ctx context.Context, tx *gorm.DB, scheduleID string) (PriceSchedule, bool, error)
+		GetPriceSchedulesByProductID(ctx context.Context, tx *gorm.DB, productID string) ([]PriceSchedule, error)
+		HasOverlappingSchedule(ctx context.Context, tx *gorm.DB, productID string, startAt time.Time, endAt *time.Time) (bool, error)
+		GetDueSchedules(ctx context.Context, tx *gorm.DB, now time.Time) ([]PriceSchedule, error)
+		GetExpiredSchedules(ctx context.Context, tx *gorm.DB, now time.Time) ([]PriceSchedule, error)
+		TransitionSchedule(ctx context.Context, tx *gorm.DB, scheduleID, fromStatus, toStatus string, originalPrice *float32) (bool, error)
+	}
+
+	PricingRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewPricingRepository(db *gorm.DB) *PricingRepository {
+	return &PricingRepository{
+		db: db,
+	}
+}
+
+func (pr *PricingRepository) CreatePriceHistory(ctx context.Context, tx *gorm.DB, history PriceHistory) error {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	return tx.WithContext(ctx).Create(&history).Error
+}
+
+func (pr *PricingRepository) GetPriceHistory(ctx context.Context, tx *gorm.DB, productID string, from, to *time.Time) ([]PriceHistory, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	query := tx.WithContext(ctx).Where("product_id = ?", productID)
+
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+
+	if to != nil {
+		query = query.Where("created_at <= ?", *to)
+	}
+
+	var histories []PriceHistory
+	if err := query.Order("created_at DESC").Find(&histories).Error; err != nil {
+		return nil, err
+	}
+
+	return histories, nil
+}
+
+func (pr *PricingRepository) CreatePriceSchedule(ctx context.Context, tx *gorm.DB, schedule PriceSchedule) error {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	return tx.WithContext(ctx).Create(&schedule).Error
+}
+
+func (pr *PricingRepository) GetPriceScheduleByID(ctx context.Context, tx *gorm.DB, scheduleID string) (PriceSchedule, bool, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var schedule PriceSchedule
+	if err := tx.WithContext(ctx).Where("id = ?", scheduleID).Take(&schedule).Error; err != nil {
+		return PriceSchedule{}, false, err
+	}
+
+	return schedule, true, nil
+}
+
+func (pr *PricingRepository) GetPriceSchedulesByProductID(ctx context.Context, tx *gorm.DB, productID string) ([]PriceSchedule, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var schedules []PriceSchedule
+	if err := tx.WithContext(ctx).Where("product_id = ?", productID).Order("start_at DESC").Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (pr *PricingRepository) HasOverlappingSchedule(ctx context.Context, tx *gorm.DB, productID string, startAt time.Time, endAt *time.Time) (bool, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	// A schedule without end_at is open ended and overlaps anything after its start.
+	query := tx.WithContext(ctx).Model(&PriceSchedule{}).
+		Where("product_id = ?", productID).
+		Where("status IN ?", []string{SCHEDULE_STATUS_PENDING, SCHEDULE_STATUS_ACTIVE}).
+		Where("end_at IS NULL OR end_at > ?", startAt)
+
+	if endAt != nil {
+		query = query.Where("start_at < ?", *endAt)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (pr *PricingRepository) GetDueSchedules(ctx context.Context, tx *gorm.DB, now time.Time) ([]PriceSchedule, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var schedules []PriceSchedule
+	if err := tx.WithContext(ctx).
+		Where("status = ? AND start_at <= ?", SCHEDULE_STATUS_PENDING, now).
+		Order("start_at ASC").
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+func (pr *PricingRepository) GetExpiredSchedules(ctx context.Context, tx *gorm.DB, now time.Time) ([]PriceSchedule, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var schedules []PriceSchedule
+	if err := tx.WithContext(ctx).
+		Where("status = ? AND end_at IS NOT NULL AND end_at <= ?", SCHEDULE_STATUS_ACTIVE, now).
+		Order("end_at ASC").
+		Find(&schedules).Error; err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
+}
+
+// TransitionSchedule moves a schedule between statuses only if it is still in
+// fromStatus, so concurrent scheduler runs cannot apply the same change twice.
+func (pr *PricingRepository) TransitionSchedule(ctx context.Context, tx *gorm.DB, scheduleID, fromStatus, toStatus string, originalPrice *float32) (bool, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	updates := map[string]any{"status": toStatus}
+	if originalPrice != nil {
+		updates["original_price"] = *originalPrice
+	}
+
+	result := tx.WithContext(ctx).Model(&PriceSchedule{}).
+		Where("id = ? AND status = ?", scheduleID, fromStatus).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,36 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const defaultSchedulerInterval = time.Minute
+
+func getSchedulerInterval() time.Duration {
+	return helpers.DurationEnv("PRICE_SCHEDULER_INTERVAL", defaultSchedulerInterval)
+}
+
+// StartScheduler applies and reverts scheduled prices until ctx is cancelled.
+// The interval is read from PRICE_SCHEDULER_INTERVAL (default 1m).
+func (ps *PricingService) StartScheduler(ctx context.Context) {
+	interval := getSchedulerInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logging.Log.Infof("price scheduler started, interval %s", interval)
+
+	for {
+		ps.RunSchedules(ctx, time.Now())
+
+		select {
+		case <-ctx.Done():
+			logging.Log.Info("price scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,339 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/policy"
+	"gorm.io/gorm"
+)
+
+type (
+	IPricingService interface {
+		GetPriceHistory(ctx context.Context, req PriceHistoryRequest) ([]PriceHistoryResponse, error)
+		CreatePriceSchedule(ctx context.Context, req CreatePriceScheduleRequest) (PriceScheduleResponse, error)
+		GetPriceSchedules(ctx context.Context, productID string) ([]PriceScheduleResponse, error)
+		CancelPriceSchedule(ctx context.Context, req CancelPriceScheduleRequest) (PriceScheduleResponse, error)
+	}
+
+	PricingService struct {
+		pricingRepo    IPricingRepository
+		productRepo    product.IProductRepository
+		productService product.IProductService
+	}
+
+	reasonContextKey struct{}
+)
+
+func NewPricingService(pricingRepo IPricingRepository, productRepo product.IProductRepository, productService product.IProductService) *PricingService {
+	return &PricingService{
+		pricingRepo:    pricingRepo,
+		productRepo:    productRepo,
+		productService: productService,
+	}
+}
+
+func withReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonContextKey{}, reason)
+}
+
+func reasonFromContext(ctx context.Context) string {
+	if reason, ok := ctx.Value(reasonContextKey{}).(string); ok {
+		return reason
+	}
+	return REASON_MANUAL
+}
+
+func (ps *PricingService) GetPriceHistory(ctx context.Context, req PriceHistoryRequest) ([]PriceHistoryResponse, error) {
+	if _, err := uuid.Parse(req.ProductID); err != nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_GET_PRICE_HISTORY + ": invalid UUID")
+		return nil, constants.ErrInvalidUUID
+	}
+
+	if req.From != nil && req.To != nil && req.From.After(*req.To) {
+		return nil, constants.ErrInvalidDateRange
+	}
+
+	histories, err := ps.pricingRepo.GetPriceHistory(ctx, nil, req.ProductID, req.From, req.To)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_PRICE_HISTORY)
+		return nil, constants.ErrGetPriceHistory
+	}
+
+	datas := make([]PriceHistoryResponse, 0, len(histories))
+	for _, h := range histories {
+		datas = append(datas, PriceHistoryResponse{
+			ID:        h.ID,
+			ProductID: h.ProductID,
+			OldPrice:  h.OldPrice,
+			NewPrice:  h.NewPrice,
+			ChangedBy: h.ChangedBy,
+			Reason:    h.Reason,
+			ChangedAt: h.CreatedAt,
+		})
+	}
+
+	return datas, nil
+}
+
+func (ps *PricingService) CreatePriceSchedule(ctx context.Context, req CreatePriceScheduleRequest) (PriceScheduleResponse, error) {
+	productUUID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE + ": invalid UUID")
+		return PriceScheduleResponse{}, constants.ErrInvalidUUID
+	}
+
+	if req.SalePrice <= 0 {
+		return PriceScheduleResponse{}, constants.ErrInvalidPrice
+	}
+
+	if req.StartAt.IsZero() || (req.EndAt != nil && !req.EndAt.After(req.StartAt)) {
+		logging.Log.Warn(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE + ": invalid date range")
+		return PriceScheduleResponse{}, constants.ErrInvalidDateRange
+	}
+
+	if _, _, err := ps.productRepo.GetProductByID(ctx, nil, req.ProductID); err != nil {
+		logging.Log.WithError(err).WithField("id", req.ProductID).Warn(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE)
+		return PriceScheduleResponse{}, constants.ErrGetProductByID
+	}
+
+	overlap, err := ps.pricingRepo.HasOverlappingSchedule(ctx, nil, req.ProductID, req.StartAt, req.EndAt)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE)
+		return PriceScheduleResponse{}, constants.ErrCreatePriceSchedule
+	}
+
+	if overlap {
+		logging.Log.Warn(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE + ": overlapping schedule")
+		return PriceScheduleResponse{}, constants.ErrPriceScheduleOverlap
+	}
+
+	schedule := PriceSchedule{
+		ID:        uuid.New(),
+		ProductID: productUUID,
+		SalePrice: req.SalePrice,
+		StartAt:   req.StartAt,
+		EndAt:     req.EndAt,
+		Status:    SCHEDULE_STATUS_PENDING,
+		CreatedBy: actorUUID(ctx),
+	}
+
+	if err := ps.pricingRepo.CreatePriceSchedule(ctx, nil, schedule); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_PRICE_SCHEDULE)
+		return PriceScheduleResponse{}, constants.ErrCreatePriceSchedule
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_CREATE_PRICE_SCHEDULE+": %s", schedule.ID)
+
+	return toPriceScheduleResponse(schedule), nil
+}
+
+func (ps *PricingService) GetPriceSchedules(ctx context.Context, productID string) ([]PriceScheduleResponse, error) {
+	if _, err := uuid.Parse(productID); err != nil {
+		return nil, constants.ErrInvalidUUID
+	}
+
+	schedules, err := ps.pricingRepo.GetPriceSchedulesByProductID(ctx, nil, productID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_PRICE_SCHEDULE)
+		return nil, constants.ErrGetPriceSchedule
+	}
+
+	datas := make([]PriceScheduleResponse, 0, len(schedules))
+	for _, s := range schedules {
+		datas = append(datas, toPriceScheduleResponse(s))
+	}
+
+	return datas, nil
+}
+
+func (ps *PricingService) CancelPriceSchedule(ctx context.Context, req CancelPriceScheduleRequest) (PriceScheduleResponse, error) {
+	if _, err := uuid.Parse(req.ScheduleID); err != nil {
+		return PriceScheduleResponse{}, constants.ErrInvalidUUID
+	}
+
+	schedule, _, err := ps.pricingRepo.GetPriceScheduleByID(ctx, nil, req.ScheduleID)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_CANCEL_PRICE_SCHEDULE)
+		return PriceScheduleResponse{}, constants.ErrGetPriceSchedule
+	}
+
+	switch schedule.Status {
+	case SCHEDULE_STATUS_PENDING:
+		ok, err := ps.pricingRepo.TransitionSchedule(ctx, nil, req.ScheduleID, SCHEDULE_STATUS_PENDING, SCHEDULE_STATUS_CANCELLED, nil)
+		if err != nil || !ok {
+			logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_CANCEL_PRICE_SCHEDULE)
+			return PriceScheduleResponse{}, constants.ErrCancelPriceSchedule
+		}
+	case SCHEDULE_STATUS_ACTIVE:
+		// Cancelling a running sale ends it immediately and restores the price.
+		ok, err := ps.pricingRepo.TransitionSchedule(ctx, nil, req.ScheduleID, SCHEDULE_STATUS_ACTIVE, SCHEDULE_STATUS_CANCELLED, nil)
+		if err != nil || !ok {
+			logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_CANCEL_PRICE_SCHEDULE)
+			return PriceScheduleResponse{}, constants.ErrCancelPriceSchedule
+		}
+
+		if err := ps.revertPrice(ctx, schedule); err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CANCEL_PRICE_SCHEDULE + ": revert price")
+			return PriceScheduleResponse{}, constants.ErrCancelPriceSchedule
+		}
+	default:
+		return PriceScheduleResponse{}, constants.ErrPriceScheduleClosed
+	}
+
+	schedule.Status = SCHEDULE_STATUS_CANCELLED
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_CANCEL_PRICE_SCHEDULE+": %s", schedule.ID)
+
+	return toPriceScheduleResponse(schedule), nil
+}
+
+// OnPriceChanged implements product.IPriceObserver and appends an entry to
+// the price history for every price change.
+func (ps *PricingService) OnPriceChanged(ctx context.Context, p product.Product, oldPrice float32) {
+	history := PriceHistory{
+		ID:        uuid.New(),
+		ProductID: p.ID,
+		OldPrice:  oldPrice,
+		NewPrice:  p.Price,
+		ChangedBy: actorUUID(ctx),
+		Reason:    reasonFromContext(ctx),
+	}
+
+	if err := ps.pricingRepo.CreatePriceHistory(ctx, nil, history); err != nil {
+		logging.Log.WithError(err).WithField("product_id", p.ID).Error(constants.MESSAGE_FAILED_CREATE_PRICE_HISTORY)
+	}
+}
+
+// RunSchedules starts every pending schedule that is due and reverts every
+// active schedule whose end has passed.
+func (ps *PricingService) RunSchedules(ctx context.Context, now time.Time) {
+	due, err := ps.pricingRepo.GetDueSchedules(ctx, nil, now)
+	if err != nil {
+		logging.Log.WithError(err).Error("failed get due price schedules")
+		return
+	}
+
+	for _, schedule := range due {
+		ps.startSchedule(ctx, schedule)
+	}
+
+	expired, err := ps.pricingRepo.GetExpiredSchedules(ctx, nil, now)
+	if err != nil {
+		logging.Log.WithError(err).Error("failed get expired price schedules")
+		return
+	}
+
+	for _, schedule := range expired {
+		ps.endSchedule(ctx, schedule)
+	}
+}
+
+func (ps *PricingService) startSchedule(ctx context.Context, schedule PriceSchedule) {
+	p, _, err := ps.productRepo.GetProductByID(ctx, nil, schedule.ProductID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// The product was deleted, so the sale can never start.
+		if _, err := ps.pricingRepo.TransitionSchedule(ctx, nil, schedule.ID.String(), SCHEDULE_STATUS_PENDING, SCHEDULE_STATUS_CANCELLED, nil); err != nil {
+			logging.Log.WithError(err).WithField("schedule_id", schedule.ID).Error("failed cancel price schedule")
+			return
+		}
+		logging.Log.Warnf("price schedule %s cancelled: product %s not found", schedule.ID, schedule.ProductID)
+		return
+	}
+	if err != nil {
+		logging.Log.WithError(err).WithField("schedule_id", schedule.ID).Error("failed start price schedule")
+		return
+	}
+
+	next := SCHEDULE_STATUS_ACTIVE
+	if schedule.EndAt == nil {
+		next = SCHEDULE_STATUS_COMPLETED
+	}
+
+	ok, err := ps.pricingRepo.TransitionSchedule(ctx, nil, schedule.ID.String(), SCHEDULE_STATUS_PENDING, next, &p.Price)
+	if err != nil || !ok {
+		return
+	}
+
+	if _, err := ps.productService.UpdateProduct(policy.AsSystem(withReason(ctx, REASON_SCHEDULE_START)), product.UpdateProductRequest{
+		ID:    schedule.ProductID.String(),
+		Price: &schedule.SalePrice,
+	}); err != nil {
+		logging.Log.WithError(err).WithField("schedule_id", schedule.ID).Error("failed start price schedule")
+		ps.pricingRepo.TransitionSchedule(ctx, nil, schedule.ID.String(), next, SCHEDULE_STATUS_PENDING, nil)
+		return
+	}
+
+	logging.Log.Infof("price schedule %s started for product %s", schedule.ID, schedule.ProductID)
+}
+
+func (ps *PricingService) endSchedule(ctx context.Context, schedule PriceSchedule) {
+	ok, err := ps.pricingRepo.TransitionSchedule(ctx, nil, schedule.ID.String(), SCHEDULE_STATUS_ACTIVE, SCHEDULE_STATUS_COMPLETED, nil)
+	if err != nil || !ok {
+		return
+	}
+
+	if err := ps.revertPrice(ctx, schedule); err != nil {
+		logging.Log.WithError(err).WithField("schedule_id", schedule.ID).Error("failed end price schedule")
+		ps.pricingRepo.TransitionSchedule(ctx, nil, schedule.ID.String(), SCHEDULE_STATUS_COMPLETED, SCHEDULE_STATUS_ACTIVE, nil)
+		return
+	}
+
+	logging.Log.Infof("price schedule %s ended for product %s", schedule.ID, schedule.ProductID)
+}
+
+// revertPrice restores the price the product had before schedule's sale. A
+// price changed during the sale is kept, and so is one changed between the
+// check and the update, which fails on the product's version instead.
+func (ps *PricingService) revertPrice(ctx context.Context, schedule PriceSchedule) error {
+	if schedule.OriginalPrice == nil {
+		return nil
+	}
+
+	p, _, err := ps.productRepo.GetProductByID(ctx, nil, schedule.ProductID.String())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logging.Log.Warnf("price schedule %s not reverted: product %s not found", schedule.ID, schedule.ProductID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if p.Price != schedule.SalePrice {
+		logging.Log.Infof("price schedule %s not reverted: product %s price changed to %v during the sale", schedule.ID, schedule.ProductID, p.Price)
+		return nil
+	}
+
+	_, err = ps.productService.UpdateProduct(policy.AsSystem(withReason(ctx, REASON_SCHEDULE_END)), product.UpdateProductRequest{
+		ID:      schedule.ProductID.String(),
+		Price:   schedule.OriginalPrice,
+		Version: &p.Version,
+	})
+	return err
+}
+
+func actorUUID(ctx context.Context) *uuid.UUID {
+	id, err := uuid.Parse(helpers.GetUserID(ctx))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+func toPriceScheduleResponse(schedule PriceSchedule) PriceScheduleResponse {
+	return PriceScheduleResponse{
+		ID:            schedule.ID,
+		ProductID:     schedule.ProductID,
+		SalePrice:     schedule.SalePrice,
+		OriginalPrice: schedule.OriginalPrice,
+		StartAt:       schedule.StartAt,
+		EndAt:         schedule.EndAt,
+		Status:        schedule.Status,
+		CreatedBy:     schedule.CreatedBy,
+	}
+}
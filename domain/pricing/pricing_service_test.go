@@ -0,0 +1,167 @@
+package pricing
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestService(t *testing.T) (*PricingService, *gorm.DB) {
+	t.Helper()
+
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &PriceHistory{}, &PriceSchedule{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	productRepo := product.NewProductRepository(db)
+	productService := product.NewProductService(productRepo, nil)
+	ps := NewPricingService(NewPricingRepository(db), productRepo, productService)
+	productService.RegisterPriceObserver(ps)
+
+	return ps, db
+}
+
+func createTestProduct(t *testing.T, db *gorm.DB, price float32) product.Product {
+	t.Helper()
+
+	p := product.Product{ID: uuid.New(), Name: "Test product", Merk: uuid.NewString(), Price: price, Version: 1}
+	if err := db.Create(&p).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return p
+}
+
+func createTestSchedule(t *testing.T, db *gorm.DB, schedule PriceSchedule) PriceSchedule {
+	t.Helper()
+
+	schedule.ID = uuid.New()
+	if err := db.Create(&schedule).Error; err != nil {
+		t.Fatalf("create schedule: %v", err)
+	}
+	return schedule
+}
+
+func scheduleStatus(t *testing.T, db *gorm.DB, schedule PriceSchedule) string {
+	t.Helper()
+
+	var current PriceSchedule
+	if err := db.First(&current, "id = ?", schedule.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return current.Status
+}
+
+func TestRunSchedulesProductDeleted(t *testing.T) {
+	ps, db := newTestService(t)
+	now := time.Now()
+	later := now.Add(time.Hour)
+	earlier := now.Add(-time.Minute)
+	original := float32(100)
+
+	p := createTestProduct(t, db, original)
+	pending := createTestSchedule(t, db, PriceSchedule{ProductID: p.ID, SalePrice: 80, StartAt: now.Add(-time.Hour), EndAt: &later, Status: SCHEDULE_STATUS_PENDING})
+
+	deleted := createTestProduct(t, db, 80)
+	active := createTestSchedule(t, db, PriceSchedule{ProductID: deleted.ID, SalePrice: 80, OriginalPrice: &original, StartAt: now.Add(-time.Hour), EndAt: &earlier, Status: SCHEDULE_STATUS_ACTIVE})
+
+	db.Delete(&p)
+	db.Delete(&deleted)
+
+	for range 2 {
+		ps.RunSchedules(context.Background(), now)
+	}
+
+	if status := scheduleStatus(t, db, pending); status != SCHEDULE_STATUS_CANCELLED {
+		t.Errorf("pending schedule of a deleted product is %s, want %s", status, SCHEDULE_STATUS_CANCELLED)
+	}
+	if status := scheduleStatus(t, db, active); status != SCHEDULE_STATUS_COMPLETED {
+		t.Errorf("active schedule of a deleted product is %s, want %s", status, SCHEDULE_STATUS_COMPLETED)
+	}
+}
+
+func TestRunSchedulesRevertsPrice(t *testing.T) {
+	ps, db := newTestService(t)
+	ctx := context.Background()
+	now := time.Now()
+	end := now.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		manual *float32
+		want   float32
+	}{
+		{"unchanged sale price", nil, 100},
+		{"changed during the sale", ptr(float32(90)), 90},
+	}
+
+	for _, tt := range tests {
+		p := createTestProduct(t, db, 100)
+		schedule := createTestSchedule(t, db, PriceSchedule{ProductID: p.ID, SalePrice: 80, StartAt: now, EndAt: &end, Status: SCHEDULE_STATUS_PENDING})
+
+		ps.RunSchedules(ctx, now)
+		if current := productPrice(t, db, p); current != 80 {
+			t.Fatalf("%s: price during the sale = %v, want 80", tt.name, current)
+		}
+
+		if tt.manual != nil {
+			db.Model(&product.Product{}).Where("id = ?", p.ID).Updates(map[string]any{"price": *tt.manual, "version": gorm.Expr("version + 1")})
+		}
+
+		ps.RunSchedules(ctx, end)
+		if current := productPrice(t, db, p); current != tt.want {
+			t.Errorf("%s: price after the sale = %v, want %v", tt.name, current, tt.want)
+		}
+		if status := scheduleStatus(t, db, schedule); status != SCHEDULE_STATUS_COMPLETED {
+			t.Errorf("%s: schedule is %s, want %s", tt.name, status, SCHEDULE_STATUS_COMPLETED)
+		}
+	}
+}
+
+func TestCancelPriceScheduleKeepsManualPrice(t *testing.T) {
+	ps, db := newTestService(t)
+	ctx := context.Background()
+	now := time.Now()
+	end := now.Add(time.Hour)
+
+	p := createTestProduct(t, db, 100)
+	schedule := createTestSchedule(t, db, PriceSchedule{ProductID: p.ID, SalePrice: 80, StartAt: now, EndAt: &end, Status: SCHEDULE_STATUS_PENDING})
+	ps.RunSchedules(ctx, now)
+
+	db.Model(&product.Product{}).Where("id = ?", p.ID).Update("price", 90)
+
+	if _, err := ps.CancelPriceSchedule(ctx, CancelPriceScheduleRequest{ScheduleID: schedule.ID.String()}); err != nil {
+		t.Fatalf("CancelPriceSchedule: %v", err)
+	}
+	if current := productPrice(t, db, p); current != 90 {
+		t.Errorf("price after cancelling = %v, want the manual 90", current)
+	}
+}
+
+func productPrice(t *testing.T, db *gorm.DB, p product.Product) float32 {
+	t.Helper()
+
+	var current product.Product
+	if err := db.First(&current, "id = ?", p.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	return current.Price
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,254 @@
+package product
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"gorm.io/gorm"
+)
+
+const (
+	BATCH_OP_CREATE = "create"
+	BATCH_OP_UPDATE = "update"
+	BATCH_OP_DELETE = "delete"
+
+	// BATCH_MODE_ATOMIC runs every operation in one transaction and commits
+	// only when all of them succeed.
+	BATCH_MODE_ATOMIC = "atomic"
+	// BATCH_MODE_BEST_EFFORT commits each operation on its own and reports
+	// failures individually.
+	BATCH_MODE_BEST_EFFORT = "best_effort"
+
+	BATCH_STATUS_SUCCESS     = "success"
+	BATCH_STATUS_FAILED      = "failed"
+	BATCH_STATUS_ROLLED_BACK = "rolled_back"
+	BATCH_STATUS_SKIPPED     = "skipped"
+
+	BATCH_MAX_OPERATIONS = 500
+)
+
+type (
+	// ProductBatchOperation is one item of a batch. Data holds a
+	// CreateProductRequest for creates and an UpdateProductRequest, including
+	// the version, for updates. Deletes only need the ID.
+	ProductBatchOperation struct {
+		Op   string          `json:"op"`
+		ID   string          `json:"id"`
+		Data json.RawMessage `json:"data"`
+	}
+
+	ProductBatchRequest struct {
+		Mode       string                  `json:"mode"`
+		Operations []ProductBatchOperation `json:"operations"`
+	}
+
+	ProductBatchResult struct {
+		Index          int              `json:"index"`
+		Op             string           `json:"op"`
+		ID             string           `json:"id,omitempty"`
+		Status         string           `json:"status"`
+		Data           *ProductResponse `json:"data,omitempty"`
+		Error          string           `json:"error,omitempty"`
+		CurrentVersion *int64           `json:"current_version,omitempty"`
+	}
+
+	ProductBatchResponse struct {
+		Mode      string               `json:"mode"`
+		Succeeded int                  `json:"succeeded"`
+		Failed    int                  `json:"failed"`
+		Results   []ProductBatchResult `json:"results"`
+	}
+
+	// batchEffect is a side effect that may only run once the operation that
+	// caused it is committed.
+	batchEffect struct {
+		product  Product
+		oldPrice float32
+	}
+)
+
+// BatchProducts runs mixed create, update and delete operations. In atomic
+// mode the first failure rolls everything back; in best-effort mode every
+// operation is committed or reported on its own. The response carries one
+// result per operation, in request order.
+func (ps *ProductService) BatchProducts(ctx context.Context, req ProductBatchRequest) (ProductBatchResponse, error) {
+	if req.Mode == "" {
+		req.Mode = BATCH_MODE_ATOMIC
+	}
+	if req.Mode != BATCH_MODE_ATOMIC && req.Mode != BATCH_MODE_BEST_EFFORT {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_BATCH_PRODUCT+": unsupported mode %q", req.Mode)
+		return ProductBatchResponse{}, constants.ErrBatchMode
+	}
+
+	if len(req.Operations) == 0 {
+		return ProductBatchResponse{}, constants.ErrBatchEmpty
+	}
+	if len(req.Operations) > BATCH_MAX_OPERATIONS {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_BATCH_PRODUCT+": %d operations", len(req.Operations))
+		return ProductBatchResponse{}, constants.ErrBatchTooLarge
+	}
+
+	res := ProductBatchResponse{
+		Mode:    req.Mode,
+		Results: make([]ProductBatchResult, len(req.Operations)),
+	}
+
+	var effects []batchEffect
+	if req.Mode == BATCH_MODE_ATOMIC {
+		failed := -1
+		err := ps.productRepo.RunInTransaction(ctx, func(tx *gorm.DB) error {
+			for i, op := range req.Operations {
+				result, effect, err := ps.runBatchOperation(ctx, tx, i, op)
+				res.Results[i] = result
+				if err != nil {
+					failed = i
+					return err
+				}
+				if effect != nil {
+					effects = append(effects, *effect)
+				}
+			}
+			return nil
+		})
+
+		if err != nil {
+			effects = nil
+			for i := range res.Results {
+				switch {
+				case i == failed:
+				case i < failed || failed < 0:
+					res.Results[i] = batchFailure(i, req.Operations[i], BATCH_STATUS_ROLLED_BACK, constants.ErrBatchRolledBack)
+				default:
+					res.Results[i] = batchFailure(i, req.Operations[i], BATCH_STATUS_SKIPPED, constants.ErrBatchSkipped)
+				}
+			}
+		}
+	} else {
+		for i, op := range req.Operations {
+			result, effect, _ := ps.runBatchOperation(ctx, nil, i, op)
+			res.Results[i] = result
+			if effect != nil {
+				effects = append(effects, *effect)
+			}
+		}
+	}
+
+	for _, result := range res.Results {
+		if result.Status == BATCH_STATUS_SUCCESS {
+			res.Succeeded++
+		} else {
+			res.Failed++
+		}
+	}
+
+	if res.Succeeded > 0 {
+		ps.suggestCache.Invalidate()
+	}
+	for _, effect := range effects {
+		ps.notifyPriceChanged(ctx, effect.product, effect.oldPrice)
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_BATCH_PRODUCT+": %s, %d succeeded, %d failed", req.Mode, res.Succeeded, res.Failed)
+
+	return res, nil
+}
+
+// runBatchOperation runs one operation within tx, or in its own statement
+// when tx is nil.
+func (ps *ProductService) runBatchOperation(ctx context.Context, tx *gorm.DB, index int, op ProductBatchOperation) (ProductBatchResult, *batchEffect, error) {
+	var (
+		product Product
+		effect  *batchEffect
+		err     error
+	)
+
+	switch op.Op {
+	case BATCH_OP_CREATE:
+		var req CreateProductRequest
+		if err = decodeBatchData(op.Data, &req); err == nil {
+			product, err = ps.createProduct(ctx, tx, req)
+		}
+
+	case BATCH_OP_UPDATE:
+		var req UpdateProductRequest
+		if _, parseErr := uuid.Parse(op.ID); parseErr != nil {
+			err = constants.ErrInvalidUUID
+		} else if err = decodeBatchData(op.Data, &req); err == nil && req.Version == nil {
+			err = constants.ErrVersionRequired
+		} else if err == nil {
+			req.ID = op.ID
+
+			var oldPrice float32
+			product, oldPrice, err = ps.updateProduct(ctx, tx, req)
+			effect = &batchEffect{product: product, oldPrice: oldPrice}
+		}
+
+	case BATCH_OP_DELETE:
+		if _, parseErr := uuid.Parse(op.ID); parseErr != nil {
+			err = constants.ErrInvalidUUID
+		} else {
+			product, err = ps.deleteProduct(ctx, tx, DeleteProductRequest{ProductID: op.ID})
+		}
+
+	default:
+		err = constants.ErrBatchOperation
+	}
+
+	if err != nil {
+		return batchFailure(index, op, BATCH_STATUS_FAILED, err), nil, err
+	}
+
+	data := ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Merk:        product.Merk,
+		Material:    product.Material,
+		Price:       product.Price,
+		OwnerID:     product.OwnerID,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
+	}
+	if op.Op == BATCH_OP_DELETE {
+		deletedAt := time.Now()
+		data.DeletedAt = &deletedAt
+	}
+
+	return ProductBatchResult{
+		Index:  index,
+		Op:     op.Op,
+		ID:     product.ID.String(),
+		Status: BATCH_STATUS_SUCCESS,
+		Data:   &data,
+	}, effect, nil
+}
+
+func batchFailure(index int, op ProductBatchOperation, status string, err error) ProductBatchResult {
+	result := ProductBatchResult{
+		Index:  index,
+		Op:     op.Op,
+		ID:     op.ID,
+		Status: status,
+		Error:  err.Error(),
+	}
+
+	var conflict *helpers.VersionConflictError
+	if errors.As(err, &conflict) {
+		result.CurrentVersion = &conflict.CurrentVersion
+	}
+
+	return result
+}
+
+func decodeBatchData(data json.RawMessage, v any) error {
+	if len(data) == 0 {
+		return constants.ErrBatchOperation
+	}
+	return json.Unmarshal(data, v)
+}
@@ -0,0 +1,254 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/cache"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	productCacheGenerationKey = "product:generation"
+	defaultProductCacheTTL    = 5 * time.Minute
+)
+
+// CachedProductRepository is a read-through cache in front of an
+// IProductRepository. Lookups by ID and product listings are served from the
+// cache; everything else, and any call made inside a transaction, goes
+// straight to the wrapped repository.
+//
+// Every key embeds a generation that writes replace, so a write invalidates
+// all cached product reads at once and a load that raced with the write is
+// stored under a generation nobody reads anymore.
+type CachedProductRepository struct {
+	IProductRepository
+
+	cache   cache.Cache
+	ttl     time.Duration
+	group   singleflight.Group
+	metrics cache.Metrics
+}
+
+// NewCachedProductRepository wraps repo with c. Entries live for
+// PRODUCT_CACHE_TTL (default 5m).
+func NewCachedProductRepository(repo IProductRepository, c cache.Cache) *CachedProductRepository {
+	ttl := helpers.DurationEnv("PRODUCT_CACHE_TTL", defaultProductCacheTTL)
+
+	return &CachedProductRepository{
+		IProductRepository: repo,
+		cache:              c,
+		ttl:                ttl,
+	}
+}
+
+func (cr *CachedProductRepository) CacheStats() cache.Stats {
+	return cr.metrics.Stats()
+}
+
+func (cr *CachedProductRepository) GetProductByID(ctx context.Context, tx *gorm.DB, productID string) (Product, bool, error) {
+	if tx != nil {
+		return cr.IProductRepository.GetProductByID(ctx, tx, productID)
+	}
+
+	type result struct {
+		Product Product
+		Found   bool
+	}
+
+	res, err := readThrough(ctx, cr, "id:"+productID, func(ctx context.Context) (result, error) {
+		product, found, err := cr.IProductRepository.GetProductByID(ctx, nil, productID)
+		return result{Product: product, Found: found}, err
+	})
+	return res.Product, res.Found, err
+}
+
+func (cr *CachedProductRepository) GetAllProduct(ctx context.Context, tx *gorm.DB, search string) ([]Product, error) {
+	if tx != nil {
+		return cr.IProductRepository.GetAllProduct(ctx, tx, search)
+	}
+
+	return readThrough(ctx, cr, "all:"+search, func(ctx context.Context) ([]Product, error) {
+		return cr.IProductRepository.GetAllProduct(ctx, nil, search)
+	})
+}
+
+func (cr *CachedProductRepository) GetAllProductWithPagination(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest) (ProductPaginationRepositoryResponse, error) {
+	if tx != nil {
+		return cr.IProductRepository.GetAllProductWithPagination(ctx, tx, req)
+	}
+
+	key, err := json.Marshal(req)
+	if err != nil {
+		return cr.IProductRepository.GetAllProductWithPagination(ctx, nil, req)
+	}
+
+	return readThrough(ctx, cr, "page:"+string(key), func(ctx context.Context) (ProductPaginationRepositoryResponse, error) {
+		return cr.IProductRepository.GetAllProductWithPagination(ctx, nil, req)
+	})
+}
+
+// RunInTransaction invalidates once more after the transaction ends: writes
+// inside it already invalidated, but a read between those and the commit may
+// have cached the old rows again.
+func (cr *CachedProductRepository) RunInTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	err := cr.IProductRepository.RunInTransaction(ctx, fn)
+	cr.Invalidate(ctx)
+	return err
+}
+
+func (cr *CachedProductRepository) CreateProduct(ctx context.Context, tx *gorm.DB, product Product) error {
+	if err := cr.IProductRepository.CreateProduct(ctx, tx, product); err != nil {
+		return err
+	}
+
+	cr.Invalidate(ctx)
+	return nil
+}
+
+func (cr *CachedProductRepository) UpdateProduct(ctx context.Context, tx *gorm.DB, product Product) (bool, error) {
+	updated, err := cr.IProductRepository.UpdateProduct(ctx, tx, product)
+	if err != nil || !updated {
+		return updated, err
+	}
+
+	cr.Invalidate(ctx)
+	return true, nil
+}
+
+func (cr *CachedProductRepository) DeleteProduct(ctx context.Context, tx *gorm.DB, productID string) error {
+	if err := cr.IProductRepository.DeleteProduct(ctx, tx, productID); err != nil {
+		return err
+	}
+
+	cr.Invalidate(ctx)
+	return nil
+}
+
+func (cr *CachedProductRepository) RestoreProduct(ctx context.Context, tx *gorm.DB, productID string) error {
+	if err := cr.IProductRepository.RestoreProduct(ctx, tx, productID); err != nil {
+		return err
+	}
+
+	cr.Invalidate(ctx)
+	return nil
+}
+
+func (cr *CachedProductRepository) PurgeProduct(ctx context.Context, tx *gorm.DB, productID string) error {
+	if err := cr.IProductRepository.PurgeProduct(ctx, tx, productID); err != nil {
+		return err
+	}
+
+	cr.Invalidate(ctx)
+	return nil
+}
+
+func (cr *CachedProductRepository) PurgeProductsDeletedBefore(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error) {
+	purged, err := cr.IProductRepository.PurgeProductsDeletedBefore(ctx, tx, before)
+	if err != nil || purged == 0 {
+		return purged, err
+	}
+
+	cr.Invalidate(ctx)
+	return purged, nil
+}
+
+func (cr *CachedProductRepository) UpsertProducts(ctx context.Context, tx *gorm.DB, products []Product, key string) ([]ProductUpsert, error) {
+	result, err := cr.IProductRepository.UpsertProducts(ctx, tx, products, key)
+	if err != nil || len(result) == 0 {
+		return result, err
+	}
+
+	cr.Invalidate(ctx)
+	return result, nil
+}
+
+// Invalidate drops every cached product read by moving to a new generation.
+func (cr *CachedProductRepository) Invalidate(ctx context.Context) {
+	if _, err := cr.bumpGeneration(ctx); err != nil {
+		cr.metrics.Error()
+		logging.Log.WithError(err).Warn("failed invalidate product cache")
+	}
+}
+
+func (cr *CachedProductRepository) generation(ctx context.Context) (string, error) {
+	data, found, err := cr.cache.Get(ctx, productCacheGenerationKey)
+	if err != nil {
+		return "", err
+	}
+
+	if found {
+		return string(data), nil
+	}
+
+	// Never fall back to a fixed generation: entries from before an
+	// eviction of this key may be stale.
+	return cr.bumpGeneration(ctx)
+}
+
+func (cr *CachedProductRepository) bumpGeneration(ctx context.Context) (string, error) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	return generation, cr.cache.Set(ctx, productCacheGenerationKey, []byte(generation), 0)
+}
+
+// readThrough returns the cached value for key or loads, stores and returns
+// it. Concurrent misses for the same key share a single load. Cache errors
+// are counted and fall back to the loader so the cache never fails a read.
+func readThrough[T any](ctx context.Context, cr *CachedProductRepository, key string, load func(ctx context.Context) (T, error)) (T, error) {
+	generation, err := cr.generation(ctx)
+	if err != nil {
+		cr.metrics.Error()
+		return load(ctx)
+	}
+	key = "product:" + generation + ":" + key
+
+	data, found, err := cr.cache.Get(ctx, key)
+	if err != nil {
+		cr.metrics.Error()
+		return load(ctx)
+	}
+
+	if found {
+		var value T
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err == nil {
+			cr.metrics.Hit()
+			return value, nil
+		}
+		cr.metrics.Error()
+	}
+
+	cr.metrics.Miss()
+
+	shared, err, _ := cr.group.Do(key, func() (any, error) {
+		// The load is shared by every waiting caller, so one caller giving
+		// up must not cancel it for the others.
+		value, err := load(context.WithoutCancel(ctx))
+		if err != nil {
+			return value, err
+		}
+
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(value); err != nil {
+			cr.metrics.Error()
+			return value, nil
+		}
+
+		if err := cr.cache.Set(ctx, key, buf.Bytes(), cr.ttl); err != nil {
+			cr.metrics.Error()
+		}
+		return value, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return shared.(T), nil
+}
@@ -1,20 +1,42 @@
 package product
 
 import (
+	"errors"
+	"io"
 	"net/http"
+	"path/filepath"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/export"
+	"github.com/mferdian/Go-GraphQL/helpers"
 	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/policy"
 	"github.com/mferdian/Go-GraphQL/utils"
 )
 
+var productExportColumns = []string{"id", "name", "description", "merk", "material", "price", "version", "updated_at"}
+
 type (
 	IProductController interface {
 		CreateProduct(ctx *gin.Context)
+		GetAllProduct(ctx *gin.Context)
+		SearchProduct(ctx *gin.Context)
+		ExportProducts(ctx *gin.Context)
+		SuggestProduct(ctx *gin.Context)
+		GetProductByID(ctx *gin.Context)
 		UpdateProduct(ctx *gin.Context)
 		DeleteProduct(ctx *gin.Context)
+		BatchProducts(ctx *gin.Context)
+
+		GetTrashedProducts(ctx *gin.Context)
+		RestoreProduct(ctx *gin.Context)
+		PurgeProduct(ctx *gin.Context)
+
+		ImportProducts(ctx *gin.Context)
+		GetImportJob(ctx *gin.Context)
 	}
 
 	ProductController struct {
@@ -49,6 +71,117 @@ func (pc *ProductController) CreateProduct(ctx *gin.Context) {
 	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CREATE_PRODUCT, result)
 	ctx.JSON(http.StatusCreated, res)
 }
+
+func (pc *ProductController) GetAllProduct(ctx *gin.Context) {
+	paginationParam := ctx.DefaultQuery("pagination", "true")
+	usePagination := paginationParam != "false"
+
+	search := ctx.DefaultQuery("search", "")
+
+	if !usePagination {
+		result, err := pc.productService.GetAllProduct(ctx.Request.Context(), search)
+		if err != nil {
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_ALL_PRODUCTS, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+			return
+		}
+		res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_ALL_PRODUCT, result)
+		ctx.JSON(http.StatusOK, res)
+		return
+	}
+
+	var query ProductPaginationRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := pc.productService.GetAllProductWithPagination(ctx.Request.Context(), query)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_ALL_PRODUCTS)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_ALL_PRODUCTS, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.Response{
+		Status:   true,
+		Messsage: constants.MESSAGE_SUCCESS_GET_ALL_PRODUCT,
+		Data:     result.Data,
+		Meta:     result.PaginationResponse,
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *ProductController) SearchProduct(ctx *gin.Context) {
+	var query ProductPaginationRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := pc.productService.SearchProduct(ctx.Request.Context(), query)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_SEARCH_PRODUCT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_SEARCH_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.Response{
+		Status:   true,
+		Messsage: constants.MESSAGE_SUCCESS_SEARCH_PRODUCT,
+		Data:     gin.H{"items": result.Items, "facets": result.Facets},
+		Meta:     result.PaginationResponse,
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *ProductController) SuggestProduct(ctx *gin.Context) {
+	var query SuggestProductRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := pc.productService.SuggestProduct(ctx.Request.Context(), query)
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_SUGGEST_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_SUGGEST_PRODUCT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *ProductController) GetProductByID(ctx *gin.Context) {
+	idParam := ctx.Param("id")
+	if _, err := uuid.Parse(idParam); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_UUID_FORMAT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UUID_FORMAT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := pc.productService.GetProductByID(ctx.Request.Context(), idParam)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_DETAIL_PRODUCT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DETAIL_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_DETAIL_PRODUCT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
 func (pc *ProductController) UpdateProduct(ctx *gin.Context) {
 	idParam := ctx.Param("id")
 	if _, err := uuid.Parse(idParam); err != nil {
@@ -68,11 +201,32 @@ func (pc *ProductController) UpdateProduct(ctx *gin.Context) {
 		return
 	}
 
+	if payload.Version == nil {
+		if version, ok := helpers.ParseIfMatch(ctx.GetHeader("If-Match")); ok {
+			payload.Version = &version
+		}
+	}
+
+	if payload.Version == nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_PRODUCT + ": missing version")
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UPDATE_PRODUCT, constants.ErrVersionRequired.Error(), nil)
+		ctx.JSON(http.StatusPreconditionRequired, res)
+		return
+	}
+
 	result, err := pc.productService.UpdateProduct(ctx.Request.Context(), payload)
 	if err != nil {
+		var conflict *helpers.VersionConflictError
+		if errors.As(err, &conflict) {
+			logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_UPDATE_PRODUCT)
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UPDATE_PRODUCT, err.Error(), conflict)
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UPDATE_PRODUCT)
 		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UPDATE_PRODUCT, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(policy.StatusCode(err, http.StatusBadRequest), res)
 		return
 	}
 
@@ -95,7 +249,7 @@ func (pc *ProductController) DeleteProduct(ctx *gin.Context) {
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_USER)
 		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_DELETE_USER, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(policy.StatusCode(err, http.StatusBadRequest), res)
 		return
 	}
 
@@ -103,3 +257,235 @@ func (pc *ProductController) DeleteProduct(ctx *gin.Context) {
 	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_DELETE_USER, result)
 	ctx.JSON(http.StatusOK, res)
 }
+
+// BatchProducts answers 200 when every operation succeeded, 207 when a
+// best-effort batch partly failed and 422 when an atomic batch was rolled
+// back. The per-operation results are in the data either way.
+func (pc *ProductController) BatchProducts(ctx *gin.Context) {
+	var payload ProductBatchRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := pc.productService.BatchProducts(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_BATCH_PRODUCT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_BATCH_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	switch {
+	case result.Failed == 0:
+		res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_BATCH_PRODUCT, result)
+		ctx.JSON(http.StatusOK, res)
+	case result.Mode == BATCH_MODE_ATOMIC:
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_BATCH_PRODUCT, constants.ErrBatchRolledBack.Error(), result)
+		ctx.JSON(http.StatusUnprocessableEntity, res)
+	default:
+		res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_BATCH_PRODUCT, result)
+		ctx.JSON(http.StatusMultiStatus, res)
+	}
+}
+
+func (pc *ProductController) GetTrashedProducts(ctx *gin.Context) {
+	var query ProductPaginationRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := pc.productService.GetTrashedProducts(ctx.Request.Context(), query)
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_TRASHED_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.Response{
+		Status:   true,
+		Messsage: constants.MESSAGE_SUCCESS_GET_TRASHED_PRODUCT,
+		Data:     result.Data,
+		Meta:     result.PaginationResponse,
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *ProductController) RestoreProduct(ctx *gin.Context) {
+	payload := RestoreProductRequest{ProductID: ctx.Param("id")}
+
+	result, err := pc.productService.RestoreProduct(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_RESTORE_PRODUCT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_RESTORE_PRODUCT, err.Error(), nil)
+		ctx.JSON(trashErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_RESTORE_PRODUCT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *ProductController) PurgeProduct(ctx *gin.Context) {
+	payload := PurgeProductRequest{ProductID: ctx.Param("id")}
+
+	result, err := pc.productService.PurgeProduct(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_PURGE_PRODUCT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_PURGE_PRODUCT, err.Error(), nil)
+		ctx.JSON(trashErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_PURGE_PRODUCT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func trashErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrTrashedRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrRestoreMerkConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ImportProducts accepts the file either as the "file" field of a multipart
+// form or as the raw request body. The format comes from ?format=, the file
+// extension or the Content-Type, in that order.
+func (pc *ProductController) ImportProducts(ctx *gin.Context) {
+	var payload ImportProductRequest
+	if err := ctx.ShouldBindQuery(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	ctx.Request.Body = http.MaxBytesReader(ctx.Writer, ctx.Request.Body, IMPORT_MAX_FILE_SIZE)
+
+	data, fileName, err := readImportFile(ctx)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_IMPORT_PRODUCT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_IMPORT_PRODUCT, constants.ErrImportFile.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	payload.Data = data
+
+	if payload.Format == "" {
+		payload.Format = importFormat(fileName, ctx.ContentType())
+	}
+
+	result, err := pc.productService.ImportProducts(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_IMPORT_PRODUCT)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_IMPORT_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if result.Status == IMPORT_STATUS_PENDING || result.Status == IMPORT_STATUS_RUNNING {
+		ctx.Header("Location", "/api/products/import/"+result.ID)
+		res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_QUEUE_IMPORT_PRODUCT, result)
+		ctx.JSON(http.StatusAccepted, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_IMPORT_PRODUCT, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (pc *ProductController) GetImportJob(ctx *gin.Context) {
+	result, err := pc.productService.GetImportJob(ctx.Request.Context(), ctx.Param("job_id"))
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_IMPORT_JOB, err.Error(), nil)
+		ctx.JSON(http.StatusNotFound, res)
+		return
+	}
+
+	// Jobs change while they run, so pollers must never see a cached copy.
+	ctx.Header("Cache-Control", "no-store")
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_IMPORT_JOB, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func readImportFile(ctx *gin.Context) ([]byte, string, error) {
+	if strings.HasPrefix(ctx.ContentType(), "multipart/") {
+		header, err := ctx.FormFile("file")
+		if err != nil {
+			return nil, "", err
+		}
+
+		file, err := header.Open()
+		if err != nil {
+			return nil, "", err
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		return data, header.Filename, err
+	}
+
+	data, err := io.ReadAll(ctx.Request.Body)
+	return data, "", err
+}
+
+func importFormat(fileName, contentType string) string {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		return helpers.RECORD_FORMAT_CSV
+	case ".json":
+		return helpers.RECORD_FORMAT_JSON
+	}
+
+	switch {
+	case strings.Contains(contentType, "csv"):
+		return helpers.RECORD_FORMAT_CSV
+	case strings.Contains(contentType, "json"):
+		return helpers.RECORD_FORMAT_JSON
+	}
+
+	return ""
+}
+
+// ExportProducts streams the products matching the list filters as CSV, XLSX or
+// NDJSON, chosen by ?format= or the Accept header.
+func (pc *ProductController) ExportProducts(ctx *gin.Context) {
+	var query ProductPaginationRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	format, err := export.Negotiate(ctx.Query("format"), ctx.GetHeader("Accept"))
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_EXPORT_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusNotAcceptable, res)
+		return
+	}
+
+	started, err := export.Stream(ctx, format, "products", productExportColumns, func(write func(values ...any) error) error {
+		return pc.productService.ExportProducts(ctx.Request.Context(), query, func(product ProductResponse) error {
+			return write(product.ID, product.Name, product.Description, product.Merk, product.Material, product.Price, product.Version, product.UpdatedAt)
+		})
+	})
+	if err != nil && !started {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_EXPORT_PRODUCT, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	if err != nil {
+		// The status line is gone already; the client gets a truncated file.
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_EXPORT_PRODUCT)
+	}
+}
@@ -1,6 +1,10 @@
 package product
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type (
 	// GraphQL
@@ -13,12 +17,25 @@ type (
 	}
 
 	ProductResponse struct {
-		ID          uuid.UUID `json:"id"`
-		Name        string    `json:"name"`
-		Description string    `json:"description"`
-		Merk        string    `json:"merk"`
-		Material    string    `json:"material"`
-		Price       float32   `json:"price"`
+		ID          uuid.UUID  `json:"id"`
+		Name        string     `json:"name"`
+		Description string     `json:"description"`
+		Merk        string     `json:"merk"`
+		Material    string     `json:"material"`
+		Price       float32    `json:"price"`
+		OwnerID     *uuid.UUID `json:"owner_id"`
+		Version     int64      `json:"version"`
+		UpdatedAt   time.Time  `json:"updated_at"`
+
+		Highlight *ProductHighlight `json:"highlight,omitempty"`
+		DeletedAt *time.Time        `json:"deleted_at,omitempty"`
+	}
+
+	// ProductHighlight carries search snippets with matches wrapped in <mark>.
+	ProductHighlight struct {
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Rank        float32 `json:"rank"`
 	}
 
 	CreateProductRequest struct {
@@ -36,17 +53,71 @@ type (
 		Merk        *string  `json:"merk"`
 		Material    *string  `json:"material"`
 		Price       *float32 `json:"price"`
+		Version     *int64   `json:"version"`
 	}
 
 	DeleteProductRequest struct {
 		ProductID string `json:"-"`
 	}
 
+	RestoreProductRequest struct {
+		ProductID string `json:"-"`
+	}
+
+	PurgeProductRequest struct {
+		ProductID string `json:"-"`
+	}
+
 	ProductPaginationRequest struct {
 		PaginationRequest
+		ProductFilter
 		UserID string `form:"id"`
 	}
 
+	// ProductFilter narrows product lists; facet counts are computed for the
+	// same filters so the numbers match what the list would return.
+	ProductFilter struct {
+		Merk     []string `form:"merk"`
+		Material []string `form:"material"`
+		MinPrice *float32 `form:"min_price"`
+		MaxPrice *float32 `form:"max_price"`
+	}
+
+	FacetCount struct {
+		Value string `json:"value"`
+		Count int64  `json:"count"`
+	}
+
+	PriceBucketCount struct {
+		Min   float32  `json:"min"`
+		Max   *float32 `json:"max"`
+		Count int64    `json:"count"`
+	}
+
+	ProductFacets struct {
+		Merk     []FacetCount       `json:"merk"`
+		Material []FacetCount       `json:"material"`
+		Price    []PriceBucketCount `json:"price"`
+		Category []FacetCount       `json:"category,omitempty"`
+	}
+
+	SuggestProductRequest struct {
+		Q     string `form:"q"`
+		Limit int    `form:"limit"`
+	}
+
+	ProductSuggestion struct {
+		Value string  `json:"value"`
+		Type  string  `json:"type"`
+		Score float32 `json:"score"`
+	}
+
+	ProductSearchResponse struct {
+		PaginationResponse
+		Items  []ProductResponse `json:"items"`
+		Facets ProductFacets     `json:"facets"`
+	}
+
 	ProductPaginationResponse struct {
 		PaginationResponse
 		Data []ProductResponse `json:"data"`
@@ -0,0 +1,470 @@
+package product
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/policy"
+	"gorm.io/gorm"
+)
+
+const (
+	IMPORT_KEY_MERK = "merk"
+	IMPORT_KEY_ID   = "id"
+
+	IMPORT_STATUS_PENDING   = "pending"
+	IMPORT_STATUS_RUNNING   = "running"
+	IMPORT_STATUS_COMPLETED = "completed"
+	IMPORT_STATUS_FAILED    = "failed"
+
+	// IMPORT_MAX_FILE_SIZE bounds the upload read by the controller.
+	IMPORT_MAX_FILE_SIZE = 10 << 20
+
+	// Rows written per transaction. A failing chunk is rolled back and
+	// reported without stopping the chunks after it.
+	importChunkSize = 100
+
+	// Files with more rows than this are imported in the background.
+	importSyncLimit = 500
+
+	// Finished jobs are kept this long for polling.
+	importJobRetention = 24 * time.Hour
+)
+
+var importKeys = map[string]bool{
+	IMPORT_KEY_MERK: true,
+	IMPORT_KEY_ID:   true,
+}
+
+type (
+	// ProductImportRow is one record of an import file. CSV headers map to
+	// the json names.
+	ProductImportRow struct {
+		ID          string  `json:"id"`
+		Name        string  `json:"name"`
+		Description string  `json:"description"`
+		Merk        string  `json:"merk"`
+		Material    string  `json:"material"`
+		Price       float32 `json:"price"`
+	}
+
+	ImportProductRequest struct {
+		Format string `form:"format"`
+		Key    string `form:"key"`
+		DryRun bool   `form:"dry_run"`
+		Async  bool   `form:"async"`
+		Data   []byte `form:"-"`
+	}
+
+	ImportRowError struct {
+		Row    int      `json:"row"`
+		Key    string   `json:"key"`
+		Errors []string `json:"errors"`
+	}
+
+	ImportResult struct {
+		Total   int              `json:"total"`
+		Created int              `json:"created"`
+		Updated int              `json:"updated"`
+		Failed  int              `json:"failed"`
+		Errors  []ImportRowError `json:"errors"`
+	}
+
+	ImportJobResponse struct {
+		ID         string        `json:"id"`
+		Status     string        `json:"status"`
+		DryRun     bool          `json:"dry_run"`
+		Key        string        `json:"key"`
+		Processed  int           `json:"processed"`
+		Result     *ImportResult `json:"result,omitempty"`
+		Error      string        `json:"error,omitempty"`
+		CreatedAt  time.Time     `json:"created_at"`
+		FinishedAt *time.Time    `json:"finished_at,omitempty"`
+	}
+
+	// ProductUpsert reports what UpsertProducts did with one product.
+	ProductUpsert struct {
+		Product  Product
+		Created  bool
+		OldPrice float32
+	}
+
+	// ImportJobStore keeps import jobs in memory so clients can poll them.
+	// Jobs do not survive a restart.
+	ImportJobStore struct {
+		mu   sync.RWMutex
+		jobs map[string]*ImportJobResponse
+	}
+
+	importRow struct {
+		index   int
+		key     string
+		product Product
+	}
+)
+
+func NewImportJobStore() *ImportJobStore {
+	return &ImportJobStore{
+		jobs: make(map[string]*ImportJobResponse),
+	}
+}
+
+func (s *ImportJobStore) add(job *ImportJobResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := time.Now().Add(-importJobRetention)
+	for id, old := range s.jobs {
+		if old.FinishedAt != nil && old.FinishedAt.Before(cutoff) {
+			delete(s.jobs, id)
+		}
+	}
+
+	s.jobs[job.ID] = job
+}
+
+func (s *ImportJobStore) update(id string, fn func(job *ImportJobResponse)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if job, ok := s.jobs[id]; ok {
+		fn(job)
+	}
+}
+
+func (s *ImportJobStore) get(id string) (ImportJobResponse, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return ImportJobResponse{}, false
+	}
+	return *job, true
+}
+
+func (ps *ProductService) ImportProducts(ctx context.Context, req ImportProductRequest) (ImportJobResponse, error) {
+	if req.Key == "" {
+		req.Key = IMPORT_KEY_MERK
+	}
+	if !importKeys[req.Key] {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_IMPORT_PRODUCT+": unsupported key %q", req.Key)
+		return ImportJobResponse{}, constants.ErrImportKey
+	}
+
+	if req.Format != helpers.RECORD_FORMAT_CSV && req.Format != helpers.RECORD_FORMAT_JSON {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_IMPORT_PRODUCT+": unsupported format %q", req.Format)
+		return ImportJobResponse{}, constants.ErrImportFormat
+	}
+
+	records, decodeErrs, err := helpers.DecodeRecords[ProductImportRow](bytes.NewReader(req.Data), req.Format)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_IMPORT_PRODUCT)
+		return ImportJobResponse{}, constants.ErrImportFile
+	}
+
+	job := &ImportJobResponse{
+		ID:        uuid.NewString(),
+		Status:    IMPORT_STATUS_PENDING,
+		DryRun:    req.DryRun,
+		Key:       req.Key,
+		CreatedAt: time.Now(),
+	}
+	ps.importJobs.add(job)
+
+	if req.Async || len(records) > importSyncLimit {
+		// The import outlives the request but keeps its actor for auditing.
+		go ps.runImport(context.WithoutCancel(ctx), job.ID, req, records, decodeErrs)
+
+		logging.Log.Infof(constants.MESSAGE_SUCCESS_QUEUE_IMPORT_PRODUCT+": %s (%d rows)", job.ID, len(records))
+		current, _ := ps.importJobs.get(job.ID)
+		return current, nil
+	}
+
+	ps.runImport(ctx, job.ID, req, records, decodeErrs)
+
+	current, _ := ps.importJobs.get(job.ID)
+	return current, nil
+}
+
+func (ps *ProductService) GetImportJob(ctx context.Context, jobID string) (ImportJobResponse, error) {
+	job, found := ps.importJobs.get(jobID)
+	if !found {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_GET_IMPORT_JOB+": %s", jobID)
+		return ImportJobResponse{}, constants.ErrImportJobNotFound
+	}
+
+	return job, nil
+}
+
+func (ps *ProductService) runImport(ctx context.Context, jobID string, req ImportProductRequest, records []ProductImportRow, decodeErrs []error) {
+	ps.importJobs.update(jobID, func(job *ImportJobResponse) {
+		job.Status = IMPORT_STATUS_RUNNING
+	})
+
+	result, err := ps.importRows(ctx, jobID, req, records, decodeErrs)
+
+	finishedAt := time.Now()
+	ps.importJobs.update(jobID, func(job *ImportJobResponse) {
+		job.FinishedAt = &finishedAt
+		if err != nil {
+			job.Status = IMPORT_STATUS_FAILED
+			job.Error = err.Error()
+			return
+		}
+		job.Status = IMPORT_STATUS_COMPLETED
+		job.Result = &result
+	})
+
+	if err != nil {
+		logging.Log.WithError(err).WithField("job", jobID).Error(constants.MESSAGE_FAILED_IMPORT_PRODUCT)
+		return
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_IMPORT_PRODUCT+": %s created %d, updated %d, failed %d",
+		jobID, result.Created, result.Updated, result.Failed)
+}
+
+func (ps *ProductService) importRows(ctx context.Context, jobID string, req ImportProductRequest, records []ProductImportRow, decodeErrs []error) (ImportResult, error) {
+	result := ImportResult{
+		Total:  len(records),
+		Errors: []ImportRowError{},
+	}
+
+	rows, rowErrs := validateImportRows(records, decodeErrs, req.Key)
+	result.Errors = append(result.Errors, rowErrs...)
+	result.Failed = len(rowErrs)
+
+	// New products belong to the importer; existing ones keep their owner.
+	owner := policy.Owner(ctx)
+	for i := range rows {
+		rows[i].product.OwnerID = owner
+	}
+
+	if req.DryRun {
+		return result, ps.planImport(ctx, jobID, req.Key, rows, &result)
+	}
+
+	written := false
+	for start := 0; start < len(rows); start += importChunkSize {
+		chunk := rows[start:min(start+importChunkSize, len(rows))]
+
+		var upserts []ProductUpsert
+		var denied []ImportRowError
+		err := ps.productRepo.RunInTransaction(ctx, func(tx *gorm.DB) error {
+			allowed, updates, rowErrs, err := ps.authorizeImportRows(ctx, tx, req.Key, chunk)
+			if err != nil {
+				return err
+			}
+			denied = rowErrs
+			if len(allowed) == 0 {
+				return nil
+			}
+
+			products := make([]Product, 0, len(allowed))
+			for _, row := range allowed {
+				products = append(products, row.product)
+			}
+
+			upserts, err = ps.productRepo.UpsertProducts(ctx, tx, products, req.Key)
+			if err != nil {
+				return err
+			}
+
+			// A product added with the same key since the lookup was updated
+			// without its owner being checked.
+			for _, upsert := range upserts {
+				if !upsert.Created && !updates[importKeyValue(upsert.Product, req.Key)] {
+					return fmt.Errorf("product %s was created concurrently", upsert.Product.ID)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			logging.Log.WithError(err).WithField("job", jobID).Warnf(constants.MESSAGE_FAILED_IMPORT_PRODUCT+": rows %d-%d", chunk[0].index, chunk[len(chunk)-1].index)
+			for _, row := range chunk {
+				result.Errors = append(result.Errors, ImportRowError{
+					Row:    row.index,
+					Key:    row.key,
+					Errors: []string{constants.ErrImportProduct.Error()},
+				})
+			}
+			result.Failed += len(chunk)
+		} else {
+			result.Errors = append(result.Errors, denied...)
+			result.Failed += len(denied)
+
+			written = written || len(upserts) > 0
+			for _, upsert := range upserts {
+				if upsert.Created {
+					result.Created++
+					continue
+				}
+
+				result.Updated++
+				ps.notifyPriceChanged(ctx, upsert.Product, upsert.OldPrice)
+			}
+		}
+
+		ps.importJobs.update(jobID, func(job *ImportJobResponse) {
+			job.Processed = start + len(chunk)
+		})
+	}
+
+	if written {
+		ps.suggestCache.Invalidate()
+	}
+
+	return result, nil
+}
+
+// planImport fills the dry-run counts by looking up which rows would update
+// an existing product, and which the actor may not write.
+func (ps *ProductService) planImport(ctx context.Context, jobID, key string, rows []importRow, result *ImportResult) error {
+	for start := 0; start < len(rows); start += importChunkSize {
+		chunk := rows[start:min(start+importChunkSize, len(rows))]
+
+		allowed, updates, rowErrs, err := ps.authorizeImportRows(ctx, nil, key, chunk)
+		if err != nil {
+			return err
+		}
+
+		result.Errors = append(result.Errors, rowErrs...)
+		result.Failed += len(rowErrs)
+		result.Updated += len(updates)
+		result.Created += len(allowed) - len(updates)
+
+		ps.importJobs.update(jobID, func(job *ImportJobResponse) {
+			job.Processed = start + len(chunk)
+		})
+	}
+
+	return nil
+}
+
+// authorizeImportRows keeps the rows the actor may write. A row matching an
+// existing product is an update and, as with UpdateProduct, only its owner
+// or an admin may make it, see policy.OwnerOrAdmin; the other rows fail.
+// The returned set holds the keys of the allowed updates.
+func (ps *ProductService) authorizeImportRows(ctx context.Context, tx *gorm.DB, key string, rows []importRow) ([]importRow, map[string]bool, []ImportRowError, error) {
+	values := make([]string, 0, len(rows))
+	for _, row := range rows {
+		values = append(values, row.key)
+	}
+
+	existing, err := ps.productRepo.GetProductsByKey(ctx, tx, key, values)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	owners := make(map[string]*uuid.UUID, len(existing))
+	for _, product := range existing {
+		owners[importKeyValue(product, key)] = product.OwnerID
+	}
+
+	allowed := make([]importRow, 0, len(rows))
+	updates := make(map[string]bool, len(existing))
+	var rowErrs []ImportRowError
+	for _, row := range rows {
+		owner, found := owners[row.key]
+		if !found {
+			allowed = append(allowed, row)
+			continue
+		}
+
+		if err := policy.OwnerOrAdmin(ctx, owner); err != nil {
+			rowErrs = append(rowErrs, ImportRowError{Row: row.index, Key: row.key, Errors: []string{err.Error()}})
+			continue
+		}
+
+		updates[row.key] = true
+		allowed = append(allowed, row)
+	}
+
+	return allowed, updates, rowErrs, nil
+}
+
+// validateImportRows checks every record against the CreateProduct rules and
+// the import key. Rows are numbered from 1 in file order.
+func validateImportRows(records []ProductImportRow, decodeErrs []error, key string) ([]importRow, []ImportRowError) {
+	var rows []importRow
+	var rowErrs []ImportRowError
+
+	seen := make(map[string]int, len(records))
+	for i, record := range records {
+		index := i + 1
+		keyValue := record.Merk
+		if key == IMPORT_KEY_ID {
+			keyValue = record.ID
+		}
+
+		if decodeErrs[i] != nil {
+			rowErrs = append(rowErrs, ImportRowError{Row: index, Key: keyValue, Errors: []string{decodeErrs[i].Error()}})
+			continue
+		}
+
+		var errs []error
+		if record.Merk == "" {
+			errs = append(errs, constants.ErrInvalidMerk)
+		}
+
+		id := uuid.New()
+		if key == IMPORT_KEY_ID || record.ID != "" {
+			parsed, err := uuid.Parse(record.ID)
+			if err != nil {
+				errs = append(errs, constants.ErrInvalidUUID)
+			}
+			id = parsed
+		}
+
+		errs = append(errs, validateProduct(record.Name, record.Description, record.Price)...)
+
+		if first, duplicate := seen[keyValue]; duplicate && keyValue != "" {
+			errs = append(errs, fmt.Errorf("duplicate %s, first seen in row %d", key, first))
+		} else {
+			seen[keyValue] = index
+		}
+
+		if len(errs) > 0 {
+			messages := make([]string, 0, len(errs))
+			for _, err := range errs {
+				messages = append(messages, err.Error())
+			}
+			rowErrs = append(rowErrs, ImportRowError{Row: index, Key: keyValue, Errors: messages})
+			continue
+		}
+
+		now := time.Now()
+		rows = append(rows, importRow{
+			index: index,
+			key:   keyValue,
+			product: Product{
+				ID:          id,
+				Name:        record.Name,
+				Description: record.Description,
+				Merk:        record.Merk,
+				Material:    record.Material,
+				Price:       record.Price,
+				Version:     1,
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			},
+		})
+	}
+
+	return rows, rowErrs
+}
+
+func importKeyValue(product Product, key string) string {
+	if key == IMPORT_KEY_ID {
+		return product.ID.String()
+	}
+	return product.Merk
+}
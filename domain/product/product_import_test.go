@@ -0,0 +1,124 @@
+package product
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&Product{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func createTestProduct(t *testing.T, db *gorm.DB, merk string, owner *uuid.UUID) Product {
+	t.Helper()
+
+	product := Product{
+		ID:          uuid.New(),
+		Name:        "Product " + merk,
+		Description: "A product for testing",
+		Merk:        merk,
+		Price:       10,
+		OwnerID:     owner,
+		Version:     1,
+	}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("create product: %v", err)
+	}
+	return product
+}
+
+func TestImportProductsChecksOwners(t *testing.T) {
+	db := newTestDB(t)
+	service := NewProductService(NewProductRepository(db), nil)
+
+	importer, other := uuid.New(), uuid.New()
+	own := createTestProduct(t, db, "own", &importer)
+	foreign := createTestProduct(t, db, "foreign", &other)
+	unowned := createTestProduct(t, db, "unowned", nil)
+
+	data := "merk,name,description,price\n" +
+		"own,Imported own,Updated by the importer,20\n" +
+		"foreign,Imported foreign,Updated by the importer,20\n" +
+		"unowned,Imported unowned,Updated by the importer,20\n" +
+		"new,Imported new,Created by the importer,20\n"
+
+	tests := []struct {
+		name                     string
+		role                     string
+		dryRun                   bool
+		created, updated, failed int
+		denied                   []string
+	}{
+		{"user dry run", constants.ENUM_ROLE_USER, true, 1, 1, 2, []string{"foreign", "unowned"}},
+		{"user", constants.ENUM_ROLE_USER, false, 1, 1, 2, []string{"foreign", "unowned"}},
+		{"admin", constants.ENUM_ROLE_ADMIN, false, 0, 4, 0, nil},
+	}
+
+	for _, tt := range tests {
+		ctx := helpers.WithActor(context.Background(), importer.String(), tt.role)
+		job, err := service.ImportProducts(ctx, ImportProductRequest{
+			Format: helpers.RECORD_FORMAT_CSV,
+			Key:    IMPORT_KEY_MERK,
+			DryRun: tt.dryRun,
+			Data:   []byte(data),
+		})
+		if err != nil || job.Result == nil {
+			t.Fatalf("%s: ImportProducts = %+v, %v", tt.name, job, err)
+		}
+
+		result := job.Result
+		if result.Created != tt.created || result.Updated != tt.updated || result.Failed != tt.failed {
+			t.Errorf("%s: created %d, updated %d, failed %d; want %d, %d, %d",
+				tt.name, result.Created, result.Updated, result.Failed, tt.created, tt.updated, tt.failed)
+		}
+
+		var denied []string
+		for _, rowErr := range result.Errors {
+			if len(rowErr.Errors) != 1 || rowErr.Errors[0] != constants.ErrDeniedAccess.Error() {
+				t.Errorf("%s: row %d errors = %v, want %v", tt.name, rowErr.Row, rowErr.Errors, constants.ErrDeniedAccess)
+			}
+			denied = append(denied, rowErr.Key)
+		}
+		if strings.Join(denied, ",") != strings.Join(tt.denied, ",") {
+			t.Errorf("%s: denied rows = %v, want %v", tt.name, denied, tt.denied)
+		}
+
+		if tt.name == "user" {
+			for _, product := range []Product{foreign, unowned} {
+				var current Product
+				db.First(&current, "id = ?", product.ID)
+				if current.Name != product.Name || current.Version != product.Version {
+					t.Errorf("%s: product %s of someone else changed to %+v", tt.name, product.Merk, current)
+				}
+			}
+
+			var current Product
+			db.First(&current, "id = ?", own.ID)
+			if current.Name != "Imported own" || current.OwnerID == nil || *current.OwnerID != importer {
+				t.Errorf("%s: own product = %+v, want updated and still owned by the importer", tt.name, current)
+			}
+		}
+	}
+}
@@ -11,11 +11,28 @@ type Product struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Name        string    `json:"name"`
 	Description string    `json:"description"`
-	Merk        string    `gorm:"unique; not null" json:"merk"`
+	Merk        string    `gorm:"not null;uniqueIndex:idx_products_merk_active,where:deleted_at IS NULL" json:"merk"`
 	Material    string    `json:"material"`
 	Price       float32   `json:"price"`
 
+	// OwnerID is the user who created the product. Only the owner and admins
+	// may change or delete it; products without an owner are left to admins.
+	OwnerID *uuid.UUID `gorm:"type:uuid;index" json:"owner_id"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+
+	// Version guards against lost updates, see UpdateProduct.
+	Version int64 `gorm:"not null;default:1" json:"version"`
+
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	DeletedBy *uuid.UUID `gorm:"type:uuid" json:"deleted_by"`
+
+	// Populated only by full-text search queries, see SearchRanking and
+	// SearchColumns.
+	SearchRank           float32 `gorm:"->;-:migration" json:"-"`
+	NameHighlight        string  `gorm:"->;-:migration" json:"-"`
+	DescriptionHighlight string  `gorm:"->;-:migration" json:"-"`
 }
@@ -2,24 +2,43 @@ package product
 
 import (
 	"context"
+	"fmt"
 	"math"
 	"strings"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type (
 	IProductRepository interface {
+		RunInTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error
 		CreateProduct(ctx context.Context, tx *gorm.DB, product Product) error
 		GetProductByID(ctx context.Context, tx *gorm.DB, productID string) (Product, bool, error)
+		GetProductByMerk(ctx context.Context, tx *gorm.DB, merk string) (Product, bool, error)
 		GetAllProduct(ctx context.Context, tx *gorm.DB, search string) ([]Product, error)
 		GetAllProductWithPagination(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest) (ProductPaginationRepositoryResponse, error)
-		UpdateProduct(ctx context.Context, tx *gorm.DB, product Product) error
+		GetProductFacets(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest) (ProductFacets, error)
+		StreamProducts(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest, fn func(product Product) error) error
+		GetSuggestionTerms(ctx context.Context, tx *gorm.DB) ([]ProductSuggestion, error)
+		SuggestProduct(ctx context.Context, tx *gorm.DB, q string, minSimilarity float32, limit int) ([]ProductSuggestion, error)
+		UpdateProduct(ctx context.Context, tx *gorm.DB, product Product) (bool, error)
 		DeleteProduct(ctx context.Context, tx *gorm.DB, productID string) error
+		GetTrashedProducts(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest) (ProductPaginationRepositoryResponse, error)
+		GetTrashedProductByID(ctx context.Context, tx *gorm.DB, productID string) (Product, bool, error)
+		RestoreProduct(ctx context.Context, tx *gorm.DB, productID string) error
+		PurgeProduct(ctx context.Context, tx *gorm.DB, productID string) error
+		PurgeProductsDeletedBefore(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error)
+		GetProductsByKey(ctx context.Context, tx *gorm.DB, key string, values []string) ([]Product, error)
+		UpsertProducts(ctx context.Context, tx *gorm.DB, products []Product, key string) ([]ProductUpsert, error)
 	}
 
 	ProductRepository struct {
 		db *gorm.DB
+
+		categoryOnce sync.Once
+		hasCategory  bool
 	}
 )
 
@@ -37,6 +56,13 @@ func Paginate(page, perPage int) func(db *gorm.DB) *gorm.DB {
 	}
 }
 
+// RunInTransaction calls fn with a transaction that is committed when fn
+// returns nil and rolled back otherwise. Pass tx on to the other repository
+// methods to make them part of it.
+func (pr *ProductRepository) RunInTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return pr.db.WithContext(ctx).Transaction(fn)
+}
+
 func (pr *ProductRepository) CreateProduct(ctx context.Context, tx *gorm.DB, product Product) error {
 	if tx == nil {
 		tx = pr.db
@@ -58,26 +84,37 @@ func (pr *ProductRepository) GetProductByID(ctx context.Context, tx *gorm.DB, pr
 	return product, true, nil
 }
 
+func (pr *ProductRepository) GetProductByMerk(ctx context.Context, tx *gorm.DB, merk string) (Product, bool, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var product Product
+	if err := tx.WithContext(ctx).Where("merk = ?", merk).Take(&product).Error; err != nil {
+		return Product{}, false, err
+	}
+
+	return product, true, nil
+}
+
 func (pr *ProductRepository) GetAllProduct(ctx context.Context, tx *gorm.DB, search string) ([]Product, error) {
 	if tx == nil {
 		tx = pr.db
 	}
 
-	var users []Product
+	var products []Product
 
 	query := tx.WithContext(ctx).Model(&Product{})
 
-	if search != "" {
-		searchValue := "%" + strings.ToLower(search) + "%"
-		query = query.Where("LOWER(name) LIKE ? OR LOWER(merk) LIKE ? OR LOWER(material) LIKE ?",
-			searchValue, searchValue, searchValue)
+	if tsQuery := BuildSearchQuery(search); tsQuery != "" {
+		query = query.Scopes(SearchFilter(tsQuery), SearchRanking(tsQuery))
 	}
 
-	if err := query.Find(&users).Error; err != nil {
+	if err := query.Order("created_at DESC").Find(&products).Error; err != nil {
 		return nil, err
 	}
 
-	return users, nil
+	return products, nil
 }
 
 func (pr *ProductRepository) GetAllProductWithPagination(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest) (ProductPaginationRepositoryResponse, error) {
@@ -97,50 +134,435 @@ func (pr *ProductRepository) GetAllProductWithPagination(ctx context.Context, tx
 		req.PaginationRequest.Page = 1
 	}
 
-	query := tx.WithContext(ctx).Model(&Product{})
+	query, tsQuery := listQuery(tx.WithContext(ctx), req)
+
+	if err := query.Count(&count).Error; err != nil {
+		return ProductPaginationRepositoryResponse{}, err
+	}
+
+	if tsQuery != "" {
+		query = query.Scopes(SearchRanking(tsQuery))
+	}
+
+	if err := query.Order("created_at DESC").Scopes(Paginate(req.PaginationRequest.Page, req.PaginationRequest.PerPage)).Find(&product).Error; err != nil {
+		return ProductPaginationRepositoryResponse{}, err
+	}
+
+	totalPage := int64(math.Ceil(float64(count) / float64(req.PaginationRequest.PerPage)))
 
-	if req.PaginationRequest.Search != "" {
-		searchValue := "%" + strings.ToLower(req.PaginationRequest.Search) + "%"
-		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?",
-			searchValue, searchValue)
+	return ProductPaginationRepositoryResponse{
+		Products: product,
+		PaginationResponse: PaginationResponse{
+			Page:    req.PaginationRequest.Page,
+			PerPage: req.PaginationRequest.PerPage,
+			MaxPage: totalPage,
+			Count:   count,
+		},
+	}, err
+}
+
+// listQuery applies the filters shared by the product list, search and
+// export. It returns the full-text query so callers can rank by it.
+func listQuery(tx *gorm.DB, req ProductPaginationRequest) (*gorm.DB, string) {
+	query := tx.Model(&Product{})
+
+	tsQuery := BuildSearchQuery(req.PaginationRequest.Search)
+	if tsQuery != "" {
+		query = query.Scopes(SearchFilter(tsQuery))
 	}
 
+	query = query.Scopes(FilterScope(req.ProductFilter, ""))
+
 	if req.UserID != "" {
 		query = query.Where("id = ?", req.UserID)
 	}
 
+	return query, tsQuery
+}
+
+// StreamProducts walks every product matching the list filters with a
+// database cursor, in list order, calling fn for each one. Returning an error
+// from fn stops the walk.
+func (pr *ProductRepository) StreamProducts(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest, fn func(product Product) error) error {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	query, _ := listQuery(tx.WithContext(ctx), req)
+
+	rows, err := query.Order("created_at DESC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var product Product
+		if err := query.ScanRows(rows, &product); err != nil {
+			return err
+		}
+
+		if err := fn(product); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (pr *ProductRepository) GetProductFacets(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest) (ProductFacets, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	tsQuery := BuildSearchQuery(req.PaginationRequest.Search)
+
+	// Each facet ignores its own filter so it lists the alternatives to the
+	// current selection, the usual behaviour for storefront filters.
+	base := func(exclude string) *gorm.DB {
+		query := tx.WithContext(ctx).Model(&Product{}).Scopes(FilterScope(req.ProductFilter, exclude))
+		if tsQuery != "" {
+			query = query.Scopes(SearchFilter(tsQuery))
+		}
+		return query
+	}
+
+	var facets ProductFacets
+
+	if err := base(FACET_MERK).
+		Select("products.merk AS value, COUNT(*) AS count").
+		Where("products.merk <> ''").
+		Group("products.merk").
+		Order("count DESC, value ASC").
+		Scan(&facets.Merk).Error; err != nil {
+		return ProductFacets{}, err
+	}
+
+	if err := base(FACET_MATERIAL).
+		Select("products.material AS value, COUNT(*) AS count").
+		Where("products.material <> ''").
+		Group("products.material").
+		Order("count DESC, value ASC").
+		Scan(&facets.Material).Error; err != nil {
+		return ProductFacets{}, err
+	}
+
+	var buckets []struct {
+		Bucket int
+		Count  int64
+	}
+
+	bucketCase, bucketArgs := priceBucketCase()
+	if err := base(FACET_PRICE).
+		Select(bucketCase+" AS bucket, COUNT(*) AS count", bucketArgs...).
+		Group("bucket").
+		Scan(&buckets).Error; err != nil {
+		return ProductFacets{}, err
+	}
+
+	facets.Price = make([]PriceBucketCount, len(PriceBucketEdges)+1)
+	for i := range facets.Price {
+		if i > 0 {
+			facets.Price[i].Min = PriceBucketEdges[i-1]
+		}
+		if i < len(PriceBucketEdges) {
+			max := PriceBucketEdges[i]
+			facets.Price[i].Max = &max
+		}
+	}
+	for _, b := range buckets {
+		if b.Bucket >= 0 && b.Bucket < len(facets.Price) {
+			facets.Price[b.Bucket].Count = b.Count
+		}
+	}
+
+	pr.categoryOnce.Do(func() {
+		pr.hasCategory = pr.db.Migrator().HasColumn(&Product{}, FACET_CATEGORY)
+	})
+
+	if pr.hasCategory {
+		if err := base(FACET_CATEGORY).
+			Select("products.category AS value, COUNT(*) AS count").
+			Where("products.category IS NOT NULL AND products.category <> ''").
+			Group("products.category").
+			Order("count DESC, value ASC").
+			Scan(&facets.Category).Error; err != nil {
+			return ProductFacets{}, err
+		}
+	}
+
+	return facets, nil
+}
+
+func (pr *ProductRepository) GetSuggestionTerms(ctx context.Context, tx *gorm.DB) ([]ProductSuggestion, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var terms []ProductSuggestion
+	if err := tx.WithContext(ctx).Raw(`
+		SELECT DISTINCT name AS value, ? AS type FROM products WHERE deleted_at IS NULL AND name <> ''
+		UNION
+		SELECT DISTINCT merk AS value, ? AS type FROM products WHERE deleted_at IS NULL AND merk <> ''`,
+		SUGGESTION_TYPE_PRODUCT, SUGGESTION_TYPE_BRAND,
+	).Scan(&terms).Error; err != nil {
+		return nil, err
+	}
+
+	return terms, nil
+}
+
+// SuggestProduct finds names and brands close to q using pg_trgm, which
+// tolerates small typos that a plain prefix match would miss. The <% operator
+// is served by the trigram GIN indexes on name and merk.
+func (pr *ProductRepository) SuggestProduct(ctx context.Context, tx *gorm.DB, q string, minSimilarity float32, limit int) ([]ProductSuggestion, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var suggestions []ProductSuggestion
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT set_config('pg_trgm.word_similarity_threshold', ?, true)",
+			fmt.Sprintf("%.2f", minSimilarity)).Error; err != nil {
+			return err
+		}
+
+		return tx.Raw(`
+			SELECT value, type, MAX(score) AS score FROM (
+				SELECT name AS value, ? AS type, word_similarity(?, name) AS score
+				FROM products WHERE deleted_at IS NULL AND ? <% name
+				UNION ALL
+				SELECT merk AS value, ? AS type, word_similarity(?, merk) AS score
+				FROM products WHERE deleted_at IS NULL AND ? <% merk
+			) matches
+			GROUP BY value, type
+			ORDER BY score DESC, value ASC
+			LIMIT ?`,
+			SUGGESTION_TYPE_PRODUCT, q, q,
+			SUGGESTION_TYPE_BRAND, q, q,
+			limit,
+		).Scan(&suggestions).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return suggestions, nil
+}
+
+// priceBucketCase builds a CASE expression mapping price to the index of its
+// bucket in PriceBucketEdges.
+func priceBucketCase() (string, []any) {
+	var sb strings.Builder
+	args := make([]any, 0, len(PriceBucketEdges))
+
+	sb.WriteString("CASE")
+	for i, edge := range PriceBucketEdges {
+		fmt.Fprintf(&sb, " WHEN products.price < ? THEN %d", i)
+		args = append(args, edge)
+	}
+	fmt.Fprintf(&sb, " ELSE %d END", len(PriceBucketEdges))
+
+	return sb.String(), args
+}
+
+// UpdateProduct only writes when product.Version still matches the stored
+// version and bumps it in the same statement. It reports false when the row
+// was changed concurrently.
+func (pr *ProductRepository) UpdateProduct(ctx context.Context, tx *gorm.DB, product Product) (bool, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	result := tx.WithContext(ctx).Model(&Product{}).
+		Where("id = ? AND version = ?", product.ID, product.Version).
+		Updates(map[string]interface{}{
+			"name":        product.Name,
+			"description": product.Description,
+			"merk":        product.Merk,
+			"material":    product.Material,
+			"price":       product.Price,
+			"updated_at":  product.UpdatedAt,
+			"version":     gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (pr *ProductRepository) DeleteProduct(ctx context.Context, tx *gorm.DB, productID string) error {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	return tx.WithContext(ctx).Where("id = ?", productID).Delete(&Product{}).Error
+}
+
+func (pr *ProductRepository) GetTrashedProducts(ctx context.Context, tx *gorm.DB, req ProductPaginationRequest) (ProductPaginationRepositoryResponse, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var products []Product
+	var count int64
+
+	if req.PaginationRequest.PerPage == 0 {
+		req.PaginationRequest.PerPage = 10
+	}
+
+	if req.PaginationRequest.Page == 0 {
+		req.PaginationRequest.Page = 1
+	}
+
+	query := tx.WithContext(ctx).Unscoped().Model(&Product{}).Where("products.deleted_at IS NOT NULL")
+
+	if tsQuery := BuildSearchQuery(req.PaginationRequest.Search); tsQuery != "" {
+		query = query.Scopes(SearchFilter(tsQuery))
+	}
+
 	if err := query.Count(&count).Error; err != nil {
 		return ProductPaginationRepositoryResponse{}, err
 	}
 
-	if err := query.Order("created_at DESC").Scopes(Paginate(req.PaginationRequest.Page, req.PaginationRequest.PerPage)).Find(&product).Error; err != nil {
+	if err := query.Order("deleted_at DESC").Scopes(Paginate(req.PaginationRequest.Page, req.PaginationRequest.PerPage)).Find(&products).Error; err != nil {
 		return ProductPaginationRepositoryResponse{}, err
 	}
 
 	totalPage := int64(math.Ceil(float64(count) / float64(req.PaginationRequest.PerPage)))
 
 	return ProductPaginationRepositoryResponse{
-		Products: product,
+		Products: products,
 		PaginationResponse: PaginationResponse{
 			Page:    req.PaginationRequest.Page,
 			PerPage: req.PaginationRequest.PerPage,
 			MaxPage: totalPage,
 			Count:   count,
 		},
-	}, err
+	}, nil
 }
 
-func (pr *ProductRepository) UpdateProduct(ctx context.Context, tx *gorm.DB, product Product) error {
+func (pr *ProductRepository) GetTrashedProductByID(ctx context.Context, tx *gorm.DB, productID string) (Product, bool, error) {
 	if tx == nil {
 		tx = pr.db
 	}
 
-	return tx.WithContext(ctx).Where("id = ?", product.ID).Updates(&product).Error
+	var product Product
+	if err := tx.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", productID).Take(&product).Error; err != nil {
+		return Product{}, false, err
+	}
+
+	return product, true, nil
 }
-func (pr *ProductRepository) DeleteProduct(ctx context.Context, tx *gorm.DB, productID string) error {
+
+func (pr *ProductRepository) RestoreProduct(ctx context.Context, tx *gorm.DB, productID string) error {
 	if tx == nil {
 		tx = pr.db
 	}
 
-	return tx.WithContext(ctx).Where("id = ?", productID).Delete(&Product{}).Error
+	return tx.WithContext(ctx).Unscoped().Model(&Product{}).
+		Where("id = ? AND deleted_at IS NOT NULL", productID).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil}).Error
+}
+
+func (pr *ProductRepository) PurgeProduct(ctx context.Context, tx *gorm.DB, productID string) error {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	return tx.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", productID).Delete(&Product{}).Error
+}
+
+func (pr *ProductRepository) PurgeProductsDeletedBefore(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	result := tx.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&Product{})
+	return result.RowsAffected, result.Error
+}
+
+// GetProductsByKey returns the active products whose key column, one of
+// IMPORT_KEY_MERK or IMPORT_KEY_ID, is in values.
+func (pr *ProductRepository) GetProductsByKey(ctx context.Context, tx *gorm.DB, key string, values []string) ([]Product, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	if !importKeys[key] {
+		return nil, fmt.Errorf("unsupported product key %q", key)
+	}
+
+	var products []Product
+	if len(values) == 0 {
+		return products, nil
+	}
+
+	err := tx.WithContext(ctx).Where(key+" IN ?", values).Find(&products).Error
+	return products, err
+}
+
+// UpsertProducts updates the products that already exist by key and creates
+// the rest, all in one transaction. Updates go through UpdateProduct, so a
+// product changed concurrently fails the whole batch instead of being
+// overwritten.
+func (pr *ProductRepository) UpsertProducts(ctx context.Context, tx *gorm.DB, products []Product, key string) ([]ProductUpsert, error) {
+	if tx == nil {
+		tx = pr.db
+	}
+
+	var result []ProductUpsert
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		values := make([]string, 0, len(products))
+		for _, product := range products {
+			values = append(values, importKeyValue(product, key))
+		}
+
+		existing, err := pr.GetProductsByKey(ctx, tx, key, values)
+		if err != nil {
+			return err
+		}
+
+		byKey := make(map[string]Product, len(existing))
+		for _, product := range existing {
+			byKey[importKeyValue(product, key)] = product
+		}
+
+		result = make([]ProductUpsert, 0, len(products))
+		var creates []Product
+		for _, product := range products {
+			current, found := byKey[importKeyValue(product, key)]
+			if !found {
+				creates = append(creates, product)
+				result = append(result, ProductUpsert{Product: product, Created: true})
+				continue
+			}
+
+			product.ID = current.ID
+			product.Version = current.Version
+			product.CreatedAt = current.CreatedAt
+
+			updated, err := pr.UpdateProduct(ctx, tx, product)
+			if err != nil {
+				return err
+			}
+			if !updated {
+				return fmt.Errorf("product %s was updated concurrently", product.ID)
+			}
+
+			product.Version++
+			result = append(result, ProductUpsert{Product: product, OldPrice: current.Price})
+		}
+
+		if len(creates) == 0 {
+			return nil
+		}
+		return tx.Create(&creates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
 }
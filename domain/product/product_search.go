@@ -0,0 +1,97 @@
+package product
+
+import (
+	"strings"
+	"unicode"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SEARCH_CONFIG is the text search configuration used both by the
+	// generated search_vector column and by queries. "simple" avoids
+	// English stemming on the mostly Indonesian catalogue.
+	SEARCH_CONFIG = "simple"
+
+	searchHeadlineOptions = "StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2"
+)
+
+// BuildSearchQuery turns free text into a tsquery where every term is
+// prefix matched, so "sep lar" matches "Sepatu Lari" while typing.
+func BuildSearchQuery(search string) string {
+	terms := strings.FieldsFunc(strings.ToLower(search), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// SearchFilter restricts the query to products matching tsQuery.
+func SearchFilter(tsQuery string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("products.search_vector @@ to_tsquery(?, ?)", SEARCH_CONFIG, tsQuery)
+	}
+}
+
+// SearchColumns are the Product fields only SearchRanking selects. Queries
+// joining products must omit them, see GetWishlistByUserID.
+var SearchColumns = []string{"search_rank", "name_highlight", "description_highlight"}
+
+// SearchRanking selects the rank and highlight snippets for tsQuery and
+// orders the best matches first. Apply it after counting.
+func SearchRanking(tsQuery string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.
+			Select(
+				"products.*, "+
+					"ts_rank_cd(products.search_vector, to_tsquery(?, ?)) AS search_rank, "+
+					"ts_headline(?, products.name, to_tsquery(?, ?), ?) AS name_highlight, "+
+					"ts_headline(?, products.description, to_tsquery(?, ?), ?) AS description_highlight",
+				SEARCH_CONFIG, tsQuery,
+				SEARCH_CONFIG, SEARCH_CONFIG, tsQuery, searchHeadlineOptions,
+				SEARCH_CONFIG, SEARCH_CONFIG, tsQuery, searchHeadlineOptions,
+			).
+			Order("search_rank DESC")
+	}
+}
+
+const (
+	FACET_MERK     = "merk"
+	FACET_MATERIAL = "material"
+	FACET_PRICE    = "price"
+	FACET_CATEGORY = "category"
+)
+
+// PriceBucketEdges are the upper bounds of the price facet buckets; the last
+// bucket is open ended.
+var PriceBucketEdges = []float32{100000, 500000, 1000000, 5000000}
+
+// FilterScope applies filter to the query, skipping the exclude dimension so
+// a facet can count the alternatives to its own selection.
+func FilterScope(filter ProductFilter, exclude string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if exclude != FACET_MERK && len(filter.Merk) > 0 {
+			db = db.Where("products.merk IN ?", filter.Merk)
+		}
+
+		if exclude != FACET_MATERIAL && len(filter.Material) > 0 {
+			db = db.Where("products.material IN ?", filter.Material)
+		}
+
+		if exclude != FACET_PRICE {
+			if filter.MinPrice != nil {
+				db = db.Where("products.price >= ?", *filter.MinPrice)
+			}
+
+			if filter.MaxPrice != nil {
+				db = db.Where("products.price <= ?", *filter.MaxPrice)
+			}
+		}
+
+		return db
+	}
+}
@@ -2,11 +2,16 @@ package product
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
 	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
 	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/policy"
+	"gorm.io/gorm"
 )
 
 type (
@@ -14,39 +19,87 @@ type (
 		CreateProduct(ctx context.Context, req CreateProductRequest) (ProductResponse, error)
 		GetAllProduct(ctx context.Context, search string) ([]ProductResponse, error)
 		GetAllProductWithPagination(ctx context.Context, req ProductPaginationRequest) (ProductPaginationResponse, error)
+		SearchProduct(ctx context.Context, req ProductPaginationRequest) (ProductSearchResponse, error)
+		ExportProducts(ctx context.Context, req ProductPaginationRequest, fn func(product ProductResponse) error) error
+		SuggestProduct(ctx context.Context, req SuggestProductRequest) ([]ProductSuggestion, error)
 		GetProductByID(ctx context.Context, productID string) (ProductResponse, error)
 		UpdateProduct(ctx context.Context, req UpdateProductRequest) (ProductResponse, error)
 		DeleteProduct(ctx context.Context, req DeleteProductRequest) (ProductResponse, error)
+		BatchProducts(ctx context.Context, req ProductBatchRequest) (ProductBatchResponse, error)
+
+		GetTrashedProducts(ctx context.Context, req ProductPaginationRequest) (ProductPaginationResponse, error)
+		RestoreProduct(ctx context.Context, req RestoreProductRequest) (ProductResponse, error)
+		PurgeProduct(ctx context.Context, req PurgeProductRequest) (ProductResponse, error)
+		PurgeDeletedProducts(ctx context.Context, before time.Time) (int64, error)
+
+		ImportProducts(ctx context.Context, req ImportProductRequest) (ImportJobResponse, error)
+		GetImportJob(ctx context.Context, jobID string) (ImportJobResponse, error)
+	}
+
+	// IPriceObserver is notified after a product's price has been changed.
+	IPriceObserver interface {
+		OnPriceChanged(ctx context.Context, product Product, oldPrice float32)
 	}
 
 	ProductService struct {
-		productRepo IProductRepository
-		jwtService  jwt.InterfaceJWTService
+		productRepo    IProductRepository
+		jwtService     jwt.InterfaceJWTService
+		priceObservers []IPriceObserver
+		suggestCache   *SuggestCache
+		importJobs     *ImportJobStore
 	}
 )
 
 func NewProductService(productRepo IProductRepository, jwtService jwt.InterfaceJWTService) *ProductService {
 	return &ProductService{
-		productRepo: productRepo,
-		jwtService:  jwtService,
+		productRepo:  productRepo,
+		jwtService:   jwtService,
+		suggestCache: NewSuggestCache(productRepo),
+		importJobs:   NewImportJobStore(),
 	}
 }
 
-func (ps *ProductService) CreateProduct(ctx context.Context, req CreateProductRequest) (ProductResponse, error) {
-	if len(req.Name) < 5 {
-		logging.Log.Warn(constants.MESSAGE_FAILED_CREATE_PRODUCT + ": name too short")
-		return ProductResponse{}, constants.ErrInvalidName
+// WarmSuggestCache loads the suggestion dictionary ahead of the first request.
+func (ps *ProductService) WarmSuggestCache(ctx context.Context) {
+	if err := ps.suggestCache.Refresh(ctx); err != nil {
+		logging.Log.WithError(err).Warn("failed warm suggestion cache")
 	}
+}
 
-	if len(req.Description) < 8 {
-		logging.Log.Warn(constants.MESSAGE_FAILED_CREATE_PRODUCT + ": description too short")
-		return ProductResponse{}, constants.ErrInvalidDescription
+func (ps *ProductService) RegisterPriceObserver(observer IPriceObserver) {
+	ps.priceObservers = append(ps.priceObservers, observer)
+}
+
+func (ps *ProductService) CreateProduct(ctx context.Context, req CreateProductRequest) (ProductResponse, error) {
+	product, err := ps.createProduct(ctx, nil, req)
+	if err != nil {
+		return ProductResponse{}, err
 	}
 
-	if req.Price <= 0 {
-		return ProductResponse{}, constants.ErrInvalidPrice
+	ps.suggestCache.Invalidate()
+
+	return ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Merk:        product.Merk,
+		Material:    product.Material,
+		Price:       product.Price,
+		OwnerID:     product.OwnerID,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
+	}, nil
+}
+
+// createProduct validates and stores a new product within tx. Cache and
+// observer side effects are left to the caller, which knows when tx commits.
+func (ps *ProductService) createProduct(ctx context.Context, tx *gorm.DB, req CreateProductRequest) (Product, error) {
+	if errs := validateProduct(req.Name, req.Description, req.Price); len(errs) > 0 {
+		logging.Log.Warn(constants.MESSAGE_FAILED_CREATE_PRODUCT + ": " + errs[0].Error())
+		return Product{}, errs[0]
 	}
 
+	now := time.Now()
 	product := Product{
 		ID:          uuid.New(),
 		Name:        req.Name,
@@ -54,24 +107,41 @@ func (ps *ProductService) CreateProduct(ctx context.Context, req CreateProductRe
 		Merk:        req.Merk,
 		Material:    req.Material,
 		Price:       req.Price,
+		OwnerID:     policy.Owner(ctx),
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
 	}
 
-	err := ps.productRepo.CreateProduct(ctx, nil, product)
+	err := ps.productRepo.CreateProduct(ctx, tx, product)
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_PRODUCT)
-		return ProductResponse{}, constants.ErrCretaeProduct
+		return Product{}, constants.ErrCretaeProduct
 	}
 
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_CREATE_PRODUCT+": %s", product.Name)
 
-	return ProductResponse{
-		ID:          product.ID,
-		Name:        product.Name,
-		Description: product.Description,
-		Merk:        product.Merk,
-		Material:    product.Material,
-		Price:       product.Price,
-	}, nil
+	return product, nil
+}
+
+// validateProduct applies the rules every new product has to pass and
+// returns all violations, so imports can report them per row.
+func validateProduct(name, description string, price float32) []error {
+	var errs []error
+
+	if len(name) < 5 {
+		errs = append(errs, constants.ErrInvalidName)
+	}
+
+	if len(description) < 8 {
+		errs = append(errs, constants.ErrInvalidDescription)
+	}
+
+	if price <= 0 {
+		errs = append(errs, constants.ErrInvalidPrice)
+	}
+
+	return errs
 }
 
 func (ps *ProductService) GetAllProduct(ctx context.Context, search string) ([]ProductResponse, error) {
@@ -90,6 +160,10 @@ func (ps *ProductService) GetAllProduct(ctx context.Context, search string) ([]P
 			Merk:        products.Merk,
 			Material:    products.Material,
 			Price:       products.Price,
+			OwnerID:     products.OwnerID,
+			Version:     products.Version,
+			UpdatedAt:   products.UpdatedAt,
+			Highlight:   searchHighlight(products),
 		}
 
 		datas = append(datas, data)
@@ -115,6 +189,10 @@ func (ps *ProductService) GetAllProductWithPagination(ctx context.Context, req P
 			Merk:        product.Merk,
 			Material:    product.Material,
 			Price:       product.Price,
+			OwnerID:     product.OwnerID,
+			Version:     product.Version,
+			UpdatedAt:   product.UpdatedAt,
+			Highlight:   searchHighlight(product),
 		})
 	}
 
@@ -129,19 +207,104 @@ func (ps *ProductService) GetAllProductWithPagination(ctx context.Context, req P
 	}, nil
 }
 
+func (ps *ProductService) SearchProduct(ctx context.Context, req ProductPaginationRequest) (ProductSearchResponse, error) {
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		logging.Log.Warn(constants.MESSAGE_FAILED_SEARCH_PRODUCT + ": min price above max price")
+		return ProductSearchResponse{}, constants.ErrInvalidPrice
+	}
+
+	list, err := ps.GetAllProductWithPagination(ctx, req)
+	if err != nil {
+		return ProductSearchResponse{}, err
+	}
+
+	facets, err := ps.productRepo.GetProductFacets(ctx, nil, req)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_SEARCH_PRODUCT)
+		return ProductSearchResponse{}, constants.ErrSearchProduct
+	}
+
+	return ProductSearchResponse{
+		Items:              list.Data,
+		Facets:             facets,
+		PaginationResponse: list.PaginationResponse,
+	}, nil
+}
+
+// ExportProducts hands every product matching the list filters to fn, one at
+// a time, without loading the whole catalogue. Errors returned by fn are
+// passed back unchanged.
+func (ps *ProductService) ExportProducts(ctx context.Context, req ProductPaginationRequest, fn func(product ProductResponse) error) error {
+	if req.MinPrice != nil && req.MaxPrice != nil && *req.MinPrice > *req.MaxPrice {
+		logging.Log.Warn(constants.MESSAGE_FAILED_EXPORT_PRODUCT + ": min price above max price")
+		return constants.ErrInvalidPrice
+	}
+
+	var writeErr error
+	count := 0
+	err := ps.productRepo.StreamProducts(ctx, nil, req, func(product Product) error {
+		writeErr = fn(ProductResponse{
+			ID:          product.ID,
+			Name:        product.Name,
+			Description: product.Description,
+			Merk:        product.Merk,
+			Material:    product.Material,
+			Price:       product.Price,
+			OwnerID:     product.OwnerID,
+			Version:     product.Version,
+			UpdatedAt:   product.UpdatedAt,
+		})
+		count++
+		return writeErr
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_EXPORT_PRODUCT)
+		return constants.ErrExportProduct
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_EXPORT_PRODUCT+": %d rows", count)
+	return nil
+}
+
+func (ps *ProductService) SuggestProduct(ctx context.Context, req SuggestProductRequest) ([]ProductSuggestion, error) {
+	q := strings.ToLower(strings.TrimSpace(req.Q))
+	if q == "" {
+		return []ProductSuggestion{}, nil
+	}
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = SUGGESTION_DEFAULT_LIMIT
+	}
+	if limit > SUGGESTION_MAX_LIMIT {
+		limit = SUGGESTION_MAX_LIMIT
+	}
+
+	suggestions, err := ps.suggestCache.Suggest(ctx, q, limit)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_SUGGEST_PRODUCT)
+		return nil, constants.ErrSuggestProduct
+	}
+
+	return suggestions, nil
+}
+
 func (ps *ProductService) GetProductByID(ctx context.Context, productID string) (ProductResponse, error) {
 	if _, err := uuid.Parse(productID); err != nil {
-		logging.Log.Warn(constants.MESSAGE_FAILED_GET_DETAIL_USER + ": invalid UUID")
+		logging.Log.Warn(constants.MESSAGE_FAILED_GET_DETAIL_PRODUCT + ": invalid UUID")
 		return ProductResponse{}, constants.ErrInvalidUUID
 	}
 
 	product, _, err := ps.productRepo.GetProductByID(ctx, nil, productID)
 	if err != nil {
-		logging.Log.WithError(err).WithField("id", productID).Error(constants.MESSAGE_FAILED_GET_DETAIL_USER)
-		return ProductResponse{}, constants.ErrGetUserByID
+		logging.Log.WithError(err).WithField("id", productID).Error(constants.MESSAGE_FAILED_GET_DETAIL_PRODUCT)
+		return ProductResponse{}, constants.ErrGetProductByID
 	}
 
-	logging.Log.Infof(constants.MESSAGE_SUCCESS_GET_DETAIL_USER+": %s", productID)
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_GET_DETAIL_PRODUCT+": %s", productID)
 
 	return ProductResponse{
 		ID:          product.ID,
@@ -150,26 +313,65 @@ func (ps *ProductService) GetProductByID(ctx context.Context, productID string)
 		Merk:        product.Merk,
 		Material:    product.Material,
 		Price:       product.Price,
+		OwnerID:     product.OwnerID,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
 	}, nil
 }
 
 func (ps *ProductService) UpdateProduct(ctx context.Context, req UpdateProductRequest) (ProductResponse, error) {
-	product, _, err := ps.productRepo.GetProductByID(ctx, nil, req.ID)
+	product, oldPrice, err := ps.updateProduct(ctx, nil, req)
+	if err != nil {
+		return ProductResponse{}, err
+	}
+
+	ps.suggestCache.Invalidate()
+	ps.notifyPriceChanged(ctx, product, oldPrice)
+
+	return ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
+		Material:    product.Material,
+		Merk:        product.Merk,
+		Price:       product.Price,
+		OwnerID:     product.OwnerID,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
+	}, nil
+}
+
+// updateProduct applies req within tx and returns the updated product with
+// its price before the update.
+func (ps *ProductService) updateProduct(ctx context.Context, tx *gorm.DB, req UpdateProductRequest) (Product, float32, error) {
+	product, _, err := ps.productRepo.GetProductByID(ctx, tx, req.ID)
 	if err != nil {
 		logging.Log.WithError(err).WithField("id", req.ID).Error(constants.MESSAGE_FAILED_UPDATE_PRODUCT)
-		return ProductResponse{}, constants.ErrGetProductByID
+		return Product{}, 0, constants.ErrGetProductByID
+	}
+
+	if err := policy.OwnerOrAdmin(ctx, product.OwnerID); err != nil {
+		logging.Log.WithError(err).WithField("id", req.ID).Warn(constants.MESSAGE_FAILED_UPDATE_PRODUCT)
+		return Product{}, 0, err
 	}
 
+	if req.Version != nil && *req.Version != product.Version {
+		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_PRODUCT + ": stale version")
+		return Product{}, 0, &helpers.VersionConflictError{CurrentVersion: product.Version}
+	}
+
+	oldPrice := product.Price
+
 	if req.Name != nil && len(*req.Name) < 5 {
 		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_PRODUCT + ": invalid name")
-		return ProductResponse{}, constants.ErrInvalidName
+		return Product{}, 0, constants.ErrInvalidName
 	} else if req.Name != nil {
 		product.Name = *req.Name
 	}
 
 	if req.Description != nil && len(*req.Description) < 5 {
 		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_PRODUCT + ": invalid name")
-		return ProductResponse{}, constants.ErrInvalidDescription
+		return Product{}, 0, constants.ErrInvalidDescription
 	} else if req.Description != nil {
 		product.Description = *req.Description
 	}
@@ -186,14 +388,52 @@ func (ps *ProductService) UpdateProduct(ctx context.Context, req UpdateProductRe
 		product.Price = *req.Price
 	}
 
-	err = ps.productRepo.UpdateProduct(ctx, nil, product)
+	product.UpdatedAt = time.Now()
+	updated, err := ps.productRepo.UpdateProduct(ctx, tx, product)
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UPDATE_PRODUCT)
-		return ProductResponse{}, constants.ErrUpdateProduct
+		return Product{}, 0, constants.ErrUpdateProduct
+	}
+
+	if !updated {
+		// Someone else updated the product between our read and write.
+		current, found, err := ps.productRepo.GetProductByID(ctx, tx, req.ID)
+		if err != nil || !found {
+			logging.Log.WithError(err).WithField("id", req.ID).Error(constants.MESSAGE_FAILED_UPDATE_PRODUCT)
+			return Product{}, 0, constants.ErrGetProductByID
+		}
+
+		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_PRODUCT + ": concurrent update")
+		return Product{}, 0, &helpers.VersionConflictError{CurrentVersion: current.Version}
 	}
+	product.Version++
 
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_UPDATE_PRODUCT+": %s", product.ID)
 
+	return product, oldPrice, nil
+}
+
+func (ps *ProductService) notifyPriceChanged(ctx context.Context, product Product, oldPrice float32) {
+	if product.Price == oldPrice {
+		return
+	}
+
+	for _, observer := range ps.priceObservers {
+		observer.OnPriceChanged(ctx, product, oldPrice)
+	}
+}
+
+func (ps *ProductService) DeleteProduct(ctx context.Context, req DeleteProductRequest) (ProductResponse, error) {
+	product, err := ps.deleteProduct(ctx, nil, req)
+	if err != nil {
+		return ProductResponse{}, err
+	}
+
+	ps.suggestCache.Invalidate()
+
+	// The product is only moved to the trash; it can be restored until purged.
+	deletedAt := time.Now()
+
 	return ProductResponse{
 		ID:          product.ID,
 		Name:        product.Name,
@@ -201,30 +441,161 @@ func (ps *ProductService) UpdateProduct(ctx context.Context, req UpdateProductRe
 		Material:    product.Material,
 		Merk:        product.Merk,
 		Price:       product.Price,
+		OwnerID:     product.OwnerID,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
+		DeletedAt:   &deletedAt,
 	}, nil
 }
 
-func (ps *ProductService) DeleteProduct(ctx context.Context, req DeleteProductRequest) (ProductResponse, error) {
-	product, _, err := ps.productRepo.GetProductByID(ctx, nil, req.ProductID)
+func (ps *ProductService) deleteProduct(ctx context.Context, tx *gorm.DB, req DeleteProductRequest) (Product, error) {
+	product, _, err := ps.productRepo.GetProductByID(ctx, tx, req.ProductID)
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_PRODUCT)
-		return ProductResponse{}, constants.ErrGetProductByID
+		return Product{}, constants.ErrGetProductByID
+	}
+
+	if err := policy.OwnerOrAdmin(ctx, product.OwnerID); err != nil {
+		logging.Log.WithError(err).WithField("id", req.ProductID).Warn(constants.MESSAGE_FAILED_DELETE_PRODUCT)
+		return Product{}, err
 	}
 
-	err = ps.productRepo.DeleteProduct(ctx, nil, req.ProductID)
+	err = ps.productRepo.DeleteProduct(ctx, tx, req.ProductID)
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_PRODUCT)
-		return ProductResponse{}, constants.ErrDeleteProduct
+		return Product{}, constants.ErrDeleteProduct
 	}
 
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_DELETE_USER+": %s", req.ProductID)
 
+	return product, nil
+}
+
+func (ps *ProductService) GetTrashedProducts(ctx context.Context, req ProductPaginationRequest) (ProductPaginationResponse, error) {
+	dataWithPaginate, err := ps.productRepo.GetTrashedProducts(ctx, nil, req)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_TRASHED_PRODUCT)
+		return ProductPaginationResponse{}, constants.ErrGetTrashedProduct
+	}
+
+	datas := make([]ProductResponse, 0, len(dataWithPaginate.Products))
+	for _, product := range dataWithPaginate.Products {
+		deletedAt := product.DeletedAt.Time
+		datas = append(datas, ProductResponse{
+			ID:          product.ID,
+			Name:        product.Name,
+			Description: product.Description,
+			Merk:        product.Merk,
+			Material:    product.Material,
+			Price:       product.Price,
+			OwnerID:     product.OwnerID,
+			Version:     product.Version,
+			UpdatedAt:   product.UpdatedAt,
+			DeletedAt:   &deletedAt,
+		})
+	}
+
+	return ProductPaginationResponse{
+		Data: datas,
+		PaginationResponse: PaginationResponse{
+			Page:    dataWithPaginate.Page,
+			PerPage: dataWithPaginate.PerPage,
+			MaxPage: dataWithPaginate.MaxPage,
+			Count:   dataWithPaginate.Count,
+		},
+	}, nil
+}
+
+func (ps *ProductService) RestoreProduct(ctx context.Context, req RestoreProductRequest) (ProductResponse, error) {
+	if _, err := uuid.Parse(req.ProductID); err != nil {
+		return ProductResponse{}, constants.ErrInvalidUUID
+	}
+
+	product, _, err := ps.productRepo.GetTrashedProductByID(ctx, nil, req.ProductID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("id", req.ProductID).Warn(constants.MESSAGE_FAILED_RESTORE_PRODUCT)
+		return ProductResponse{}, constants.ErrTrashedRecordNotFound
+	}
+
+	// Merk is only unique among active products, so another product may have
+	// taken it while this one was in the trash.
+	if existing, found, err := ps.productRepo.GetProductByMerk(ctx, nil, product.Merk); err == nil && found && existing.ID != product.ID {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_RESTORE_PRODUCT+": merk %s already in use", product.Merk)
+		return ProductResponse{}, constants.ErrRestoreMerkConflict
+	}
+
+	if err := ps.productRepo.RestoreProduct(ctx, nil, req.ProductID); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_RESTORE_PRODUCT)
+		return ProductResponse{}, constants.ErrRestoreProduct
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_RESTORE_PRODUCT+": %s", req.ProductID)
+	ps.suggestCache.Invalidate()
+
 	return ProductResponse{
 		ID:          product.ID,
 		Name:        product.Name,
 		Description: product.Description,
+		Merk:        product.Merk,
 		Material:    product.Material,
+		Price:       product.Price,
+		OwnerID:     product.OwnerID,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
+	}, nil
+}
+
+func (ps *ProductService) PurgeProduct(ctx context.Context, req PurgeProductRequest) (ProductResponse, error) {
+	if _, err := uuid.Parse(req.ProductID); err != nil {
+		return ProductResponse{}, constants.ErrInvalidUUID
+	}
+
+	product, _, err := ps.productRepo.GetTrashedProductByID(ctx, nil, req.ProductID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("id", req.ProductID).Warn(constants.MESSAGE_FAILED_PURGE_PRODUCT)
+		return ProductResponse{}, constants.ErrTrashedRecordNotFound
+	}
+
+	if err := ps.productRepo.PurgeProduct(ctx, nil, req.ProductID); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_PURGE_PRODUCT)
+		return ProductResponse{}, constants.ErrPurgeProduct
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_PURGE_PRODUCT+": %s", req.ProductID)
+
+	deletedAt := product.DeletedAt.Time
+	return ProductResponse{
+		ID:          product.ID,
+		Name:        product.Name,
+		Description: product.Description,
 		Merk:        product.Merk,
+		Material:    product.Material,
 		Price:       product.Price,
+		OwnerID:     product.OwnerID,
+		Version:     product.Version,
+		UpdatedAt:   product.UpdatedAt,
+		DeletedAt:   &deletedAt,
 	}, nil
 }
+
+func (ps *ProductService) PurgeDeletedProducts(ctx context.Context, before time.Time) (int64, error) {
+	count, err := ps.productRepo.PurgeProductsDeletedBefore(ctx, nil, before)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_PURGE_PRODUCT)
+		return 0, constants.ErrPurgeProduct
+	}
+
+	return count, nil
+}
+
+func searchHighlight(product Product) *ProductHighlight {
+	if product.NameHighlight == "" && product.DescriptionHighlight == "" {
+		return nil
+	}
+
+	return &ProductHighlight{
+		Name:        product.NameHighlight,
+		Description: product.DescriptionHighlight,
+		Rank:        product.SearchRank,
+	}
+}
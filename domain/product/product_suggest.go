@@ -0,0 +1,165 @@
+package product
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const (
+	SUGGESTION_TYPE_PRODUCT = "product"
+	SUGGESTION_TYPE_BRAND   = "brand"
+
+	SUGGESTION_DEFAULT_LIMIT = 10
+	SUGGESTION_MAX_LIMIT     = 20
+
+	// Similarity threshold for typo tolerant matches, see pg_trgm word_similarity.
+	suggestionMinSimilarity = 0.3
+	suggestionMaxCachedKeys = 1000
+)
+
+// SuggestCache keeps every product name and brand in memory so prefix
+// suggestions are answered without a round trip, and memoises the results of
+// fuzzy lookups that had to fall back to pg_trgm. Product writes invalidate it.
+type SuggestCache struct {
+	productRepo IProductRepository
+
+	mu      sync.RWMutex
+	terms   []ProductSuggestion
+	results map[string][]ProductSuggestion
+	loaded  bool
+}
+
+func NewSuggestCache(productRepo IProductRepository) *SuggestCache {
+	return &SuggestCache{
+		productRepo: productRepo,
+		results:     make(map[string][]ProductSuggestion),
+	}
+}
+
+// Refresh reloads the dictionary from the database and drops memoised results.
+func (sc *SuggestCache) Refresh(ctx context.Context) error {
+	terms, err := sc.productRepo.GetSuggestionTerms(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	sc.mu.Lock()
+	sc.terms = terms
+	sc.results = make(map[string][]ProductSuggestion)
+	sc.loaded = true
+	sc.mu.Unlock()
+
+	return nil
+}
+
+// Invalidate drops memoised results immediately and reloads the dictionary
+// in the background.
+func (sc *SuggestCache) Invalidate() {
+	sc.mu.Lock()
+	sc.results = make(map[string][]ProductSuggestion)
+	sc.mu.Unlock()
+
+	go func() {
+		if err := sc.Refresh(context.Background()); err != nil {
+			logging.Log.WithError(err).Warn("failed refresh suggestion cache")
+		}
+	}()
+}
+
+func (sc *SuggestCache) Suggest(ctx context.Context, q string, limit int) ([]ProductSuggestion, error) {
+	sc.mu.RLock()
+	loaded := sc.loaded
+	cached, ok := sc.results[q]
+	sc.mu.RUnlock()
+
+	if ok {
+		return truncateSuggestions(cached, limit), nil
+	}
+
+	if !loaded {
+		if err := sc.Refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	suggestions := sc.prefixMatches(q, SUGGESTION_MAX_LIMIT)
+
+	// Too few exact prefix hits usually means a typo; let pg_trgm find close matches.
+	if len(suggestions) < limit {
+		fuzzy, err := sc.productRepo.SuggestProduct(ctx, nil, q, suggestionMinSimilarity, SUGGESTION_MAX_LIMIT)
+		if err != nil {
+			return nil, err
+		}
+		suggestions = mergeSuggestions(suggestions, fuzzy)
+	}
+
+	sc.mu.Lock()
+	if len(sc.results) >= suggestionMaxCachedKeys {
+		sc.results = make(map[string][]ProductSuggestion)
+	}
+	sc.results[q] = suggestions
+	sc.mu.Unlock()
+
+	return truncateSuggestions(suggestions, limit), nil
+}
+
+func (sc *SuggestCache) prefixMatches(q string, limit int) []ProductSuggestion {
+	sc.mu.RLock()
+	defer sc.mu.RUnlock()
+
+	var matches []ProductSuggestion
+	for _, term := range sc.terms {
+		value := strings.ToLower(term.Value)
+
+		switch {
+		case strings.HasPrefix(value, q):
+			term.Score = 1
+		case strings.Contains(value, " "+q):
+			term.Score = 0.9
+		default:
+			continue
+		}
+
+		matches = append(matches, term)
+	}
+
+	sortSuggestions(matches)
+	return truncateSuggestions(matches, limit)
+}
+
+func mergeSuggestions(primary, secondary []ProductSuggestion) []ProductSuggestion {
+	seen := make(map[string]bool, len(primary))
+	for _, s := range primary {
+		seen[s.Type+"|"+s.Value] = true
+	}
+
+	for _, s := range secondary {
+		if !seen[s.Type+"|"+s.Value] {
+			seen[s.Type+"|"+s.Value] = true
+			primary = append(primary, s)
+		}
+	}
+
+	sortSuggestions(primary)
+	return primary
+}
+
+func sortSuggestions(suggestions []ProductSuggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Score != suggestions[j].Score {
+			return suggestions[i].Score > suggestions[j].Score
+		}
+		return suggestions[i].Value < suggestions[j].Value
+	})
+}
+
+func truncateSuggestions(suggestions []ProductSuggestion, limit int) []ProductSuggestion {
+	if len(suggestions) > limit {
+		return suggestions[:limit]
+	}
+	return suggestions
+}
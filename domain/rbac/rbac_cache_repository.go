@@ -0,0 +1,148 @@
+package rbac
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/cache"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	rbacCacheGenerationKey = "rbac:generation"
+	defaultRBACCacheTTL    = 5 * time.Minute
+)
+
+// CachedRBACRepository caches the permissions of each role, which every
+// permission check looks up. Any write to roles or their grants invalidates
+// all of them by moving to a new generation, like CachedProductRepository.
+type CachedRBACRepository struct {
+	IRBACRepository
+
+	cache   cache.Cache
+	ttl     time.Duration
+	group   singleflight.Group
+	metrics cache.Metrics
+}
+
+// NewCachedRBACRepository wraps repo with c. Entries live for RBAC_CACHE_TTL
+// (default 5m).
+func NewCachedRBACRepository(repo IRBACRepository, c cache.Cache) *CachedRBACRepository {
+	ttl := helpers.DurationEnv("RBAC_CACHE_TTL", defaultRBACCacheTTL)
+
+	return &CachedRBACRepository{
+		IRBACRepository: repo,
+		cache:           c,
+		ttl:             ttl,
+	}
+}
+
+func (cr *CachedRBACRepository) CacheStats() cache.Stats {
+	return cr.metrics.Stats()
+}
+
+func (cr *CachedRBACRepository) GetPermissionsByRoleName(ctx context.Context, tx *gorm.DB, name string) ([]string, error) {
+	if tx != nil {
+		return cr.IRBACRepository.GetPermissionsByRoleName(ctx, tx, name)
+	}
+
+	generation, err := cr.generation(ctx)
+	if err != nil {
+		cr.metrics.Error()
+		return cr.IRBACRepository.GetPermissionsByRoleName(ctx, nil, name)
+	}
+	key := "rbac:" + generation + ":role:" + name
+
+	data, found, err := cr.cache.Get(ctx, key)
+	if err != nil {
+		cr.metrics.Error()
+		return cr.IRBACRepository.GetPermissionsByRoleName(ctx, nil, name)
+	}
+
+	if found {
+		var names []string
+		if err := json.Unmarshal(data, &names); err == nil {
+			cr.metrics.Hit()
+			return names, nil
+		}
+		cr.metrics.Error()
+	}
+
+	cr.metrics.Miss()
+
+	shared, err, _ := cr.group.Do(key, func() (any, error) {
+		names, err := cr.IRBACRepository.GetPermissionsByRoleName(context.WithoutCancel(ctx), nil, name)
+		if err != nil {
+			return names, err
+		}
+
+		if data, err := json.Marshal(names); err != nil {
+			cr.metrics.Error()
+		} else if err := cr.cache.Set(ctx, key, data, cr.ttl); err != nil {
+			cr.metrics.Error()
+		}
+		return names, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return shared.([]string), nil
+}
+
+// RunInTransaction invalidates once more after the transaction ends, see
+// CachedProductRepository.RunInTransaction.
+func (cr *CachedRBACRepository) RunInTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	err := cr.IRBACRepository.RunInTransaction(ctx, fn)
+	cr.Invalidate(ctx)
+	return err
+}
+
+func (cr *CachedRBACRepository) DeleteRole(ctx context.Context, tx *gorm.DB, role Role) error {
+	if err := cr.IRBACRepository.DeleteRole(ctx, tx, role); err != nil {
+		return err
+	}
+
+	cr.Invalidate(ctx)
+	return nil
+}
+
+func (cr *CachedRBACRepository) SetRolePermissions(ctx context.Context, tx *gorm.DB, role Role, permissions []Permission) error {
+	if err := cr.IRBACRepository.SetRolePermissions(ctx, tx, role, permissions); err != nil {
+		return err
+	}
+
+	cr.Invalidate(ctx)
+	return nil
+}
+
+// Invalidate drops every cached role lookup by moving to a new generation.
+func (cr *CachedRBACRepository) Invalidate(ctx context.Context) {
+	if _, err := cr.bumpGeneration(ctx); err != nil {
+		cr.metrics.Error()
+		logging.Log.WithError(err).Warn("failed invalidate rbac cache")
+	}
+}
+
+func (cr *CachedRBACRepository) generation(ctx context.Context) (string, error) {
+	data, found, err := cr.cache.Get(ctx, rbacCacheGenerationKey)
+	if err != nil {
+		return "", err
+	}
+
+	if found {
+		return string(data), nil
+	}
+
+	return cr.bumpGeneration(ctx)
+}
+
+func (cr *CachedRBACRepository) bumpGeneration(ctx context.Context) (string, error) {
+	generation := strconv.FormatInt(time.Now().UnixNano(), 36)
+	return generation, cr.cache.Set(ctx, rbacCacheGenerationKey, []byte(generation), 0)
+}
@@ -0,0 +1,145 @@
+package rbac
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/utils"
+)
+
+type (
+	IRBACController interface {
+		GetRoles(ctx *gin.Context)
+		CreateRole(ctx *gin.Context)
+		UpdateRole(ctx *gin.Context)
+		DeleteRole(ctx *gin.Context)
+		GetPermissions(ctx *gin.Context)
+		AssignRole(ctx *gin.Context)
+	}
+
+	RBACController struct {
+		rbacService IRBACService
+	}
+)
+
+func NewRBACController(rbacService IRBACService) *RBACController {
+	return &RBACController{
+		rbacService: rbacService,
+	}
+}
+
+func (rc *RBACController) GetRoles(ctx *gin.Context) {
+	result, err := rc.rbacService.GetRoles(ctx.Request.Context())
+	if err != nil {
+		abortRBAC(ctx, constants.MESSAGE_FAILED_GET_ROLE, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_ROLE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (rc *RBACController) CreateRole(ctx *gin.Context) {
+	var payload CreateRoleRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := rc.rbacService.CreateRole(ctx.Request.Context(), payload)
+	if err != nil {
+		abortRBAC(ctx, constants.MESSAGE_FAILED_CREATE_ROLE, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CREATE_ROLE, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (rc *RBACController) UpdateRole(ctx *gin.Context) {
+	var payload UpdateRoleRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	payload.Name = ctx.Param("name")
+
+	result, err := rc.rbacService.UpdateRole(ctx.Request.Context(), payload)
+	if err != nil {
+		abortRBAC(ctx, constants.MESSAGE_FAILED_UPDATE_ROLE, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_UPDATE_ROLE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (rc *RBACController) DeleteRole(ctx *gin.Context) {
+	payload := DeleteRoleRequest{Name: ctx.Param("name")}
+
+	result, err := rc.rbacService.DeleteRole(ctx.Request.Context(), payload)
+	if err != nil {
+		abortRBAC(ctx, constants.MESSAGE_FAILED_DELETE_ROLE, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_DELETE_ROLE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (rc *RBACController) GetPermissions(ctx *gin.Context) {
+	result, err := rc.rbacService.GetPermissions(ctx.Request.Context())
+	if err != nil {
+		abortRBAC(ctx, constants.MESSAGE_FAILED_GET_PERMISSION, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_PERMISSION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (rc *RBACController) AssignRole(ctx *gin.Context) {
+	var payload AssignRoleRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	payload.UserID = ctx.Param("id")
+
+	result, err := rc.rbacService.AssignRole(ctx.Request.Context(), payload)
+	if err != nil {
+		abortRBAC(ctx, constants.MESSAGE_FAILED_ASSIGN_ROLE, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_ASSIGN_ROLE, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func abortRBAC(ctx *gin.Context, message string, err error) {
+	logging.Log.WithError(err).Warn(message)
+	res := utils.BuildResponseFailed(message, err.Error(), nil)
+
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, constants.ErrRoleNotFound), errors.Is(err, constants.ErrGetUserByID):
+		status = http.StatusNotFound
+	case errors.Is(err, constants.ErrRoleAlreadyExists), errors.Is(err, constants.ErrRoleInUse):
+		status = http.StatusConflict
+	case errors.Is(err, constants.ErrSystemRole), errors.Is(err, constants.ErrAdminPermissions), errors.Is(err, constants.ErrAssignOwnRole):
+		status = http.StatusForbidden
+	case errors.Is(err, constants.ErrGetRole), errors.Is(err, constants.ErrGetPermission), errors.Is(err, constants.ErrCreateRole),
+		errors.Is(err, constants.ErrUpdateRole), errors.Is(err, constants.ErrDeleteRole), errors.Is(err, constants.ErrAssignRole):
+		status = http.StatusInternalServerError
+	}
+	ctx.JSON(status, res)
+}
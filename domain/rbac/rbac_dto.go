@@ -0,0 +1,49 @@
+package rbac
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	CreateRoleRequest struct {
+		Name        string   `json:"name"`
+		Description string   `json:"description"`
+		Permissions []string `json:"permissions"`
+	}
+
+	UpdateRoleRequest struct {
+		Name        string    `json:"-"`
+		Description *string   `json:"description"`
+		Permissions *[]string `json:"permissions"`
+	}
+
+	DeleteRoleRequest struct {
+		Name string `json:"-"`
+	}
+
+	AssignRoleRequest struct {
+		UserID string `json:"-"`
+		Role   string `json:"role"`
+	}
+
+	RoleResponse struct {
+		ID          uuid.UUID `json:"id"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		System      bool      `json:"system"`
+		Permissions []string  `json:"permissions"`
+		UpdatedAt   time.Time `json:"updated_at"`
+	}
+
+	PermissionResponse struct {
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	AssignRoleResponse struct {
+		UserID string `json:"user_id"`
+		Role   string `json:"role"`
+	}
+)
@@ -0,0 +1,84 @@
+package rbac
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+const (
+	PERMISSION_PRODUCT_READ   = "product:read"
+	PERMISSION_PRODUCT_WRITE  = "product:write"
+	PERMISSION_PRODUCT_DELETE = "product:delete"
+	PERMISSION_PRODUCT_IMPORT = "product:import"
+	PERMISSION_PRODUCT_TRASH  = "product:trash"
+	PERMISSION_PRICE_SCHEDULE = "price:schedule"
+	PERMISSION_USER_READ      = "user:read"
+	PERMISSION_USER_WRITE     = "user:write"
+	PERMISSION_USER_TRASH     = "user:trash"
+	PERMISSION_AUDIT_READ     = "audit:read"
+	PERMISSION_METRICS_READ   = "metrics:read"
+	PERMISSION_ROLE_MANAGE    = "role:manage"
+
+	PERMISSION_SERVICE_ACCOUNT_MANAGE = "service_account:manage"
+)
+
+type Role struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string    `gorm:"not null;uniqueIndex" json:"name"`
+	Description string    `json:"description"`
+	// System roles are created by the migration and cannot be deleted.
+	System bool `gorm:"not null;default:false" json:"system"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type Permission struct {
+	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	Name        string    `gorm:"not null;uniqueIndex" json:"name"`
+	Description string    `json:"description"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type RolePermission struct {
+	RoleID       uuid.UUID `gorm:"type:uuid;primaryKey" json:"role_id"`
+	PermissionID uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"permission_id"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// DefaultPermission is a permission the migration makes sure exists. Roles
+// lists the system roles it is granted to when it is first created; after
+// that the grants are managed through the role API.
+type DefaultPermission struct {
+	Name        string
+	Description string
+	Roles       []string
+}
+
+// SystemRoles are the roles the migration makes sure exist.
+var SystemRoles = []Role{
+	{Name: constants.ENUM_ROLE_ADMIN, Description: "Full access", System: true},
+	{Name: constants.ENUM_ROLE_USER, Description: "Registered customer", System: true},
+}
+
+// DefaultPermissions keeps the access the hardcoded roles had: users may
+// manage products, everything else is for admins.
+var DefaultPermissions = []DefaultPermission{
+	{PERMISSION_PRODUCT_READ, "View products and their price history", []string{constants.ENUM_ROLE_ADMIN, constants.ENUM_ROLE_USER}},
+	{PERMISSION_PRODUCT_WRITE, "Create and update products", []string{constants.ENUM_ROLE_ADMIN, constants.ENUM_ROLE_USER}},
+	{PERMISSION_PRODUCT_DELETE, "Move products to the trash", []string{constants.ENUM_ROLE_ADMIN, constants.ENUM_ROLE_USER}},
+	{PERMISSION_PRODUCT_IMPORT, "Import products from files", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_PRODUCT_TRASH, "List, restore and purge trashed products", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_PRICE_SCHEDULE, "Schedule and cancel sale prices", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_USER_READ, "List and export users", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_USER_WRITE, "Create and unlock users and set their mfa policy", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_USER_TRASH, "List, restore and purge trashed users", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_AUDIT_READ, "Read the audit log", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_METRICS_READ, "Read cache metrics", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_ROLE_MANAGE, "Manage roles and assign them to users", []string{constants.ENUM_ROLE_ADMIN}},
+	{PERMISSION_SERVICE_ACCOUNT_MANAGE, "Create service accounts and manage their api keys", []string{constants.ENUM_ROLE_ADMIN}},
+}
@@ -0,0 +1,226 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/domain/audit"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"gorm.io/gorm"
+)
+
+type (
+	IRBACRepository interface {
+		RunInTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error
+		GetRoles(ctx context.Context, tx *gorm.DB) ([]Role, error)
+		GetRoleByName(ctx context.Context, tx *gorm.DB, name string) (Role, bool, error)
+		CreateRole(ctx context.Context, tx *gorm.DB, role Role) error
+		UpdateRole(ctx context.Context, tx *gorm.DB, role Role) error
+		DeleteRole(ctx context.Context, tx *gorm.DB, role Role) error
+		CountUsersWithRole(ctx context.Context, tx *gorm.DB, name string) (int64, error)
+		GetPermissions(ctx context.Context, tx *gorm.DB) ([]Permission, error)
+		GetPermissionsByNames(ctx context.Context, tx *gorm.DB, names []string) ([]Permission, error)
+		GetPermissionsByRoleName(ctx context.Context, tx *gorm.DB, name string) ([]string, error)
+		SetRolePermissions(ctx context.Context, tx *gorm.DB, role Role, permissions []Permission) error
+		AssignUserRole(ctx context.Context, tx *gorm.DB, userID, name string, now time.Time) (bool, error)
+	}
+
+	RBACRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewRBACRepository(db *gorm.DB) *RBACRepository {
+	return &RBACRepository{
+		db: db,
+	}
+}
+
+func (rr *RBACRepository) RunInTransaction(ctx context.Context, fn func(tx *gorm.DB) error) error {
+	return rr.db.WithContext(ctx).Transaction(fn)
+}
+
+func (rr *RBACRepository) GetRoles(ctx context.Context, tx *gorm.DB) ([]Role, error) {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	var roles []Role
+	if err := tx.WithContext(ctx).Order("name ASC").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+func (rr *RBACRepository) GetRoleByName(ctx context.Context, tx *gorm.DB, name string) (Role, bool, error) {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	var role Role
+	if err := tx.WithContext(ctx).Where("name = ?", name).Take(&role).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Role{}, false, nil
+		}
+		return Role{}, false, err
+	}
+
+	return role, true, nil
+}
+
+func (rr *RBACRepository) CreateRole(ctx context.Context, tx *gorm.DB, role Role) error {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	return tx.WithContext(ctx).Create(&role).Error
+}
+
+func (rr *RBACRepository) UpdateRole(ctx context.Context, tx *gorm.DB, role Role) error {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	return tx.WithContext(ctx).Model(&Role{}).
+		Where("id = ?", role.ID).
+		Updates(map[string]interface{}{
+			"description": role.Description,
+			"updated_at":  time.Now(),
+		}).Error
+}
+
+// DeleteRole deletes role together with its permission grants.
+func (rr *RBACRepository) DeleteRole(ctx context.Context, tx *gorm.DB, role Role) error {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", role.ID).Delete(&Role{}).Error
+	})
+}
+
+// CountUsersWithRole counts the users holding the role, trashed ones
+// included since they can be restored.
+func (rr *RBACRepository) CountUsersWithRole(ctx context.Context, tx *gorm.DB, name string) (int64, error) {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	var count int64
+	err := tx.WithContext(ctx).Unscoped().Model(&user.User{}).Where("role = ?", name).Count(&count).Error
+	return count, err
+}
+
+func (rr *RBACRepository) GetPermissions(ctx context.Context, tx *gorm.DB) ([]Permission, error) {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	var permissions []Permission
+	if err := tx.WithContext(ctx).Order("name ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func (rr *RBACRepository) GetPermissionsByNames(ctx context.Context, tx *gorm.DB, names []string) ([]Permission, error) {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	var permissions []Permission
+	if len(names) == 0 {
+		return permissions, nil
+	}
+
+	if err := tx.WithContext(ctx).Where("name IN ?", names).Order("name ASC").Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// GetPermissionsByRoleName returns the names of the permissions granted to
+// the role, empty for a role that does not exist.
+func (rr *RBACRepository) GetPermissionsByRoleName(ctx context.Context, tx *gorm.DB, name string) ([]string, error) {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	names := []string{}
+	err := tx.WithContext(ctx).Model(&Permission{}).
+		Joins("JOIN role_permissions ON role_permissions.permission_id = permissions.id").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Where("roles.name = ?", name).
+		Order("permissions.name ASC").
+		Pluck("permissions.name", &names).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return names, nil
+}
+
+// SetRolePermissions replaces the permissions granted to role.
+func (rr *RBACRepository) SetRolePermissions(ctx context.Context, tx *gorm.DB, role Role, permissions []Permission) error {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("role_id = ?", role.ID).Delete(&RolePermission{}).Error; err != nil {
+			return err
+		}
+
+		if len(permissions) == 0 {
+			return nil
+		}
+
+		grants := make([]RolePermission, 0, len(permissions))
+		for _, permission := range permissions {
+			grants = append(grants, RolePermission{RoleID: role.ID, PermissionID: permission.ID})
+		}
+
+		return tx.Create(&grants).Error
+	})
+}
+
+// AssignUserRole gives the user the role and revokes the user's sessions,
+// whose tokens carry the old role. It reports false when the user does not
+// exist.
+func (rr *RBACRepository) AssignUserRole(ctx context.Context, tx *gorm.DB, userID, name string, now time.Time) (bool, error) {
+	if tx == nil {
+		tx = rr.db
+	}
+
+	assigned := false
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := audit.WithAction(tx, audit.ACTION_ASSIGN_ROLE).Model(&user.User{}).
+			Where("id = ?", userID).
+			UpdateColumns(map[string]interface{}{
+				"role":                name,
+				"sessions_revoked_at": now.Truncate(time.Second),
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		assigned = true
+
+		return tx.Model(&user.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return assigned, nil
+}
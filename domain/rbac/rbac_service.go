@@ -0,0 +1,314 @@
+package rbac
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"gorm.io/gorm"
+)
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,50}$`)
+
+type (
+	// PermissionChecker is what the REST middleware and the GraphQL
+	// directive need to authorize a request.
+	PermissionChecker interface {
+		HasPermission(ctx context.Context, role, permission string) (bool, error)
+	}
+
+	IRBACService interface {
+		PermissionChecker
+		GetRoles(ctx context.Context) ([]RoleResponse, error)
+		GetPermissions(ctx context.Context) ([]PermissionResponse, error)
+		CreateRole(ctx context.Context, req CreateRoleRequest) (RoleResponse, error)
+		UpdateRole(ctx context.Context, req UpdateRoleRequest) (RoleResponse, error)
+		DeleteRole(ctx context.Context, req DeleteRoleRequest) (RoleResponse, error)
+		AssignRole(ctx context.Context, req AssignRoleRequest) (AssignRoleResponse, error)
+	}
+
+	RBACService struct {
+		rbacRepo IRBACRepository
+	}
+)
+
+func NewRBACService(rbacRepo IRBACRepository) *RBACService {
+	return &RBACService{
+		rbacRepo: rbacRepo,
+	}
+}
+
+// HasPermission reports whether role grants permission. Role names are
+// matched exactly, the way they are stored on users and in tokens.
+func (rs *RBACService) HasPermission(ctx context.Context, role, permission string) (bool, error) {
+	if role == "" {
+		return false, nil
+	}
+
+	permissions, err := rs.rbacRepo.GetPermissionsByRoleName(ctx, nil, role)
+	if err != nil {
+		logging.Log.WithError(err).WithField("role", role).Error(constants.MESSAGE_FAILED_GET_PERMISSION)
+		return false, constants.ErrGetPermissionsByRoleID
+	}
+
+	return slices.Contains(permissions, permission), nil
+}
+
+func (rs *RBACService) GetRoles(ctx context.Context) ([]RoleResponse, error) {
+	roles, err := rs.rbacRepo.GetRoles(ctx, nil)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_ROLE)
+		return nil, constants.ErrGetRole
+	}
+
+	datas := make([]RoleResponse, 0, len(roles))
+	for _, role := range roles {
+		data, err := rs.toRoleResponse(ctx, role)
+		if err != nil {
+			return nil, err
+		}
+		datas = append(datas, data)
+	}
+
+	return datas, nil
+}
+
+func (rs *RBACService) GetPermissions(ctx context.Context) ([]PermissionResponse, error) {
+	permissions, err := rs.rbacRepo.GetPermissions(ctx, nil)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_PERMISSION)
+		return nil, constants.ErrGetPermission
+	}
+
+	datas := make([]PermissionResponse, 0, len(permissions))
+	for _, permission := range permissions {
+		datas = append(datas, PermissionResponse{
+			Name:        permission.Name,
+			Description: permission.Description,
+		})
+	}
+
+	return datas, nil
+}
+
+func (rs *RBACService) CreateRole(ctx context.Context, req CreateRoleRequest) (RoleResponse, error) {
+	req.Name = strings.TrimSpace(req.Name)
+	if !roleNamePattern.MatchString(req.Name) {
+		return RoleResponse{}, constants.ErrInvalidRoleName
+	}
+
+	_, found, err := rs.rbacRepo.GetRoleByName(ctx, nil, req.Name)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_ROLE)
+		return RoleResponse{}, constants.ErrCreateRole
+	}
+	if found {
+		return RoleResponse{}, constants.ErrRoleAlreadyExists
+	}
+
+	permissions, err := rs.resolvePermissions(ctx, req.Permissions)
+	if err != nil {
+		return RoleResponse{}, err
+	}
+
+	role := Role{
+		ID:          uuid.New(),
+		Name:        req.Name,
+		Description: strings.TrimSpace(req.Description),
+	}
+
+	err = rs.rbacRepo.RunInTransaction(ctx, func(tx *gorm.DB) error {
+		if err := rs.rbacRepo.CreateRole(ctx, tx, role); err != nil {
+			return err
+		}
+		return rs.rbacRepo.SetRolePermissions(ctx, tx, role, permissions)
+	})
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CREATE_ROLE)
+		return RoleResponse{}, constants.ErrCreateRole
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_CREATE_ROLE+": %s", role.Name)
+
+	return rs.getRole(ctx, role.Name)
+}
+
+// UpdateRole changes the description and, when given, replaces the
+// permissions of a role. The admin role keeps every permission.
+func (rs *RBACService) UpdateRole(ctx context.Context, req UpdateRoleRequest) (RoleResponse, error) {
+	role, found, err := rs.rbacRepo.GetRoleByName(ctx, nil, req.Name)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UPDATE_ROLE)
+		return RoleResponse{}, constants.ErrUpdateRole
+	}
+	if !found {
+		return RoleResponse{}, constants.ErrRoleNotFound
+	}
+
+	if req.Permissions != nil && role.Name == constants.ENUM_ROLE_ADMIN {
+		return RoleResponse{}, constants.ErrAdminPermissions
+	}
+
+	var permissions []Permission
+	if req.Permissions != nil {
+		permissions, err = rs.resolvePermissions(ctx, *req.Permissions)
+		if err != nil {
+			return RoleResponse{}, err
+		}
+	}
+
+	if req.Description != nil {
+		role.Description = strings.TrimSpace(*req.Description)
+	}
+
+	err = rs.rbacRepo.RunInTransaction(ctx, func(tx *gorm.DB) error {
+		if err := rs.rbacRepo.UpdateRole(ctx, tx, role); err != nil {
+			return err
+		}
+		if req.Permissions == nil {
+			return nil
+		}
+		return rs.rbacRepo.SetRolePermissions(ctx, tx, role, permissions)
+	})
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UPDATE_ROLE)
+		return RoleResponse{}, constants.ErrUpdateRole
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_UPDATE_ROLE+": %s", role.Name)
+
+	return rs.getRole(ctx, role.Name)
+}
+
+// DeleteRole deletes a role nobody holds anymore. System roles are kept.
+func (rs *RBACService) DeleteRole(ctx context.Context, req DeleteRoleRequest) (RoleResponse, error) {
+	role, found, err := rs.rbacRepo.GetRoleByName(ctx, nil, req.Name)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_ROLE)
+		return RoleResponse{}, constants.ErrDeleteRole
+	}
+	if !found {
+		return RoleResponse{}, constants.ErrRoleNotFound
+	}
+
+	if role.System {
+		return RoleResponse{}, constants.ErrSystemRole
+	}
+
+	count, err := rs.rbacRepo.CountUsersWithRole(ctx, nil, role.Name)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_ROLE)
+		return RoleResponse{}, constants.ErrDeleteRole
+	}
+	if count > 0 {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_DELETE_ROLE+": %s is held by %d users", role.Name, count)
+		return RoleResponse{}, constants.ErrRoleInUse
+	}
+
+	res, err := rs.toRoleResponse(ctx, role)
+	if err != nil {
+		return RoleResponse{}, err
+	}
+
+	if err := rs.rbacRepo.DeleteRole(ctx, nil, role); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_ROLE)
+		return RoleResponse{}, constants.ErrDeleteRole
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_DELETE_ROLE+": %s", role.Name)
+
+	return res, nil
+}
+
+// AssignRole gives a user a role. The user's tokens are revoked, so the new
+// role applies from the next sign in. Admins cannot change their own role,
+// which keeps them from locking themselves out.
+func (rs *RBACService) AssignRole(ctx context.Context, req AssignRoleRequest) (AssignRoleResponse, error) {
+	if _, err := uuid.Parse(req.UserID); err != nil {
+		return AssignRoleResponse{}, constants.ErrInvalidUUID
+	}
+
+	if req.UserID == helpers.GetUserID(ctx) {
+		return AssignRoleResponse{}, constants.ErrAssignOwnRole
+	}
+
+	_, found, err := rs.rbacRepo.GetRoleByName(ctx, nil, req.Role)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_ASSIGN_ROLE)
+		return AssignRoleResponse{}, constants.ErrAssignRole
+	}
+	if !found {
+		return AssignRoleResponse{}, constants.ErrRoleNotFound
+	}
+
+	assigned, err := rs.rbacRepo.AssignUserRole(ctx, nil, req.UserID, req.Role, time.Now())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_ASSIGN_ROLE)
+		return AssignRoleResponse{}, constants.ErrAssignRole
+	}
+	if !assigned {
+		return AssignRoleResponse{}, constants.ErrGetUserByID
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_ASSIGN_ROLE+": %s is now %s", req.UserID, req.Role)
+
+	return AssignRoleResponse{
+		UserID: req.UserID,
+		Role:   req.Role,
+	}, nil
+}
+
+// resolvePermissions looks up the named permissions and fails on any name
+// that does not exist.
+func (rs *RBACService) resolvePermissions(ctx context.Context, names []string) ([]Permission, error) {
+	permissions, err := rs.rbacRepo.GetPermissionsByNames(ctx, nil, names)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_PERMISSION)
+		return nil, constants.ErrGetPermission
+	}
+
+	for _, name := range names {
+		known := slices.ContainsFunc(permissions, func(p Permission) bool {
+			return p.Name == name
+		})
+		if !known {
+			logging.Log.Warnf(constants.MESSAGE_FAILED_GET_PERMISSION+": unknown permission %s", name)
+			return nil, constants.ErrUnknownPermission
+		}
+	}
+
+	return permissions, nil
+}
+
+func (rs *RBACService) getRole(ctx context.Context, name string) (RoleResponse, error) {
+	role, found, err := rs.rbacRepo.GetRoleByName(ctx, nil, name)
+	if err != nil || !found {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_ROLE)
+		return RoleResponse{}, constants.ErrGetRole
+	}
+
+	return rs.toRoleResponse(ctx, role)
+}
+
+func (rs *RBACService) toRoleResponse(ctx context.Context, role Role) (RoleResponse, error) {
+	permissions, err := rs.rbacRepo.GetPermissionsByRoleName(ctx, nil, role.Name)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_ROLE)
+		return RoleResponse{}, constants.ErrGetRole
+	}
+
+	return RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		System:      role.System,
+		Permissions: permissions,
+		UpdatedAt:   role.UpdatedAt,
+	}, nil
+}
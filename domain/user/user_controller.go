@@ -1,25 +1,62 @@
 package user
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/export"
+	"github.com/mferdian/Go-GraphQL/helpers"
 	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/policy"
 	"github.com/mferdian/Go-GraphQL/utils"
 )
 
+var userExportColumns = []string{"id", "name", "email", "phone_number", "address", "version", "updated_at"}
+
+const (
+	oidcFlowCookie     = "oidc_flow"
+	oidcFlowCookiePath = "/api/auth/oidc"
+)
+
 type (
 	IUserController interface {
 		Register(ctx *gin.Context)
 		Login(ctx *gin.Context)
+		VerifyEmail(ctx *gin.Context)
+		ResendVerificationEmail(ctx *gin.Context)
+		ForgotPassword(ctx *gin.Context)
+		ResetPassword(ctx *gin.Context)
+		VerifyMFA(ctx *gin.Context)
+		StartMFAEnrollment(ctx *gin.Context)
+		ConfirmMFAEnrollment(ctx *gin.Context)
+		StartOIDCLogin(ctx *gin.Context)
+		OIDCCallback(ctx *gin.Context)
+
+		EnrollTOTP(ctx *gin.Context)
+		ConfirmTOTP(ctx *gin.Context)
+		DisableTOTP(ctx *gin.Context)
+
+		GetSessions(ctx *gin.Context)
+		RevokeSession(ctx *gin.Context)
+		RevokeOtherSessions(ctx *gin.Context)
 
 		CreateUser(ctx *gin.Context)
 		GetAllUser(ctx *gin.Context)
+		ExportUsers(ctx *gin.Context)
 		GetUserByID(ctx *gin.Context)
 		UpdateUser(ctx *gin.Context)
 		DeleteUser(ctx *gin.Context)
+
+		GetTrashedUsers(ctx *gin.Context)
+		RestoreUser(ctx *gin.Context)
+		PurgeUser(ctx *gin.Context)
+
+		UnlockUser(ctx *gin.Context)
+		GetMFAPolicies(ctx *gin.Context)
+		SetMFAPolicy(ctx *gin.Context)
 	}
 
 	UserController struct {
@@ -68,15 +105,392 @@ func (uc *UserController) Login(ctx *gin.Context) {
 	if err != nil {
 		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_LOGIN_USER)
 		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_LOGIN_USER, err.Error(), nil)
-		ctx.JSON(http.StatusUnauthorized, res)
+
+		var throttled *helpers.TooManyRequestsError
+		if errors.As(err, &throttled) {
+			ctx.Header("Retry-After", helpers.RetryAfterSeconds(throttled.RetryAfter))
+			ctx.JSON(http.StatusTooManyRequests, res)
+			return
+		}
+
+		switch {
+		case errors.Is(err, constants.ErrEmailNotVerified):
+			ctx.JSON(http.StatusForbidden, res)
+		case errors.Is(err, constants.ErrGetMFAPolicy), errors.Is(err, constants.ErrCreateSession):
+			ctx.JSON(http.StatusInternalServerError, res)
+		default:
+			ctx.JSON(http.StatusUnauthorized, res)
+		}
+		return
+	}
+
+	message := constants.MESSAGE_SUCCESS_LOGIN_USER
+	switch {
+	case result.MFARequired:
+		message = constants.MESSAGE_SUCCESS_MFA_REQUIRED
+	case result.MFAEnrollmentRequired:
+		message = constants.MESSAGE_SUCCESS_MFA_ENROLLMENT_REQUIRED
+	default:
+		logging.Log.Infof(constants.MESSAGE_SUCCESS_LOGIN_USER+": %s", payload.Email)
+	}
+
+	res := utils.BuildResponseSuccess(message, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// VerifyMFA is the second step of a login that answered mfa_required.
+func (uc *UserController) VerifyMFA(ctx *gin.Context) {
+	var payload MFALoginRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := uc.userService.VerifyMFA(ctx.Request.Context(), payload)
+	if err != nil {
+		abortMFA(ctx, constants.MESSAGE_FAILED_VERIFY_MFA, err)
 		return
 	}
 
-	logging.Log.Infof(constants.MESSAGE_SUCCESS_LOGIN_USER+": %s", payload.Email)
 	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_LOGIN_USER, result)
 	ctx.JSON(http.StatusOK, res)
 }
 
+// StartMFAEnrollment is the second step of a login that answered
+// mfa_enrollment_required.
+func (uc *UserController) StartMFAEnrollment(ctx *gin.Context) {
+	var payload MFAEnrollmentRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := uc.userService.StartMFAEnrollment(ctx.Request.Context(), payload)
+	if err != nil {
+		abortMFA(ctx, constants.MESSAGE_FAILED_ENROLL_MFA, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_ENROLL_MFA, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) ConfirmMFAEnrollment(ctx *gin.Context) {
+	var payload MFALoginRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := uc.userService.ConfirmMFAEnrollment(ctx.Request.Context(), payload)
+	if err != nil {
+		abortMFA(ctx, constants.MESSAGE_FAILED_CONFIRM_MFA, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CONFIRM_MFA, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) EnrollTOTP(ctx *gin.Context) {
+	result, err := uc.userService.EnrollTOTP(ctx.Request.Context(), ctx.GetString("id"))
+	if err != nil {
+		abortMFA(ctx, constants.MESSAGE_FAILED_ENROLL_MFA, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_ENROLL_MFA, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) ConfirmTOTP(ctx *gin.Context) {
+	var payload TOTPCodeRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := uc.userService.ConfirmTOTP(ctx.Request.Context(), ctx.GetString("id"), payload)
+	if err != nil {
+		abortMFA(ctx, constants.MESSAGE_FAILED_CONFIRM_MFA, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_CONFIRM_MFA, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) DisableTOTP(ctx *gin.Context) {
+	var payload TOTPCodeRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := uc.userService.DisableTOTP(ctx.Request.Context(), ctx.GetString("id"), payload); err != nil {
+		abortMFA(ctx, constants.MESSAGE_FAILED_DISABLE_MFA, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_DISABLE_MFA, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) GetSessions(ctx *gin.Context) {
+	result, err := uc.userService.GetSessions(ctx.Request.Context(), ctx.GetString("id"))
+	if err != nil {
+		abortSession(ctx, constants.MESSAGE_FAILED_GET_SESSION, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_SESSION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) RevokeSession(ctx *gin.Context) {
+	payload := RevokeSessionRequest{
+		UserID:    ctx.GetString("id"),
+		SessionID: ctx.Param("session_id"),
+	}
+
+	if err := uc.userService.RevokeSession(ctx.Request.Context(), payload); err != nil {
+		abortSession(ctx, constants.MESSAGE_FAILED_REVOKE_SESSION, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_REVOKE_SESSION, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) RevokeOtherSessions(ctx *gin.Context) {
+	result, err := uc.userService.RevokeOtherSessions(ctx.Request.Context(), ctx.GetString("id"))
+	if err != nil {
+		abortSession(ctx, constants.MESSAGE_FAILED_REVOKE_SESSION, err)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_REVOKE_SESSION, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func abortSession(ctx *gin.Context, message string, err error) {
+	logging.Log.WithError(err).Warn(message)
+	res := utils.BuildResponseFailed(message, err.Error(), nil)
+
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, constants.ErrInvalidUUID):
+		status = http.StatusBadRequest
+	case errors.Is(err, constants.ErrGetIDFromToken):
+		status = http.StatusUnauthorized
+	case errors.Is(err, constants.ErrSessionNotFound):
+		status = http.StatusNotFound
+	}
+	ctx.JSON(status, res)
+}
+
+// abortMFA answers a failed MFA request with the status matching err.
+func abortMFA(ctx *gin.Context, message string, err error) {
+	res := utils.BuildResponseFailed(message, err.Error(), nil)
+
+	var throttled *helpers.TooManyRequestsError
+	if errors.As(err, &throttled) {
+		ctx.Header("Retry-After", helpers.RetryAfterSeconds(throttled.RetryAfter))
+		ctx.JSON(http.StatusTooManyRequests, res)
+		return
+	}
+
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, constants.ErrMFATokenInvalid), errors.Is(err, constants.ErrInvalidMFACode):
+		status = http.StatusUnauthorized
+	case errors.Is(err, constants.ErrMFARequiredForRole):
+		status = http.StatusForbidden
+	case errors.Is(err, constants.ErrGetUserByID):
+		status = http.StatusNotFound
+	case errors.Is(err, constants.ErrMFAAlreadyEnabled), errors.Is(err, constants.ErrMFANotEnabled), errors.Is(err, constants.ErrMFANotEnrolling):
+		status = http.StatusConflict
+	case errors.Is(err, constants.ErrEnrollMFA), errors.Is(err, constants.ErrDisableMFA), errors.Is(err, constants.ErrGetMFAPolicy), errors.Is(err, constants.ErrGenerateAccessToken),
+		errors.Is(err, constants.ErrCreateSession):
+		status = http.StatusInternalServerError
+	}
+	ctx.JSON(status, res)
+}
+
+// StartOIDCLogin redirects to the identity provider. The flow is kept in an
+// HttpOnly cookie scoped to the callback, which the provider redirects back
+// to on the same site, so SameSite=Lax still sends it.
+func (uc *UserController) StartOIDCLogin(ctx *gin.Context) {
+	result, err := uc.userService.StartOIDCLogin(ctx.Request.Context(), ctx.Param("provider"))
+	if err != nil {
+		abortOIDC(ctx, err)
+		return
+	}
+
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcFlowCookie, result.Flow, int(OIDCFlowTTL.Seconds()), oidcFlowCookiePath, "", ctx.Request.TLS != nil, true)
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Redirect(http.StatusFound, result.AuthURL)
+}
+
+func (uc *UserController) OIDCCallback(ctx *gin.Context) {
+	var payload OIDCCallbackRequest
+	if err := ctx.ShouldBindQuery(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	payload.Provider = ctx.Param("provider")
+	payload.Flow, _ = ctx.Cookie(oidcFlowCookie)
+
+	// Each flow is good for one callback.
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcFlowCookie, "", -1, oidcFlowCookiePath, "", ctx.Request.TLS != nil, true)
+
+	result, err := uc.userService.FinishOIDCLogin(ctx.Request.Context(), payload)
+	if err != nil {
+		abortOIDC(ctx, err)
+		return
+	}
+
+	message := constants.MESSAGE_SUCCESS_LOGIN_USER
+	switch {
+	case result.MFARequired:
+		message = constants.MESSAGE_SUCCESS_MFA_REQUIRED
+	case result.MFAEnrollmentRequired:
+		message = constants.MESSAGE_SUCCESS_MFA_ENROLLMENT_REQUIRED
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	res := utils.BuildResponseSuccess(message, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func abortOIDC(ctx *gin.Context, err error) {
+	logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_OIDC_LOGIN)
+	res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_OIDC_LOGIN, err.Error(), nil)
+
+	var throttled *helpers.TooManyRequestsError
+	if errors.As(err, &throttled) {
+		ctx.Header("Retry-After", helpers.RetryAfterSeconds(throttled.RetryAfter))
+		ctx.JSON(http.StatusTooManyRequests, res)
+		return
+	}
+
+	status := http.StatusUnauthorized
+	switch {
+	case errors.Is(err, constants.ErrOIDCProviderNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, constants.ErrOIDCStateInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, constants.ErrOIDCEmailNotVerified), errors.Is(err, constants.ErrOIDCAccountNotVerified):
+		status = http.StatusForbidden
+	case errors.Is(err, constants.ErrOIDCLogin), errors.Is(err, constants.ErrGetMFAPolicy), errors.Is(err, constants.ErrCreateSession),
+		errors.Is(err, constants.ErrGenerateAccessToken):
+		status = http.StatusInternalServerError
+	}
+	ctx.JSON(status, res)
+}
+
+func (uc *UserController) VerifyEmail(ctx *gin.Context) {
+	var payload VerifyEmailRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := uc.userService.VerifyEmail(ctx.Request.Context(), payload)
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_VERIFY_EMAIL, err.Error(), nil)
+
+		status := http.StatusBadRequest
+		switch {
+		case errors.Is(err, constants.ErrEmailAlreadyVerified):
+			status = http.StatusConflict
+		case errors.Is(err, constants.ErrVerifyEmail):
+			status = http.StatusInternalServerError
+		}
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_VERIFY_EMAIL, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) ResendVerificationEmail(ctx *gin.Context) {
+	var payload ResendVerificationRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := uc.userService.ResendVerificationEmail(ctx.Request.Context(), payload); err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_RESEND_VERIFICATION, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_RESEND_VERIFICATION, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+// ForgotPassword answers 200 for any well-formed request so it cannot be
+// used to find out which emails have an account.
+func (uc *UserController) ForgotPassword(ctx *gin.Context) {
+	var payload ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	uc.userService.ForgotPassword(ctx.Request.Context(), payload)
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_FORGOT_PASSWORD, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) ResetPassword(ctx *gin.Context) {
+	var payload ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	if err := uc.userService.ResetPassword(ctx.Request.Context(), payload); err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_RESET_PASSWORD, err.Error(), nil)
+
+		status := http.StatusBadRequest
+		if errors.Is(err, constants.ErrResetPassword) || errors.Is(err, constants.ErrHashPassword) {
+			status = http.StatusInternalServerError
+		}
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_RESET_PASSWORD, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
 func (uc *UserController) CreateUser(ctx *gin.Context) {
 	var payload CreateUserRequest
 	if err := ctx.ShouldBindJSON(&payload); err != nil {
@@ -147,16 +561,6 @@ func (uc *UserController) GetAllUser(ctx *gin.Context) {
 func (uc *UserController) GetUserByID(ctx *gin.Context) {
 	idStr := ctx.Param("id")
 
-	userID := ctx.GetString("id")
-	role := ctx.GetString("role")
-
-	if role == constants.ENUM_ROLE_USER && userID != idStr {
-		logging.Log.Warn("unauthorized access: user trying to access another user's data")
-		res := utils.BuildResponseFailed("unauthorized", "you can only get your own account", nil)
-		ctx.AbortWithStatusJSON(http.StatusForbidden, res)
-		return
-	}
-
 	if _, err := uuid.Parse(idStr); err != nil {
 		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_UUID_FORMAT)
 		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UUID_FORMAT, err.Error(), nil)
@@ -168,7 +572,7 @@ func (uc *UserController) GetUserByID(ctx *gin.Context) {
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_DETAIL_USER)
 		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DETAIL_USER, err.Error(), nil)
-		ctx.AbortWithStatusJSON(http.StatusNotFound, res)
+		ctx.AbortWithStatusJSON(policy.StatusCode(err, http.StatusNotFound), res)
 		return
 	}
 
@@ -186,16 +590,6 @@ func (uc *UserController) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
-	userID := ctx.GetString("id")
-	role := ctx.GetString("role")
-
-	if role == constants.ENUM_ROLE_USER && userID != idParam {
-		logging.Log.Warn("unauthorized update attempt by user")
-		res := utils.BuildResponseFailed("unauthorized", "you can only update your own account", nil)
-		ctx.JSON(http.StatusForbidden, res)
-		return
-	}
-
 	var payload UpdateUserRequest
 	payload.ID = idParam
 
@@ -206,11 +600,32 @@ func (uc *UserController) UpdateUser(ctx *gin.Context) {
 		return
 	}
 
+	if payload.Version == nil {
+		if version, ok := helpers.ParseIfMatch(ctx.GetHeader("If-Match")); ok {
+			payload.Version = &version
+		}
+	}
+
+	if payload.Version == nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_USER + ": missing version")
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UPDATE_USER, constants.ErrVersionRequired.Error(), nil)
+		ctx.JSON(http.StatusPreconditionRequired, res)
+		return
+	}
+
 	result, err := uc.userService.UpdateUser(ctx.Request.Context(), payload)
 	if err != nil {
+		var conflict *helpers.VersionConflictError
+		if errors.As(err, &conflict) {
+			logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_UPDATE_USER)
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UPDATE_USER, err.Error(), conflict)
+			ctx.JSON(http.StatusConflict, res)
+			return
+		}
+
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UPDATE_USER)
 		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UPDATE_USER, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(policy.StatusCode(err, http.StatusBadRequest), res)
 		return
 	}
 
@@ -228,23 +643,13 @@ func (uc *UserController) DeleteUser(ctx *gin.Context) {
 		return
 	}
 
-	userID := ctx.GetString("id")
-	role := ctx.GetString("role")
-
-	if role == constants.ENUM_ROLE_USER && userID != idParam {
-		logging.Log.Warn("unauthorized delete attempt by user")
-		res := utils.BuildResponseFailed("unauthorized", "you can only delete your own account", nil)
-		ctx.JSON(http.StatusForbidden, res)
-		return
-	}
-
 	payload := DeleteUserRequest{UserID: idParam}
 
 	result, err := uc.userService.DeleteUser(ctx.Request.Context(), payload)
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_USER)
 		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_DELETE_USER, err.Error(), nil)
-		ctx.JSON(http.StatusBadRequest, res)
+		ctx.JSON(policy.StatusCode(err, http.StatusBadRequest), res)
 		return
 	}
 
@@ -252,3 +657,161 @@ func (uc *UserController) DeleteUser(ctx *gin.Context) {
 	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_DELETE_USER, result)
 	ctx.JSON(http.StatusOK, res)
 }
+
+func (uc *UserController) GetTrashedUsers(ctx *gin.Context) {
+	var query UserPaginationRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := uc.userService.GetTrashedUsers(ctx.Request.Context(), query)
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_TRASHED_USER, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.Response{
+		Status:   true,
+		Messsage: constants.MESSAGE_SUCCESS_GET_TRASHED_USER,
+		Data:     result.Data,
+		Meta:     result.PaginationResponse,
+	}
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) RestoreUser(ctx *gin.Context) {
+	payload := RestoreUserRequest{UserID: ctx.Param("id")}
+
+	result, err := uc.userService.RestoreUser(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_RESTORE_USER)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_RESTORE_USER, err.Error(), nil)
+		ctx.JSON(trashErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_RESTORE_USER, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) PurgeUser(ctx *gin.Context) {
+	payload := PurgeUserRequest{UserID: ctx.Param("id")}
+
+	result, err := uc.userService.PurgeUser(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_PURGE_USER)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_PURGE_USER, err.Error(), nil)
+		ctx.JSON(trashErrorStatus(err), res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_PURGE_USER, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) UnlockUser(ctx *gin.Context) {
+	payload := UnlockUserRequest{UserID: ctx.Param("id")}
+
+	result, err := uc.userService.UnlockUser(ctx.Request.Context(), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_UNLOCK_USER)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UNLOCK_USER, err.Error(), nil)
+
+		status := http.StatusBadRequest
+		if errors.Is(err, constants.ErrGetUserByID) {
+			status = http.StatusNotFound
+		}
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_UNLOCK_USER, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) GetMFAPolicies(ctx *gin.Context) {
+	result, err := uc.userService.GetMFAPolicies(ctx.Request.Context())
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_MFA_POLICY, err.Error(), nil)
+		ctx.JSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_MFA_POLICY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (uc *UserController) SetMFAPolicy(ctx *gin.Context) {
+	var payload MFAPolicyRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	payload.Role = ctx.Param("role")
+
+	result, err := uc.userService.SetMFAPolicy(ctx.Request.Context(), payload)
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_UPDATE_MFA_POLICY, err.Error(), nil)
+
+		status := http.StatusBadRequest
+		if errors.Is(err, constants.ErrUpdateMFAPolicy) {
+			status = http.StatusInternalServerError
+		}
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_UPDATE_MFA_POLICY, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func trashErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, constants.ErrTrashedRecordNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, constants.ErrRestoreEmailConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ExportUsers streams the users matching the list filters as CSV, XLSX or
+// NDJSON, chosen by ?format= or the Accept header.
+func (uc *UserController) ExportUsers(ctx *gin.Context) {
+	var query UserPaginationRequest
+	if err := ctx.ShouldBindQuery(&query); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	format, err := export.Negotiate(ctx.Query("format"), ctx.GetHeader("Accept"))
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_EXPORT_USER, err.Error(), nil)
+		ctx.JSON(http.StatusNotAcceptable, res)
+		return
+	}
+
+	started, err := export.Stream(ctx, format, "users", userExportColumns, func(write func(values ...any) error) error {
+		return uc.userService.ExportUsers(ctx.Request.Context(), query, func(user UserResponse) error {
+			return write(user.ID, user.Name, user.Email, user.PhoneNumber, user.Address, user.Version, user.UpdatedAt)
+		})
+	})
+	if err != nil && !started {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_EXPORT_USER, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+	if err != nil {
+		// The status line is gone already; the client gets a truncated file.
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_EXPORT_USER)
+	}
+}
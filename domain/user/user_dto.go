@@ -1,6 +1,10 @@
 package user
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type (
 	UserResponse struct {
@@ -9,6 +13,13 @@ type (
 		Email       string    `json:"email"`
 		PhoneNumber string    `json:"phone_number"`
 		Address     string    `json:"address"`
+		Version     int64     `json:"version"`
+		UpdatedAt   time.Time `json:"updated_at"`
+
+		DeletedAt *time.Time `json:"deleted_at,omitempty"`
+
+		// LockedUntil is only filled in for admins while the account is locked.
+		LockedUntil *time.Time `json:"locked_until,omitempty"`
 	}
 
 	RegisterUserRequest struct {
@@ -28,9 +39,88 @@ type (
 		Password string `json:"password"`
 	}
 
+	// LoginResponse carries the token pair once the user is fully signed in.
+	// When a second factor is needed instead, MFARequired or
+	// MFAEnrollmentRequired is set and MFAToken must be exchanged for the
+	// tokens, see VerifyMFA and ConfirmMFAEnrollment.
 	LoginResponse struct {
-		AccessToken  string `json:"access_token"`
-		RefreshToken string `json:"refresh_token"`
+		AccessToken  string `json:"access_token,omitempty"`
+		RefreshToken string `json:"refresh_token,omitempty"`
+
+		MFARequired           bool   `json:"mfa_required,omitempty"`
+		MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+		MFAToken              string `json:"mfa_token,omitempty"`
+
+		// RecoveryCodes is only set when MFA was enrolled during login.
+		RecoveryCodes []string `json:"recovery_codes,omitempty"`
+	}
+
+	MFALoginRequest struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	MFAEnrollmentRequest struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+
+	TOTPEnrollmentResponse struct {
+		Secret     string `json:"secret"`
+		OTPAuthURL string `json:"otpauth_url"`
+		// QRCode is the otpauth URL as a PNG data URI.
+		QRCode string `json:"qr_code,omitempty"`
+	}
+
+	TOTPCodeRequest struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	RecoveryCodesResponse struct {
+		RecoveryCodes []string `json:"recovery_codes"`
+	}
+
+	SessionResponse struct {
+		ID         uuid.UUID `json:"id"`
+		UserAgent  string    `json:"user_agent"`
+		IP         string    `json:"ip"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastSeenAt time.Time `json:"last_seen_at"`
+		// Current marks the session the request was made with.
+		Current bool `json:"current"`
+	}
+
+	RevokeSessionRequest struct {
+		UserID    string `json:"-"`
+		SessionID string `json:"-"`
+	}
+
+	RevokeSessionsResponse struct {
+		Revoked int64 `json:"revoked"`
+	}
+
+	OIDCLoginResponse struct {
+		AuthURL string `json:"auth_url"`
+		// Flow is kept by the client, e.g. in a cookie, until the callback.
+		Flow string `json:"-"`
+	}
+
+	OIDCCallbackRequest struct {
+		Provider string `json:"-"`
+		Code     string `form:"code"`
+		State    string `form:"state"`
+		Error    string `form:"error"`
+		Flow     string `json:"-"`
+	}
+
+	MFAPolicyRequest struct {
+		Role     string `json:"-"`
+		Required *bool  `json:"required" binding:"required"`
+	}
+
+	MFAPolicyResponse struct {
+		Role      string    `json:"role"`
+		Required  bool      `json:"required"`
+		UpdatedAt time.Time `json:"updated_at"`
 	}
 
 	CreateUserRequest struct {
@@ -48,12 +138,42 @@ type (
 		Password    *string `json:"password,omitempty"`
 		PhoneNumber *string `json:"phone_number,omitempty"`
 		Address     *string `json:"address,omitempty"`
+		Version     *int64  `json:"version,omitempty"`
 	}
 
 	DeleteUserRequest struct {
 		UserID string `json:"-"`
 	}
 
+	RestoreUserRequest struct {
+		UserID string `json:"-"`
+	}
+
+	PurgeUserRequest struct {
+		UserID string `json:"-"`
+	}
+
+	UnlockUserRequest struct {
+		UserID string `json:"-"`
+	}
+
+	VerifyEmailRequest struct {
+		Token string `json:"token" binding:"required"`
+	}
+
+	ResendVerificationRequest struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	ForgotPasswordRequest struct {
+		Email string `json:"email" binding:"required"`
+	}
+
+	ResetPasswordRequest struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required"`
+	}
+
 	UserPaginationRequest struct {
 		PaginationRequest
 		UserID string `form:"id"`
@@ -0,0 +1,87 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+// recordFailedLogin counts a wrong password for user and locks the account
+// once the lockout threshold is reached. It returns the error to report for
+// the attempt: the lockout itself when this attempt caused it, otherwise the
+// usual invalid credential error.
+func (us *UserService) recordFailedLogin(ctx context.Context, user User) error {
+	updated, err := us.userRepo.IncrementFailedLogins(ctx, nil, user.ID.String())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed count login attempt")
+		return constants.ErrInvalidLoginCredential
+	}
+
+	if updated.FailedLoginAttempts < us.lockout.Threshold {
+		return constants.ErrInvalidLoginCredential
+	}
+
+	lockFor := us.lockout.LockFor(updated.LockoutCount)
+	locked, err := us.userRepo.LockUser(ctx, nil, user.ID.String(), us.lockout.Threshold, time.Now().Add(lockFor))
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed lock account")
+		return constants.ErrInvalidLoginCredential
+	}
+	if !locked {
+		// A concurrent attempt locked the account first.
+		return constants.ErrInvalidLoginCredential
+	}
+
+	logging.Log.Warnf(constants.MESSAGE_FAILED_LOGIN_USER+": %s locked for %s after %d failed attempts", user.Email, lockFor, updated.FailedLoginAttempts)
+
+	return &helpers.TooManyRequestsError{RetryAfter: lockFor, Err: constants.ErrAccountLocked}
+}
+
+// UnlockUser lifts a lockout before it expires and resets the backoff, so
+// the next lockout starts from the base duration again.
+func (us *UserService) UnlockUser(ctx context.Context, req UnlockUserRequest) (UserResponse, error) {
+	if _, err := uuid.Parse(req.UserID); err != nil {
+		return UserResponse{}, constants.ErrInvalidUUID
+	}
+
+	found, err := us.userRepo.UnlockUser(ctx, nil, req.UserID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UNLOCK_USER)
+		return UserResponse{}, constants.ErrUnlockUser
+	}
+	if !found {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_UNLOCK_USER+": %s not found", req.UserID)
+		return UserResponse{}, constants.ErrGetUserByID
+	}
+
+	user, _, err := us.userRepo.GetUserByID(ctx, nil, req.UserID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("id", req.UserID).Error(constants.MESSAGE_FAILED_UNLOCK_USER)
+		return UserResponse{}, constants.ErrGetUserByID
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_UNLOCK_USER+": %s", req.UserID)
+
+	return UserResponse{
+		ID:          user.ID,
+		Name:        user.Name,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
+	}, nil
+}
+
+// activeLock returns when the lockout of user ends, or nil when it is not
+// locked.
+func activeLock(user User) *time.Time {
+	if user.LockedUntil == nil || !user.LockedUntil.After(time.Now()) {
+		return nil
+	}
+	return user.LockedUntil
+}
@@ -0,0 +1,58 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
+)
+
+func TestRecordFailedLoginLocksWithBackoff(t *testing.T) {
+	db := newTestDB(t)
+	us := &UserService{
+		userRepo: NewUserRepository(db),
+		lockout:  ratelimit.AccountLockout{Threshold: 2, Duration: time.Minute, MaxDuration: 3 * time.Minute},
+	}
+	ctx := context.Background()
+	user := createTestUser(t, db, "alice@example.com")
+
+	wantLocks := []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute, 3 * time.Minute}
+	for i, want := range wantLocks {
+		if err := us.recordFailedLogin(ctx, user); !errors.Is(err, constants.ErrInvalidLoginCredential) {
+			t.Fatalf("lockout %d: first failure returned %v, want invalid credential", i+1, err)
+		}
+
+		err := us.recordFailedLogin(ctx, user)
+		var locked *helpers.TooManyRequestsError
+		if !errors.As(err, &locked) || !errors.Is(err, constants.ErrAccountLocked) {
+			t.Fatalf("lockout %d: second failure returned %v, want account locked", i+1, err)
+		}
+		if locked.RetryAfter != want {
+			t.Errorf("lockout %d: locked for %s, want %s", i+1, locked.RetryAfter, want)
+		}
+	}
+}
+
+func TestActiveLock(t *testing.T) {
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name        string
+		lockedUntil *time.Time
+		wantLocked  bool
+	}{
+		{"never locked", nil, false},
+		{"lock expired", &past, false},
+		{"locked", &future, true},
+	}
+
+	for _, tt := range tests {
+		if got := activeLock(User{LockedUntil: tt.lockedUntil}); (got != nil) != tt.wantLocked {
+			t.Errorf("%s: activeLock = %v, want locked %t", tt.name, got, tt.wantLocked)
+		}
+	}
+}
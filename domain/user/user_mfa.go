@@ -0,0 +1,468 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"image/png"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/totp"
+)
+
+const (
+	TOKEN_PURPOSE_MFA_LOGIN = "mfa_login"
+
+	// What an MFA token lets the holder do: enter a code, or set up TOTP
+	// first because their role requires it.
+	mfaModeVerify = "verify"
+	mfaModeEnroll = "enroll"
+
+	defaultMFAIssuer       = "Go-GraphQL"
+	defaultMFAChallengeTTL = 5 * time.Minute
+
+	recoveryCodeCount = 10
+	recoveryCodeBytes = 10
+	qrCodeSize        = 256
+)
+
+var mfaRoles = []string{constants.ENUM_ROLE_ADMIN, constants.ENUM_ROLE_USER}
+
+type mfaConfig struct {
+	secret       []byte
+	issuer       string
+	challengeTTL time.Duration
+}
+
+// newMFAConfig encrypts TOTP secrets and signs MFA tokens with
+// MFA_ENCRYPTION_KEY, falling back to JWT_SECRET. Without either, users who
+// need a second factor cannot sign in rather than being let through on a
+// forgeable token. MFA tokens are valid for MFA_CHALLENGE_TTL (default 5m);
+// authenticator apps show accounts under MFA_ISSUER.
+func newMFAConfig() mfaConfig {
+	secret := helpers.SecretEnv("MFA_ENCRYPTION_KEY", "JWT_SECRET")
+	if len(secret) == 0 {
+		logging.Log.Error("neither MFA_ENCRYPTION_KEY nor JWT_SECRET is set, two-factor authentication is disabled")
+	}
+
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = defaultMFAIssuer
+	}
+
+	ttl := helpers.DurationEnv("MFA_CHALLENGE_TTL", defaultMFAChallengeTTL)
+
+	return mfaConfig{secret: secret, issuer: issuer, challengeTTL: ttl}
+}
+
+// mfaChallenge decides whether user, whose password was just checked, needs
+// a second factor. If so it returns the response carrying the MFA token
+// instead of the token pair, and true.
+func (us *UserService) mfaChallenge(ctx context.Context, user User) (LoginResponse, bool, error) {
+	mode := mfaModeVerify
+	if user.TOTPEnabledAt == nil {
+		required, err := us.userRepo.IsMFARequired(ctx, nil, user.Role)
+		if err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed get mfa policy")
+			return LoginResponse{}, true, constants.ErrGetMFAPolicy
+		}
+		if !required {
+			return LoginResponse{}, false, nil
+		}
+		mode = mfaModeEnroll
+	}
+
+	token, err := helpers.SignToken(us.mfa.secret, helpers.SignedToken{
+		Purpose:   TOKEN_PURPOSE_MFA_LOGIN,
+		Subject:   user.ID.String(),
+		Binding:   mode,
+		ExpiresAt: time.Now().Add(us.mfa.challengeTTL).Unix(),
+	})
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed sign mfa token")
+		return LoginResponse{}, true, constants.ErrGenerateAccessToken
+	}
+
+	logging.Log.Infof("mfa %s challenge for %s", mode, user.Email)
+
+	return LoginResponse{
+		MFARequired:           mode == mfaModeVerify,
+		MFAEnrollmentRequired: mode == mfaModeEnroll,
+		MFAToken:              token,
+	}, true, nil
+}
+
+// VerifyMFA exchanges an MFA token and a TOTP or recovery code for the token
+// pair. Wrong codes count as failed logins for the throttle.
+func (us *UserService) VerifyMFA(ctx context.Context, req MFALoginRequest) (LoginResponse, error) {
+	user, err := us.mfaChallengeUser(ctx, req.MFAToken, mfaModeVerify)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	ip := helpers.GetClientIP(ctx)
+	if retryAfter, blocked := us.loginThrottle.Check(user.Email, ip); blocked {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_VERIFY_MFA+": throttled %s from %s", user.Email, ip)
+		return LoginResponse{}, &helpers.TooManyRequestsError{RetryAfter: retryAfter, Err: constants.ErrTooManyLoginAttempts}
+	}
+
+	if err := us.checkSecondFactor(ctx, user, req.Code); err != nil {
+		us.loginThrottle.Fail(user.Email, ip)
+		return LoginResponse{}, err
+	}
+
+	return us.issueTokens(ctx, user)
+}
+
+// StartMFAEnrollment starts TOTP enrollment for a user whose role requires
+// MFA but who has not set it up, with the MFA token Login returned.
+func (us *UserService) StartMFAEnrollment(ctx context.Context, req MFAEnrollmentRequest) (TOTPEnrollmentResponse, error) {
+	user, err := us.mfaChallengeUser(ctx, req.MFAToken, mfaModeEnroll)
+	if err != nil {
+		return TOTPEnrollmentResponse{}, err
+	}
+
+	return us.enrollTOTP(ctx, user)
+}
+
+// ConfirmMFAEnrollment completes enrollment started by StartMFAEnrollment
+// and signs the user in. The response includes the recovery codes.
+func (us *UserService) ConfirmMFAEnrollment(ctx context.Context, req MFALoginRequest) (LoginResponse, error) {
+	user, err := us.mfaChallengeUser(ctx, req.MFAToken, mfaModeEnroll)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	codes, err := us.confirmTOTP(ctx, user, req.Code)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	res, err := us.issueTokens(ctx, user)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+	res.RecoveryCodes = codes
+
+	return res, nil
+}
+
+// EnrollTOTP generates a new secret for the user. It only takes effect once
+// confirmed with a code from the app, see ConfirmTOTP.
+func (us *UserService) EnrollTOTP(ctx context.Context, userID string) (TOTPEnrollmentResponse, error) {
+	user, found, err := us.userRepo.GetUserByID(ctx, nil, userID)
+	if err != nil || !found {
+		logging.Log.WithError(err).WithField("id", userID).Warn(constants.MESSAGE_FAILED_ENROLL_MFA)
+		return TOTPEnrollmentResponse{}, constants.ErrGetUserByID
+	}
+
+	return us.enrollTOTP(ctx, user)
+}
+
+// ConfirmTOTP enables TOTP once the user proves their app has the secret,
+// and returns a fresh set of recovery codes. They are shown only this once.
+func (us *UserService) ConfirmTOTP(ctx context.Context, userID string, req TOTPCodeRequest) (RecoveryCodesResponse, error) {
+	user, found, err := us.userRepo.GetUserByID(ctx, nil, userID)
+	if err != nil || !found {
+		logging.Log.WithError(err).WithField("id", userID).Warn(constants.MESSAGE_FAILED_CONFIRM_MFA)
+		return RecoveryCodesResponse{}, constants.ErrGetUserByID
+	}
+
+	codes, err := us.confirmTOTP(ctx, user, req.Code)
+	if err != nil {
+		return RecoveryCodesResponse{}, err
+	}
+
+	return RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns TOTP off after checking a current code or a recovery
+// code. It is refused while the user's role requires MFA.
+func (us *UserService) DisableTOTP(ctx context.Context, userID string, req TOTPCodeRequest) error {
+	user, found, err := us.userRepo.GetUserByID(ctx, nil, userID)
+	if err != nil || !found {
+		logging.Log.WithError(err).WithField("id", userID).Warn(constants.MESSAGE_FAILED_DISABLE_MFA)
+		return constants.ErrGetUserByID
+	}
+
+	if user.TOTPEnabledAt == nil {
+		return constants.ErrMFANotEnabled
+	}
+
+	required, err := us.userRepo.IsMFARequired(ctx, nil, user.Role)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DISABLE_MFA)
+		return constants.ErrGetMFAPolicy
+	}
+	if required {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_DISABLE_MFA+": required for role %s", user.Role)
+		return constants.ErrMFARequiredForRole
+	}
+
+	ip := helpers.GetClientIP(ctx)
+	if retryAfter, blocked := us.loginThrottle.Check(user.Email, ip); blocked {
+		return &helpers.TooManyRequestsError{RetryAfter: retryAfter, Err: constants.ErrTooManyLoginAttempts}
+	}
+	if err := us.checkSecondFactor(ctx, user, req.Code); err != nil {
+		us.loginThrottle.Fail(user.Email, ip)
+		return err
+	}
+
+	if err := us.userRepo.DisableTOTP(ctx, nil, userID); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DISABLE_MFA)
+		return constants.ErrDisableMFA
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_DISABLE_MFA+": %s", user.Email)
+
+	return nil
+}
+
+// GetMFAPolicies lists every role with whether it requires MFA.
+func (us *UserService) GetMFAPolicies(ctx context.Context) ([]MFAPolicyResponse, error) {
+	policies, err := us.userRepo.GetMFAPolicies(ctx, nil)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_MFA_POLICY)
+		return nil, constants.ErrGetMFAPolicy
+	}
+
+	byRole := make(map[string]MFAPolicy, len(policies))
+	for _, policy := range policies {
+		byRole[policy.Role] = policy
+	}
+
+	res := make([]MFAPolicyResponse, 0, len(mfaRoles))
+	for _, role := range mfaRoles {
+		policy := byRole[role]
+		res = append(res, MFAPolicyResponse{
+			Role:      role,
+			Required:  policy.Required,
+			UpdatedAt: policy.UpdatedAt,
+		})
+	}
+
+	return res, nil
+}
+
+// SetMFAPolicy sets whether a role requires MFA. Users of the role without
+// TOTP are asked to enroll at their next login; existing tokens stay valid.
+func (us *UserService) SetMFAPolicy(ctx context.Context, req MFAPolicyRequest) (MFAPolicyResponse, error) {
+	valid := false
+	for _, role := range mfaRoles {
+		valid = valid || role == req.Role
+	}
+	if !valid {
+		return MFAPolicyResponse{}, constants.ErrInvalidRole
+	}
+
+	policy := MFAPolicy{
+		Role:      req.Role,
+		Required:  *req.Required,
+		UpdatedAt: time.Now(),
+	}
+	if err := us.userRepo.SetMFAPolicy(ctx, nil, policy); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UPDATE_MFA_POLICY)
+		return MFAPolicyResponse{}, constants.ErrUpdateMFAPolicy
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_UPDATE_MFA_POLICY+": %s required=%t", policy.Role, policy.Required)
+
+	return MFAPolicyResponse{
+		Role:      policy.Role,
+		Required:  policy.Required,
+		UpdatedAt: policy.UpdatedAt,
+	}, nil
+}
+
+// mfaChallengeUser returns the user an MFA token of the given mode was
+// issued to.
+func (us *UserService) mfaChallengeUser(ctx context.Context, mfaToken, mode string) (User, error) {
+	token, err := helpers.VerifySignedToken(us.mfa.secret, TOKEN_PURPOSE_MFA_LOGIN, mfaToken)
+	if err != nil || token.Binding != mode {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_VERIFY_MFA + ": invalid mfa token")
+		return User{}, constants.ErrMFATokenInvalid
+	}
+
+	user, found, err := us.userRepo.GetUserByID(ctx, nil, token.Subject)
+	if err != nil || !found {
+		logging.Log.WithError(err).WithField("id", token.Subject).Warn(constants.MESSAGE_FAILED_VERIFY_MFA)
+		return User{}, constants.ErrMFATokenInvalid
+	}
+
+	if lockedUntil := activeLock(user); lockedUntil != nil {
+		return User{}, &helpers.TooManyRequestsError{RetryAfter: time.Until(*lockedUntil), Err: constants.ErrAccountLocked}
+	}
+
+	if mode == mfaModeVerify && user.TOTPEnabledAt == nil {
+		return User{}, constants.ErrMFANotEnabled
+	}
+
+	return user, nil
+}
+
+func (us *UserService) enrollTOTP(ctx context.Context, user User) (TOTPEnrollmentResponse, error) {
+	if user.TOTPEnabledAt != nil {
+		return TOTPEnrollmentResponse{}, constants.ErrMFAAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_ENROLL_MFA)
+		return TOTPEnrollmentResponse{}, constants.ErrEnrollMFA
+	}
+
+	encrypted, err := helpers.Encrypt(us.mfa.secret, secret)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_ENROLL_MFA)
+		return TOTPEnrollmentResponse{}, constants.ErrEnrollMFA
+	}
+
+	if err := us.userRepo.SetPendingTOTPSecret(ctx, nil, user.ID.String(), encrypted); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_ENROLL_MFA)
+		return TOTPEnrollmentResponse{}, constants.ErrEnrollMFA
+	}
+
+	uri := totp.URI(us.mfa.issuer, user.Email, secret)
+	res := TOTPEnrollmentResponse{Secret: secret, OTPAuthURL: uri}
+
+	// The QR code is a convenience; the secret can be typed in instead.
+	if code, err := qrCodePNG(uri); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_ENROLL_MFA + ": no qr code")
+	} else {
+		res.QRCode = "data:image/png;base64," + base64.StdEncoding.EncodeToString(code)
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_ENROLL_MFA+": %s", user.Email)
+
+	return res, nil
+}
+
+// qrCodePNG renders text as a QR code image of at least qrCodeSize pixels.
+func qrCodePNG(text string) ([]byte, error) {
+	code, err := qrcode.NewQRCodeWriter().Encode(text, gozxing.BarcodeFormat_QR_CODE, qrCodeSize, qrCodeSize, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, code); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (us *UserService) confirmTOTP(ctx context.Context, user User, code string) ([]string, error) {
+	if user.TOTPEnabledAt != nil {
+		return nil, constants.ErrMFAAlreadyEnabled
+	}
+	if user.PendingTOTPSecret == "" {
+		return nil, constants.ErrMFANotEnrolling
+	}
+
+	secret, err := helpers.Decrypt(us.mfa.secret, user.PendingTOTPSecret)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CONFIRM_MFA)
+		return nil, constants.ErrEnrollMFA
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_CONFIRM_MFA+": wrong code for %s", user.Email)
+		return nil, constants.ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	codes := make([]string, recoveryCodeCount)
+	records := make([]RecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		codes[i], err = generateRecoveryCode()
+		if err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CONFIRM_MFA)
+			return nil, constants.ErrEnrollMFA
+		}
+		records[i] = RecoveryCode{
+			ID:        uuid.New(),
+			UserID:    user.ID,
+			CodeHash:  hashRecoveryCode(codes[i]),
+			CreatedAt: now,
+		}
+	}
+
+	enabled, err := us.userRepo.EnableTOTP(ctx, nil, user.ID.String(), user.PendingTOTPSecret, step, now, records)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_CONFIRM_MFA)
+		return nil, constants.ErrEnrollMFA
+	}
+	if !enabled {
+		// Enrollment was restarted or finished by another request.
+		return nil, constants.ErrMFANotEnrolling
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_CONFIRM_MFA+": %s", user.Email)
+
+	return codes, nil
+}
+
+// checkSecondFactor accepts a TOTP code newer than the last one used, or an
+// unused recovery code, which is used up.
+func (us *UserService) checkSecondFactor(ctx context.Context, user User, code string) error {
+	secret, err := helpers.Decrypt(us.mfa.secret, user.TOTPSecret)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_VERIFY_MFA)
+		return constants.ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	var accepted bool
+	if step, ok := totp.Validate(secret, code, now); ok {
+		accepted, err = us.userRepo.UseTOTPStep(ctx, nil, user.ID.String(), step)
+	} else {
+		accepted, err = us.userRepo.UseRecoveryCode(ctx, nil, user.ID.String(), hashRecoveryCode(code), now)
+		if accepted {
+			logging.Log.Warnf("recovery code used by %s", user.Email)
+		}
+	}
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_VERIFY_MFA)
+		return constants.ErrInvalidMFACode
+	}
+	if !accepted {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_VERIFY_MFA+": wrong or reused code for %s", user.Email)
+		return constants.ErrInvalidMFACode
+	}
+
+	return nil
+}
+
+// generateRecoveryCode returns 80 random bits as four groups of four base32
+// characters, e.g. "abcd-efgh-ijkl-mnop".
+func generateRecoveryCode() (string, error) {
+	raw := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+
+	encoded := strings.ToLower(base32.StdEncoding.EncodeToString(raw))
+	return encoded[0:4] + "-" + encoded[4:8] + "-" + encoded[8:12] + "-" + encoded[12:16], nil
+}
+
+// hashRecoveryCode ignores case, dashes and spaces, so codes can be typed
+// back however they were written down.
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
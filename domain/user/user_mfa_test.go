@@ -0,0 +1,76 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/qrcode"
+	"github.com/mferdian/Go-GraphQL/totp"
+)
+
+func TestMFAChallengeWithoutSecretFailsClosed(t *testing.T) {
+	now := time.Now()
+	us := &UserService{mfa: mfaConfig{challengeTTL: time.Minute}}
+
+	res, required, err := us.mfaChallenge(context.Background(), User{Email: "alice@example.com", TOTPEnabledAt: &now})
+	if err == nil || !required || res.MFAToken != "" {
+		t.Errorf("mfaChallenge without secret = %+v, %t, %v; want an error and no token", res, required, err)
+	}
+}
+
+func TestMFATokenBoundToSecret(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice@example.com")
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	db.Model(&user).Update("totp_enabled_at", now)
+
+	signer := &UserService{userRepo: NewUserRepository(db), mfa: mfaConfig{secret: []byte("secret"), challengeTTL: time.Minute}}
+	res, required, err := signer.mfaChallenge(context.Background(), user)
+	if err != nil || !required || res.MFAToken == "" {
+		t.Fatalf("mfaChallenge = %+v, %t, %v; want an MFA token", res, required, err)
+	}
+
+	if _, err := signer.mfaChallengeUser(context.Background(), res.MFAToken, mfaModeVerify); err != nil {
+		t.Errorf("token rejected by its signer: %v", err)
+	}
+
+	for _, secret := range [][]byte{nil, []byte("other")} {
+		verifier := &UserService{userRepo: signer.userRepo, mfa: mfaConfig{secret: secret}}
+		if _, err := verifier.mfaChallengeUser(context.Background(), res.MFAToken, mfaModeVerify); err == nil {
+			t.Errorf("token accepted under secret %q", secret)
+		}
+	}
+}
+
+func TestQRCodePNGRoundTrip(t *testing.T) {
+	uri := totp.URI("Go-GraphQL", "alice@example.com", "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+
+	code, err := qrCodePNG(uri)
+	if err != nil {
+		t.Fatalf("qrCodePNG: %v", err)
+	}
+	img, err := png.Decode(bytes.NewReader(code))
+	if err != nil {
+		t.Fatalf("decode png: %v", err)
+	}
+	if img.Bounds().Dx() < qrCodeSize {
+		t.Errorf("image width %d, want at least %d", img.Bounds().Dx(), qrCodeSize)
+	}
+
+	bmp, err := gozxing.NewBinaryBitmapFromImage(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := qrcode.NewQRCodeReader().Decode(bmp, nil)
+	if err != nil {
+		t.Fatalf("decode qr code: %v", err)
+	}
+	if result.GetText() != uri {
+		t.Errorf("decoded %q, want %q", result.GetText(), uri)
+	}
+}
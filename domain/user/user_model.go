@@ -11,15 +11,48 @@ import (
 type User struct {
 	ID          uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
 	Name        string    `json:"name"`
-	Email       string    `gorm:"unique; not null" json:"email"`
+	Email       string    `gorm:"not null;uniqueIndex:idx_users_email_active,where:deleted_at IS NULL" json:"email"`
 	Password    string    `json:"password"`
 	PhoneNumber string    `json:"phone_number"`
 	Address     string    `json:"address"`
 	Role        string    `json:"role"`
 
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// ServiceAccount users stand for an integration rather than a person.
+	// They cannot sign in and only authenticate with API keys.
+	ServiceAccount bool `gorm:"not null;default:false" json:"service_account"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `json:"deleted_at"`
+
+	// Version is the revision the client must send back, see UpdateUser.
+	Version int64 `gorm:"not null;default:1" json:"version"`
+
+	// FailedLoginAttempts counts wrong passwords since the last successful
+	// login or lockout. LockoutCount counts lockouts since the last successful
+	// login; each one doubles the next lockout, see ratelimit.AccountLockout.
+	FailedLoginAttempts int        `gorm:"not null;default:0" json:"-"`
+	LockoutCount        int        `gorm:"not null;default:0" json:"-"`
+	LockedUntil         *time.Time `json:"locked_until"`
+
+	// SessionsRevokedAt invalidates every token issued before it. Kept at
+	// whole seconds, the precision of a token's issued-at claim.
+	SessionsRevokedAt *time.Time `json:"-"`
+
+	// TOTPSecret is set once two-factor authentication is confirmed;
+	// PendingTOTPSecret holds a secret being enrolled until then. Both are
+	// encrypted, see helpers.Encrypt. TOTPLastStep is the time step of the
+	// last code accepted, so a code cannot be used twice.
+	TOTPSecret        string     `json:"-"`
+	PendingTOTPSecret string     `json:"-"`
+	TOTPEnabledAt     *time.Time `json:"totp_enabled_at"`
+	TOTPLastStep      int64      `gorm:"not null;default:0" json:"-"`
+
+	CreatedBy *uuid.UUID `gorm:"type:uuid" json:"created_by"`
+	UpdatedBy *uuid.UUID `gorm:"type:uuid" json:"updated_by"`
+	DeletedBy *uuid.UUID `gorm:"type:uuid" json:"deleted_by"`
 }
 
 func (u *User) BeforeCreate(tx *gorm.DB) error {
@@ -37,3 +70,59 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 
 	return nil
 }
+
+// PasswordResetToken is a single-use token for resetting a forgotten
+// password. Only its SHA-256 is stored, so the table cannot be used to reset
+// accounts if it leaks.
+type PasswordResetToken struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	TokenHash string     `gorm:"not null;uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// Session is one sign in, e.g. on one device. Tokens carry its ID and stop
+// working as soon as it is revoked, see TokensRevoked. It ends with the
+// refresh token issued with it.
+type Session struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// RecoveryCode is a single-use code that stands in for a TOTP code when the
+// authenticator is lost. Only its SHA-256 is stored.
+type RecoveryCode struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MFAPolicy says whether users with Role must use two-factor
+// authentication. Roles without a row do not.
+type MFAPolicy struct {
+	Role      string    `gorm:"primaryKey" json:"role"`
+	Required  bool      `gorm:"not null;default:false" json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// ExternalIdentity links a user to an account at an identity provider they
+// sign in with, see oidc.Provider. Subject is the provider's stable ID for
+// the account; Email is what the provider reported when it was linked.
+type ExternalIdentity struct {
+	ID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Provider  string    `gorm:"not null;uniqueIndex:idx_external_identities_subject" json:"provider"`
+	Subject   string    `gorm:"not null;uniqueIndex:idx_external_identities_subject" json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,253 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/oidc"
+)
+
+const (
+	// A sign in with an identity provider must be finished within
+	// OIDCFlowTTL of starting it.
+	OIDCFlowTTL = 10 * time.Minute
+
+	oidcPasswordBytes = 32
+)
+
+type (
+	oidcConfig struct {
+		providers oidc.Providers
+		secret    []byte
+	}
+
+	// oidcFlow is what the callback needs to finish a sign in. It is
+	// encrypted and kept by the client, so the verifier stays secret and
+	// nothing is stored between the redirects.
+	oidcFlow struct {
+		Provider  string `json:"p"`
+		State     string `json:"s"`
+		Nonce     string `json:"n"`
+		Verifier  string `json:"v"`
+		ExpiresAt int64  `json:"e"`
+	}
+)
+
+// newOIDCConfig encrypts sign in flows with OIDC_STATE_SECRET, falling back
+// to JWT_SECRET. Without either, signing in with a provider fails instead of
+// trusting a flow anyone could forge.
+func newOIDCConfig(providers oidc.Providers) oidcConfig {
+	secret := helpers.SecretEnv("OIDC_STATE_SECRET", "JWT_SECRET")
+	if len(secret) == 0 && len(providers) > 0 {
+		logging.Log.Error("neither OIDC_STATE_SECRET nor JWT_SECRET is set, signing in with an identity provider is disabled")
+	}
+
+	return oidcConfig{providers: providers, secret: secret}
+}
+
+// StartOIDCLogin returns where to send the user to sign in with provider,
+// and the flow to hand back to FinishOIDCLogin with the callback.
+func (us *UserService) StartOIDCLogin(ctx context.Context, provider string) (OIDCLoginResponse, error) {
+	p, ok := us.oidc.providers[provider]
+	if !ok {
+		return OIDCLoginResponse{}, constants.ErrOIDCProviderNotFound
+	}
+
+	flow := oidcFlow{
+		Provider:  provider,
+		ExpiresAt: time.Now().Add(OIDCFlowTTL).Unix(),
+	}
+	for _, value := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		token, err := oidc.RandomToken()
+		if err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_OIDC_LOGIN)
+			return OIDCLoginResponse{}, constants.ErrOIDCLogin
+		}
+		*value = token
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, flow.State, flow.Nonce, oidc.S256Challenge(flow.Verifier))
+	if err != nil {
+		logging.Log.WithError(err).Errorf(constants.MESSAGE_FAILED_OIDC_LOGIN+": %s", provider)
+		return OIDCLoginResponse{}, constants.ErrOIDCLogin
+	}
+
+	payload, err := json.Marshal(flow)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_OIDC_LOGIN)
+		return OIDCLoginResponse{}, constants.ErrOIDCLogin
+	}
+
+	sealed, err := helpers.Encrypt(us.oidc.secret, string(payload))
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_OIDC_LOGIN)
+		return OIDCLoginResponse{}, constants.ErrOIDCLogin
+	}
+
+	return OIDCLoginResponse{AuthURL: authURL, Flow: sealed}, nil
+}
+
+// FinishOIDCLogin checks the callback against the flow it started with and
+// signs in whoever the provider vouches for, see loginWithIdentity.
+func (us *UserService) FinishOIDCLogin(ctx context.Context, req OIDCCallbackRequest) (LoginResponse, error) {
+	p, ok := us.oidc.providers[req.Provider]
+	if !ok {
+		return LoginResponse{}, constants.ErrOIDCProviderNotFound
+	}
+
+	payload, err := helpers.Decrypt(us.oidc.secret, req.Flow)
+	if err != nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_OIDC_LOGIN + ": invalid flow")
+		return LoginResponse{}, constants.ErrOIDCStateInvalid
+	}
+
+	var flow oidcFlow
+	if err := json.Unmarshal([]byte(payload), &flow); err != nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_OIDC_LOGIN + ": invalid flow")
+		return LoginResponse{}, constants.ErrOIDCStateInvalid
+	}
+
+	if flow.Provider != req.Provider || time.Now().Unix() >= flow.ExpiresAt ||
+		subtle.ConstantTimeCompare([]byte(flow.State), []byte(req.State)) != 1 {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": state mismatch for %s", req.Provider)
+		return LoginResponse{}, constants.ErrOIDCStateInvalid
+	}
+
+	if req.Error != "" || req.Code == "" {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": %s returned %q", req.Provider, req.Error)
+		return LoginResponse{}, constants.ErrOIDCExchange
+	}
+
+	identity, err := p.Exchange(ctx, req.Code, flow.Verifier, flow.Nonce)
+	if err != nil {
+		logging.Log.WithError(err).Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": %s", req.Provider)
+		return LoginResponse{}, constants.ErrOIDCExchange
+	}
+
+	return us.loginWithIdentity(ctx, req.Provider, identity)
+}
+
+// loginWithIdentity signs in the user linked to identity. An identity seen
+// for the first time is linked by its email, which the provider must have
+// verified, to the account with that address, or to a new account when
+// there is none. An account whose address is not verified yet is not linked;
+// it is sent a verification link instead. Lockouts and MFA apply as they do
+// to Login.
+func (us *UserService) loginWithIdentity(ctx context.Context, provider string, identity oidc.Identity) (LoginResponse, error) {
+	user, err := us.identityUser(ctx, provider, identity)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	if user.ServiceAccount {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": %s is a service account", user.Email)
+		return LoginResponse{}, constants.ErrInvalidLoginCredential
+	}
+
+	if lockedUntil := activeLock(user); lockedUntil != nil {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": %s locked until %s", user.Email, lockedUntil.Format(time.RFC3339))
+		return LoginResponse{}, &helpers.TooManyRequestsError{RetryAfter: time.Until(*lockedUntil), Err: constants.ErrAccountLocked}
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_OIDC_LOGIN+": %s with %s", user.Email, provider)
+
+	if challenge, required, err := us.mfaChallenge(ctx, user); err != nil || required {
+		return challenge, err
+	}
+
+	return us.issueTokens(ctx, user)
+}
+
+func (us *UserService) identityUser(ctx context.Context, provider string, identity oidc.Identity) (User, error) {
+	linked, found, err := us.userRepo.GetExternalIdentity(ctx, nil, provider, identity.Subject)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_OIDC_LOGIN)
+		return User{}, constants.ErrOIDCLogin
+	}
+	if found {
+		user, found, err := us.userRepo.GetUserByID(ctx, nil, linked.UserID.String())
+		if err != nil || !found {
+			logging.Log.WithError(err).Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": no account for %s identity %s", provider, identity.Subject)
+			return User{}, constants.ErrOIDCLogin
+		}
+		return user, nil
+	}
+
+	if !identity.EmailVerified || !helpers.IsValidEmail(identity.Email) {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": %s identity %s without verified email", provider, identity.Subject)
+		return User{}, constants.ErrOIDCEmailNotVerified
+	}
+
+	now := time.Now()
+	link := ExternalIdentity{
+		ID:        uuid.New(),
+		Provider:  provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: now,
+	}
+
+	user, found, err := us.userRepo.GetUserByEmail(ctx, nil, identity.Email)
+	if err == nil && found {
+		// Anyone could have registered an unverified account with the
+		// address, or changed theirs to it, and set its password, so it is
+		// only linked once its owner has verified the address. Changing the
+		// email clears EmailVerifiedAt, so it always refers to user.Email.
+		if user.EmailVerifiedAt == nil {
+			logging.Log.Warnf(constants.MESSAGE_FAILED_OIDC_LOGIN+": %s identity %s matches unverified %s", provider, identity.Subject, user.Email)
+			us.sendVerificationEmail(ctx, user)
+			return User{}, constants.ErrOIDCAccountNotVerified
+		}
+
+		link.UserID = user.ID
+		if err := us.userRepo.CreateExternalIdentity(ctx, nil, link); err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_OIDC_LOGIN)
+			return User{}, constants.ErrOIDCLogin
+		}
+
+		logging.Log.Infof("linked %s identity %s to %s", provider, identity.Subject, user.Email)
+		return user, nil
+	}
+
+	// Accounts created this way have a random password; a password can be
+	// set later through ForgotPassword.
+	raw := make([]byte, oidcPasswordBytes)
+	if _, err := rand.Read(raw); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_OIDC_LOGIN)
+		return User{}, constants.ErrOIDCLogin
+	}
+
+	name := strings.TrimSpace(identity.Name)
+	if name == "" {
+		name, _, _ = strings.Cut(identity.Email, "@")
+	}
+
+	user = User{
+		ID:              uuid.New(),
+		Name:            name,
+		Email:           identity.Email,
+		Password:        base64.RawURLEncoding.EncodeToString(raw),
+		Role:            constants.ENUM_ROLE_USER,
+		Version:         1,
+		EmailVerifiedAt: &now,
+	}
+	link.UserID = user.ID
+
+	if err := us.userRepo.RegisterWithIdentity(ctx, nil, user, link); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_OIDC_LOGIN)
+		return User{}, constants.ErrOIDCLogin
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_REGISTER+": %s with %s", user.Email, provider)
+
+	return user, nil
+}
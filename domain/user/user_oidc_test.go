@@ -0,0 +1,280 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	jwtservice "github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/oidc"
+	"github.com/mferdian/Go-GraphQL/oidc/oidctest"
+	"gorm.io/gorm"
+)
+
+// stubProvider sends users to a fixed URL and never exchanges codes.
+type stubProvider struct{}
+
+func (stubProvider) AuthCodeURL(_ context.Context, state, _, _ string) (string, error) {
+	return "https://idp.example.com/authorize?state=" + state, nil
+}
+
+func (stubProvider) Exchange(context.Context, string, string, string) (oidc.Identity, error) {
+	return oidc.Identity{}, errors.New("stub provider cannot exchange codes")
+}
+
+func TestOIDCFlowRequiresSecret(t *testing.T) {
+	providers := oidc.Providers{"stub": stubProvider{}}
+
+	t.Setenv("OIDC_STATE_SECRET", "")
+	t.Setenv("JWT_SECRET", "")
+	unconfigured := &UserService{oidc: newOIDCConfig(providers)}
+	if _, err := unconfigured.StartOIDCLogin(context.Background(), "stub"); !errors.Is(err, constants.ErrOIDCLogin) {
+		t.Errorf("StartOIDCLogin without secret = %v, want %v", err, constants.ErrOIDCLogin)
+	}
+
+	t.Setenv("JWT_SECRET", "secret")
+	configured := &UserService{oidc: newOIDCConfig(providers)}
+	res, err := configured.StartOIDCLogin(context.Background(), "stub")
+	if err != nil {
+		t.Fatalf("StartOIDCLogin = %v", err)
+	}
+
+	state := strings.TrimPrefix(res.AuthURL, "https://idp.example.com/authorize?state=")
+	req := OIDCCallbackRequest{Provider: "stub", Flow: res.Flow, State: state, Code: "code"}
+	if _, err := unconfigured.FinishOIDCLogin(context.Background(), req); !errors.Is(err, constants.ErrOIDCStateInvalid) {
+		t.Errorf("FinishOIDCLogin without secret = %v, want %v", err, constants.ErrOIDCStateInvalid)
+	}
+	if _, err := configured.FinishOIDCLogin(context.Background(), req); !errors.Is(err, constants.ErrOIDCExchange) {
+		t.Errorf("FinishOIDCLogin = %v, want the flow accepted and %v from the stub", err, constants.ErrOIDCExchange)
+	}
+}
+
+func TestIdentityUserRefusesUnverifiedAccount(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice@example.com")
+
+	mail := newRecordingMailer()
+	us := &UserService{
+		userRepo:     NewUserRepository(db),
+		mailer:       mail,
+		verification: emailVerification{secret: []byte("secret"), ttl: time.Hour, url: "http://localhost/verify"},
+	}
+
+	identity := oidc.Identity{Subject: "alice", Email: user.Email, EmailVerified: true}
+	if _, err := us.identityUser(context.Background(), "google", identity); !errors.Is(err, constants.ErrOIDCAccountNotVerified) {
+		t.Fatalf("identityUser = %v, want %v", err, constants.ErrOIDCAccountNotVerified)
+	}
+
+	if _, found, _ := us.userRepo.GetExternalIdentity(context.Background(), nil, "google", "alice"); found {
+		t.Error("identity linked to an unverified account")
+	}
+	if got, _, _ := us.userRepo.GetUserByID(context.Background(), nil, user.ID.String()); got.EmailVerifiedAt != nil {
+		t.Error("unverified account marked verified")
+	}
+
+	select {
+	case msg := <-mail.sent:
+		if msg.To != user.Email || !strings.Contains(msg.Body, "http://localhost/verify?token=") {
+			t.Errorf("sent %+v, want a verification link to %s", msg, user.Email)
+		}
+	case <-time.After(time.Second):
+		t.Error("no verification email sent")
+	}
+}
+
+// newOIDCTestService returns a service signing in with a fake issuer, as
+// provider "test" and again as "other".
+func newOIDCTestService(t *testing.T, db *gorm.DB) (*UserService, *oidctest.Issuer) {
+	t.Helper()
+
+	t.Setenv("JWT_SECRET", "secret")
+	issuer := oidctest.NewIssuer(t)
+	provider := oidc.NewProvider(issuer.Config())
+
+	us := NewUserService(NewUserRepository(db), jwtservice.NewJWTService(), newRecordingMailer(), oidc.Providers{"test": provider, "other": provider})
+	return us, issuer
+}
+
+// oidcCallback starts a sign in with "test" and returns the callback the
+// issuer sends the user back with.
+func oidcCallback(t *testing.T, us *UserService, issuer *oidctest.Issuer) OIDCCallbackRequest {
+	t.Helper()
+
+	res, err := us.StartOIDCLogin(context.Background(), "test")
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+
+	code, state := issuer.Authorize(t, res.AuthURL)
+	return OIDCCallbackRequest{Provider: "test", Code: code, State: state, Flow: res.Flow}
+}
+
+// resealFlow changes the flow kept by the client as only someone knowing the
+// secret could.
+func resealFlow(t *testing.T, secret []byte, req *OIDCCallbackRequest, change func(flow *oidcFlow)) {
+	t.Helper()
+
+	payload, err := helpers.Decrypt(secret, req.Flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var flow oidcFlow
+	if err := json.Unmarshal([]byte(payload), &flow); err != nil {
+		t.Fatal(err)
+	}
+
+	change(&flow)
+
+	changed, _ := json.Marshal(flow)
+	if req.Flow, err = helpers.Encrypt(secret, string(changed)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFinishOIDCLoginRejects(t *testing.T) {
+	db := newTestDB(t)
+	us, issuer := newOIDCTestService(t, db)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		keyID  string
+		tamper func(req *OIDCCallbackRequest)
+		want   error
+	}{
+		{name: "state mismatch", tamper: func(req *OIDCCallbackRequest) { req.State += "x" }, want: constants.ErrOIDCStateInvalid},
+		{name: "other provider", tamper: func(req *OIDCCallbackRequest) { req.Provider = "other" }, want: constants.ErrOIDCStateInvalid},
+		{name: "unknown provider", tamper: func(req *OIDCCallbackRequest) { req.Provider = "unknown" }, want: constants.ErrOIDCProviderNotFound},
+		{name: "forged flow", tamper: func(req *OIDCCallbackRequest) {
+			req.Flow, _ = helpers.Encrypt([]byte("other secret"), `{"p":"test","s":"`+req.State+`","e":9999999999}`)
+		}, want: constants.ErrOIDCStateInvalid},
+		{name: "expired flow", tamper: func(req *OIDCCallbackRequest) {
+			resealFlow(t, us.oidc.secret, req, func(flow *oidcFlow) { flow.ExpiresAt = time.Now().Unix() })
+		}, want: constants.ErrOIDCStateInvalid},
+		{name: "provider error", tamper: func(req *OIDCCallbackRequest) { req.Error = "access_denied" }, want: constants.ErrOIDCExchange},
+		{name: "verifier fails S256", tamper: func(req *OIDCCallbackRequest) {
+			resealFlow(t, us.oidc.secret, req, func(flow *oidcFlow) { flow.Verifier += "x" })
+		}, want: constants.ErrOIDCExchange},
+		{name: "nonce mismatch", tamper: func(req *OIDCCallbackRequest) {
+			resealFlow(t, us.oidc.secret, req, func(flow *oidcFlow) { flow.Nonce += "x" })
+		}, want: constants.ErrOIDCExchange},
+		{name: "wrong issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}, want: constants.ErrOIDCExchange},
+		{name: "wrong audience", claims: jwt.MapClaims{"aud": "other-client"}, want: constants.ErrOIDCExchange},
+		{name: "unknown key", keyID: "other-key", want: constants.ErrOIDCExchange},
+		{name: "email_verified false", claims: jwt.MapClaims{"email_verified": false}, want: constants.ErrOIDCEmailNotVerified},
+		{name: `email_verified "false"`, claims: jwt.MapClaims{"email_verified": "false"}, want: constants.ErrOIDCEmailNotVerified},
+	}
+
+	for _, tt := range tests {
+		keyID := tt.keyID
+		if keyID == "" {
+			keyID = oidctest.KeyID
+		}
+		issuer.SetClaims(tt.claims)
+		issuer.SetTokenKeyID(keyID)
+
+		req := oidcCallback(t, us, issuer)
+		if tt.tamper != nil {
+			tt.tamper(&req)
+		}
+
+		if res, err := us.FinishOIDCLogin(context.Background(), req); !errors.Is(err, tt.want) {
+			t.Errorf("%s: FinishOIDCLogin = %+v, %v; want %v", tt.name, res, err, tt.want)
+		}
+	}
+
+	var users int64
+	db.Model(&User{}).Count(&users)
+	if users != 0 {
+		t.Errorf("%d accounts created by rejected sign ins", users)
+	}
+}
+
+func TestOIDCLoginCreatesAccount(t *testing.T) {
+	db := newTestDB(t)
+	us, issuer := newOIDCTestService(t, db)
+	ctx := context.Background()
+
+	// Some providers send email_verified as a string.
+	issuer.SetClaims(jwt.MapClaims{"email_verified": "true"})
+
+	res, err := us.FinishOIDCLogin(ctx, oidcCallback(t, us, issuer))
+	if err != nil || res.AccessToken == "" {
+		t.Fatalf("FinishOIDCLogin = %+v, %v; want tokens", res, err)
+	}
+
+	user, found, _ := us.userRepo.GetUserByEmail(ctx, nil, oidctest.Email)
+	if !found || user.Name != "Alice" || user.EmailVerifiedAt == nil || !strings.HasPrefix(user.Password, "$argon2id$") {
+		t.Fatalf("created account = %+v, want Alice, verified, with a hashed password", user)
+	}
+
+	link, found, _ := us.userRepo.GetExternalIdentity(ctx, nil, "test", oidctest.Subject)
+	if !found || link.UserID != user.ID {
+		t.Errorf("identity = %+v, %t; want linked to %s", link, found, user.ID)
+	}
+}
+
+func TestOIDCLoginLinksExistingAccount(t *testing.T) {
+	db := newTestDB(t)
+	us, issuer := newOIDCTestService(t, db)
+	ctx := context.Background()
+
+	existing := createTestUser(t, db, oidctest.Email)
+	db.Model(&existing).Update("email_verified_at", time.Now())
+
+	res, err := us.FinishOIDCLogin(ctx, oidcCallback(t, us, issuer))
+	if err != nil || res.AccessToken == "" {
+		t.Fatalf("FinishOIDCLogin = %+v, %v; want tokens", res, err)
+	}
+
+	link, found, _ := us.userRepo.GetExternalIdentity(ctx, nil, "test", oidctest.Subject)
+	if !found || link.UserID != existing.ID {
+		t.Errorf("identity = %+v, %t; want linked to %s", link, found, existing.ID)
+	}
+
+	user, _, _ := us.userRepo.GetUserByID(ctx, nil, existing.ID.String())
+	if user.Password != existing.Password {
+		t.Error("linking changed the password")
+	}
+
+	// Once linked, the identity signs in to the same account whatever email
+	// the provider reports.
+	issuer.SetClaims(jwt.MapClaims{"email": "alice@elsewhere.example.com", "email_verified": false})
+	if res, err := us.FinishOIDCLogin(ctx, oidcCallback(t, us, issuer)); err != nil || res.AccessToken == "" {
+		t.Fatalf("second FinishOIDCLogin = %+v, %v; want tokens", res, err)
+	}
+
+	var users int64
+	db.Model(&User{}).Count(&users)
+	if users != 1 {
+		t.Errorf("%d accounts, want only the existing one", users)
+	}
+}
+
+func TestOIDCLoginRefusesChangedEmail(t *testing.T) {
+	db := newTestDB(t)
+	us, issuer := newOIDCTestService(t, db)
+
+	attacker := createTestUser(t, db, "mallory@example.com")
+	db.Model(&attacker).Update("email_verified_at", time.Now())
+
+	ctx := helpers.WithActor(context.Background(), attacker.ID.String(), constants.ENUM_ROLE_USER)
+	email := oidctest.Email
+	if _, err := us.UpdateUser(ctx, UpdateUserRequest{ID: attacker.ID.String(), Email: &email}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+
+	if _, err := us.FinishOIDCLogin(context.Background(), oidcCallback(t, us, issuer)); !errors.Is(err, constants.ErrOIDCAccountNotVerified) {
+		t.Fatalf("FinishOIDCLogin = %v, want %v", err, constants.ErrOIDCAccountNotVerified)
+	}
+
+	if _, found, _ := us.userRepo.GetExternalIdentity(context.Background(), nil, "test", oidctest.Subject); found {
+		t.Error("identity linked to an account whose new email is unverified")
+	}
+}
@@ -0,0 +1,170 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/mailer"
+)
+
+const (
+	defaultPasswordResetTTL = 30 * time.Minute
+	defaultPasswordResetURL = "http://localhost:8000/reset-password"
+
+	passwordResetTokenBytes = 32
+)
+
+type passwordResetConfig struct {
+	ttl time.Duration
+	url string
+}
+
+// newPasswordResetConfig reads PASSWORD_RESET_TTL (default 30m) and the link
+// base PASSWORD_RESET_URL, which gets the token in ?token=.
+func newPasswordResetConfig() passwordResetConfig {
+	ttl := helpers.DurationEnv("PASSWORD_RESET_TTL", defaultPasswordResetTTL)
+
+	link := os.Getenv("PASSWORD_RESET_URL")
+	if link == "" {
+		link = defaultPasswordResetURL
+	}
+
+	return passwordResetConfig{ttl: ttl, url: link}
+}
+
+// ForgotPassword emails a reset link when an account with the address
+// exists. It reports nothing back and does all the work in the background,
+// so neither the response nor its timing tells callers whether it did.
+func (us *UserService) ForgotPassword(ctx context.Context, req ForgotPasswordRequest) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendEmailTimeout)
+	go func() {
+		defer cancel()
+		us.sendPasswordResetEmail(ctx, req.Email)
+	}()
+}
+
+func (us *UserService) sendPasswordResetEmail(ctx context.Context, email string) {
+	user, found, err := us.userRepo.GetUserByEmail(ctx, nil, email)
+	if err != nil || !found {
+		logging.Log.Infof(constants.MESSAGE_FAILED_FORGOT_PASSWORD+": no account for %s", email)
+		return
+	}
+
+	raw := make([]byte, passwordResetTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_FORGOT_PASSWORD)
+		return
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	now := time.Now()
+	err = us.userRepo.CreatePasswordResetToken(ctx, nil, PasswordResetToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		TokenHash: hashResetToken(token),
+		ExpiresAt: now.Add(us.passwordReset.ttl),
+		CreatedAt: now,
+	})
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_FORGOT_PASSWORD)
+		return
+	}
+
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. To choose a new password, open the link below:\n\n%s\n\nThe link expires in %s and works once. If you did not ask for this, you can ignore this email.\n",
+			user.Name, withToken(us.passwordReset.url, token), humanDuration(us.passwordReset.ttl)),
+	}
+	if err := us.mailer.Send(ctx, msg); err != nil {
+		logging.Log.WithError(err).Errorf(constants.MESSAGE_FAILED_SEND_EMAIL+": %s", msg.To)
+	}
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword. The
+// token is used up, every token issued to the user before is revoked, and
+// an account lockout is lifted.
+func (us *UserService) ResetPassword(ctx context.Context, req ResetPasswordRequest) error {
+	tokenHash := hashResetToken(req.Token)
+	token, found, err := us.userRepo.GetPasswordResetToken(ctx, nil, tokenHash, time.Now())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_RESET_PASSWORD)
+		return constants.ErrResetPassword
+	}
+	if !found {
+		logging.Log.Warn(constants.MESSAGE_FAILED_RESET_PASSWORD + ": unknown, used or expired token")
+		return constants.ErrResetTokenInvalid
+	}
+
+	owner, _, err := us.userRepo.GetUserByID(ctx, nil, token.UserID.String())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_RESET_PASSWORD)
+		return constants.ErrResetPassword
+	}
+
+	if err := us.passwordPolicy.Validate(req.Password, owner.Email); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_RESET_PASSWORD)
+		return err
+	}
+
+	hashed, err := helpers.HashPassword(req.Password)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_RESET_PASSWORD)
+		return constants.ErrHashPassword
+	}
+
+	user, found, err := us.userRepo.ResetPassword(ctx, nil, tokenHash, hashed, time.Now())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_RESET_PASSWORD)
+		return constants.ErrResetPassword
+	}
+	if !found {
+		logging.Log.Warn(constants.MESSAGE_FAILED_RESET_PASSWORD + ": unknown, used or expired token")
+		return constants.ErrResetTokenInvalid
+	}
+
+	us.loginThrottle.Succeed(user.Email)
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_RESET_PASSWORD+": %s", user.Email)
+
+	us.sendEmail(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Your password was changed",
+		Body:    fmt.Sprintf("Hi %s,\n\nThe password of your account was just reset and you were signed out everywhere. If this was not you, reset your password again right away.\n", user.Name),
+	})
+
+	return nil
+}
+
+// TokensRevoked implements jwt.RevocationCheck. Tokens issued in the same
+// second as a revocation are still accepted, so logging in right after a
+// reset works. The token's session must also still be active, see
+// sessionActive. When a lookup fails the token is treated as revoked.
+func (us *UserService) TokensRevoked(userID, sessionID string, issuedAt time.Time) bool {
+	revokedAt, err := us.userRepo.GetSessionsRevokedAt(context.Background(), nil, userID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("id", userID).Error(constants.MESSAGE_FAILED_TOKEN_NOT_VALID)
+		return true
+	}
+
+	if revokedAt != nil && issuedAt.Before(*revokedAt) {
+		return true
+	}
+
+	return !us.sessionActive(userID, sessionID)
+}
+
+func hashResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/mailer"
+	"gorm.io/gorm"
+)
+
+type recordingMailer struct {
+	sent chan mailer.Message
+}
+
+func newRecordingMailer() *recordingMailer {
+	return &recordingMailer{sent: make(chan mailer.Message, 10)}
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg mailer.Message) error {
+	m.sent <- msg
+	return nil
+}
+
+// gatedUserRepository holds up email lookups until release is closed.
+type gatedUserRepository struct {
+	IUserRepository
+	release chan struct{}
+}
+
+func (r *gatedUserRepository) GetUserByEmail(ctx context.Context, tx *gorm.DB, email string) (User, bool, error) {
+	<-r.release
+	return r.IUserRepository.GetUserByEmail(ctx, tx, email)
+}
+
+func TestForgotPasswordRunsOffTheRequestPath(t *testing.T) {
+	db := newTestDB(t)
+	createTestUser(t, db, "alice@example.com")
+
+	repo := &gatedUserRepository{IUserRepository: NewUserRepository(db), release: make(chan struct{})}
+	mail := newRecordingMailer()
+	us := &UserService{userRepo: repo, mailer: mail, passwordReset: passwordResetConfig{ttl: time.Hour, url: "http://localhost/reset"}}
+
+	for _, email := range []string{"alice@example.com", "nobody@example.com"} {
+		done := make(chan struct{})
+		go func() {
+			us.ForgotPassword(context.Background(), ForgotPasswordRequest{Email: email})
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("ForgotPassword(%s) waited for the account lookup", email)
+		}
+	}
+	close(repo.release)
+
+	select {
+	case msg := <-mail.sent:
+		if msg.To != "alice@example.com" || !strings.Contains(msg.Body, "http://localhost/reset?token=") {
+			t.Errorf("sent %+v, want a reset link to alice", msg)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reset email sent")
+	}
+
+	select {
+	case msg := <-mail.sent:
+		t.Errorf("unexpected email to %s", msg.To)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	var tokens int64
+	db.Model(&PasswordResetToken{}).Count(&tokens)
+	if tokens != 1 {
+		t.Errorf("%d reset tokens stored, want 1", tokens)
+	}
+}
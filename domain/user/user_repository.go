@@ -2,10 +2,14 @@ package user
 
 import (
 	"context"
+	"errors"
 	"math"
 	"strings"
+	"time"
 
+	"github.com/mferdian/Go-GraphQL/domain/audit"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type (
@@ -15,9 +19,42 @@ type (
 		GetUserByEmail(ctx context.Context, tx *gorm.DB, email string) (User, bool, error)
 		GetAllUser(ctx context.Context, tx *gorm.DB, search string) ([]User, error)
 		GetAllUserWithPagination(ctx context.Context, tx *gorm.DB, req UserPaginationRequest) (UserPaginationRepositoryResponse, error)
+		StreamUsers(ctx context.Context, tx *gorm.DB, req UserPaginationRequest, fn func(user User) error) error
 		CreateUser(ctx context.Context, tx *gorm.DB, user User) error
-		UpdateUser(ctx context.Context, tx *gorm.DB, user User) error
+		UpdateUser(ctx context.Context, tx *gorm.DB, user User) (bool, error)
 		DeleteUserByID(ctx context.Context, tx *gorm.DB, userID string) error
+		GetTrashedUsers(ctx context.Context, tx *gorm.DB, req UserPaginationRequest) (UserPaginationRepositoryResponse, error)
+		GetTrashedUserByID(ctx context.Context, tx *gorm.DB, userID string) (User, bool, error)
+		RestoreUserByID(ctx context.Context, tx *gorm.DB, userID string) error
+		PurgeUserByID(ctx context.Context, tx *gorm.DB, userID string) error
+		PurgeUsersDeletedBefore(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error)
+		IncrementFailedLogins(ctx context.Context, tx *gorm.DB, userID string) (User, error)
+		LockUser(ctx context.Context, tx *gorm.DB, userID string, threshold int, until time.Time) (bool, error)
+		ResetFailedLogins(ctx context.Context, tx *gorm.DB, userID string) error
+		UnlockUser(ctx context.Context, tx *gorm.DB, userID string) (bool, error)
+		MarkEmailVerified(ctx context.Context, tx *gorm.DB, userID, email string, at time.Time) (bool, error)
+		CreatePasswordResetToken(ctx context.Context, tx *gorm.DB, token PasswordResetToken) error
+		ResetPassword(ctx context.Context, tx *gorm.DB, tokenHash, password string, now time.Time) (User, bool, error)
+		GetSessionsRevokedAt(ctx context.Context, tx *gorm.DB, userID string) (*time.Time, error)
+		GetPasswordResetToken(ctx context.Context, tx *gorm.DB, tokenHash string, now time.Time) (PasswordResetToken, bool, error)
+		RehashPassword(ctx context.Context, tx *gorm.DB, userID, oldHash, newHash string) error
+		SetPendingTOTPSecret(ctx context.Context, tx *gorm.DB, userID, secret string) error
+		EnableTOTP(ctx context.Context, tx *gorm.DB, userID, secret string, step int64, at time.Time, codes []RecoveryCode) (bool, error)
+		DisableTOTP(ctx context.Context, tx *gorm.DB, userID string) error
+		UseTOTPStep(ctx context.Context, tx *gorm.DB, userID string, step int64) (bool, error)
+		UseRecoveryCode(ctx context.Context, tx *gorm.DB, userID, codeHash string, at time.Time) (bool, error)
+		GetMFAPolicies(ctx context.Context, tx *gorm.DB) ([]MFAPolicy, error)
+		IsMFARequired(ctx context.Context, tx *gorm.DB, role string) (bool, error)
+		SetMFAPolicy(ctx context.Context, tx *gorm.DB, policy MFAPolicy) error
+		CreateSession(ctx context.Context, tx *gorm.DB, session Session) error
+		GetSession(ctx context.Context, tx *gorm.DB, sessionID string) (Session, bool, error)
+		GetActiveSessions(ctx context.Context, tx *gorm.DB, userID string, now time.Time) ([]Session, error)
+		TouchSession(ctx context.Context, tx *gorm.DB, sessionID string, now, before time.Time) error
+		RevokeSession(ctx context.Context, tx *gorm.DB, userID, sessionID string, now time.Time) (bool, error)
+		RevokeOtherSessions(ctx context.Context, tx *gorm.DB, userID, keepID string, now time.Time) (int64, error)
+		GetExternalIdentity(ctx context.Context, tx *gorm.DB, provider, subject string) (ExternalIdentity, bool, error)
+		CreateExternalIdentity(ctx context.Context, tx *gorm.DB, identity ExternalIdentity) error
+		RegisterWithIdentity(ctx context.Context, tx *gorm.DB, user User, identity ExternalIdentity) error
 	}
 
 	UserRepository struct {
@@ -114,7 +151,32 @@ func (ur *UserRepository) GetAllUserWithPagination(ctx context.Context, tx *gorm
 		req.PaginationRequest.Page = 1
 	}
 
-	query := tx.WithContext(ctx).Model(&User{})
+	query := listQuery(tx.WithContext(ctx), req)
+
+	if err := query.Count(&count).Error; err != nil {
+		return UserPaginationRepositoryResponse{}, err
+	}
+
+	if err := query.Order("created_at DESC").Scopes(Paginate(req.PaginationRequest.Page, req.PaginationRequest.PerPage)).Find(&users).Error; err != nil {
+		return UserPaginationRepositoryResponse{}, err
+	}
+
+	totalPage := int64(math.Ceil(float64(count) / float64(req.PaginationRequest.PerPage)))
+
+	return UserPaginationRepositoryResponse{
+		Users: users,
+		PaginationResponse: PaginationResponse{
+			Page:    req.PaginationRequest.Page,
+			PerPage: req.PaginationRequest.PerPage,
+			MaxPage: totalPage,
+			Count:   count,
+		},
+	}, err
+}
+
+// listQuery applies the filters shared by the user list and export.
+func listQuery(tx *gorm.DB, req UserPaginationRequest) *gorm.DB {
+	query := tx.Model(&User{})
 
 	if req.PaginationRequest.Search != "" {
 		searchValue := "%" + strings.ToLower(req.PaginationRequest.Search) + "%"
@@ -126,11 +188,112 @@ func (ur *UserRepository) GetAllUserWithPagination(ctx context.Context, tx *gorm
 		query = query.Where("id = ?", req.UserID)
 	}
 
+	return query
+}
+
+// StreamUsers walks every user matching the list filters with a database
+// cursor, in list order, calling fn for each one. Returning an error from fn
+// stops the walk.
+func (ur *UserRepository) StreamUsers(ctx context.Context, tx *gorm.DB, req UserPaginationRequest, fn func(user User) error) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	query := listQuery(tx.WithContext(ctx), req)
+
+	rows, err := query.Order("created_at DESC").Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var user User
+		if err := query.ScanRows(rows, &user); err != nil {
+			return err
+		}
+
+		if err := fn(user); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (ur *UserRepository) CreateUser(ctx context.Context, tx *gorm.DB, user User) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Create(&user).Error
+}
+
+// UpdateUser only writes when user.Version still matches the stored version
+// and bumps it in the same statement. It reports false when the row was
+// changed concurrently. email_verified_at is written from user as well, so
+// a changed address loses its verification in the same statement.
+func (ur *UserRepository) UpdateUser(ctx context.Context, tx *gorm.DB, user User) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := tx.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND version = ?", user.ID, user.Version).
+		Updates(map[string]interface{}{
+			"name":              user.Name,
+			"email":             user.Email,
+			"email_verified_at": user.EmailVerifiedAt,
+			"password":          user.Password,
+			"phone_number":      user.PhoneNumber,
+			"address":           user.Address,
+			"updated_at":        user.UpdatedAt,
+			"version":           gorm.Expr("version + 1"),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (ur *UserRepository) DeleteUserByID(ctx context.Context, tx *gorm.DB, userID string) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Where("id = ?", userID).Delete(&User{}).Error
+}
+
+func (ur *UserRepository) GetTrashedUsers(ctx context.Context, tx *gorm.DB, req UserPaginationRequest) (UserPaginationRepositoryResponse, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var users []User
+	var count int64
+
+	if req.PaginationRequest.PerPage == 0 {
+		req.PaginationRequest.PerPage = 10
+	}
+
+	if req.PaginationRequest.Page == 0 {
+		req.PaginationRequest.Page = 1
+	}
+
+	query := tx.WithContext(ctx).Unscoped().Model(&User{}).Where("deleted_at IS NOT NULL")
+
+	if req.PaginationRequest.Search != "" {
+		searchValue := "%" + strings.ToLower(req.PaginationRequest.Search) + "%"
+		query = query.Where("LOWER(name) LIKE ? OR LOWER(email) LIKE ?",
+			searchValue, searchValue)
+	}
+
 	if err := query.Count(&count).Error; err != nil {
 		return UserPaginationRepositoryResponse{}, err
 	}
 
-	if err := query.Order("created_at DESC").Scopes(Paginate(req.PaginationRequest.Page, req.PaginationRequest.PerPage)).Find(&users).Error; err != nil {
+	if err := query.Order("deleted_at DESC").Scopes(Paginate(req.PaginationRequest.Page, req.PaginationRequest.PerPage)).Find(&users).Error; err != nil {
 		return UserPaginationRepositoryResponse{}, err
 	}
 
@@ -144,29 +307,540 @@ func (ur *UserRepository) GetAllUserWithPagination(ctx context.Context, tx *gorm
 			MaxPage: totalPage,
 			Count:   count,
 		},
-	}, err
+	}, nil
 }
 
-func (ur *UserRepository) CreateUser(ctx context.Context, tx *gorm.DB, user User) error {
+func (ur *UserRepository) GetTrashedUserByID(ctx context.Context, tx *gorm.DB, userID string) (User, bool, error) {
 	if tx == nil {
 		tx = ur.db
 	}
 
-	return tx.WithContext(ctx).Create(&user).Error
+	var user User
+	if err := tx.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", userID).Take(&user).Error; err != nil {
+		return User{}, false, err
+	}
+
+	return user, true, nil
 }
 
-func (ur *UserRepository) UpdateUser(ctx context.Context, tx *gorm.DB, user User) error {
+func (ur *UserRepository) RestoreUserByID(ctx context.Context, tx *gorm.DB, userID string) error {
 	if tx == nil {
 		tx = ur.db
 	}
 
-	return tx.WithContext(ctx).Where("id = ?", user.ID).Updates(&user).Error
+	return tx.WithContext(ctx).Unscoped().Model(&User{}).
+		Where("id = ? AND deleted_at IS NOT NULL", userID).
+		Updates(map[string]interface{}{"deleted_at": nil, "deleted_by": nil}).Error
 }
 
-func (ur *UserRepository) DeleteUserByID(ctx context.Context, tx *gorm.DB, userID string) error {
+func (ur *UserRepository) PurgeUserByID(ctx context.Context, tx *gorm.DB, userID string) error {
 	if tx == nil {
 		tx = ur.db
 	}
 
-	return tx.WithContext(ctx).Where("id = ?", userID).Delete(&User{}).Error
+	return tx.WithContext(ctx).Unscoped().Where("id = ? AND deleted_at IS NOT NULL", userID).Delete(&User{}).Error
+}
+
+func (ur *UserRepository) PurgeUsersDeletedBefore(ctx context.Context, tx *gorm.DB, before time.Time) (int64, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := tx.WithContext(ctx).Unscoped().Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Delete(&User{})
+	return result.RowsAffected, result.Error
+}
+
+// IncrementFailedLogins counts a wrong password and returns the account with
+// its new counters. The counter itself is not audited; lockouts are.
+func (ur *UserRepository) IncrementFailedLogins(ctx context.Context, tx *gorm.DB, userID string) (User, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	err := audit.Skip(tx.WithContext(ctx)).Model(&User{}).
+		Where("id = ?", userID).
+		UpdateColumn("failed_login_attempts", gorm.Expr("failed_login_attempts + 1")).Error
+	if err != nil {
+		return User{}, err
+	}
+
+	var user User
+	if err := tx.WithContext(ctx).Where("id = ?", userID).Take(&user).Error; err != nil {
+		return User{}, err
+	}
+
+	return user, nil
+}
+
+// LockUser locks the account until the given time and starts counting
+// failures afresh. It only applies while the account still has at least
+// threshold failures, so concurrent failed logins lock it once.
+func (ur *UserRepository) LockUser(ctx context.Context, tx *gorm.DB, userID string, threshold int, until time.Time) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := audit.WithAction(tx.WithContext(ctx), audit.ACTION_LOCK).Model(&User{}).
+		Where("id = ? AND failed_login_attempts >= ?", userID, threshold).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"lockout_count":         gorm.Expr("lockout_count + 1"),
+			"locked_until":          until,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ResetFailedLogins clears the lockout state after a successful login.
+func (ur *UserRepository) ResetFailedLogins(ctx context.Context, tx *gorm.DB, userID string) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return audit.Skip(tx.WithContext(ctx)).Model(&User{}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"lockout_count":         0,
+			"locked_until":          nil,
+		}).Error
+}
+
+// UnlockUser clears the lockout state on behalf of an admin and resets the
+// backoff. It reports false when the user does not exist.
+func (ur *UserRepository) UnlockUser(ctx context.Context, tx *gorm.DB, userID string) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := audit.WithAction(tx.WithContext(ctx), audit.ACTION_UNLOCK).Model(&User{}).
+		Where("id = ?", userID).
+		UpdateColumns(map[string]interface{}{
+			"failed_login_attempts": 0,
+			"lockout_count":         0,
+			"locked_until":          nil,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// MarkEmailVerified verifies the user's email, provided it is still the
+// given address and not verified yet. It reports false otherwise, which makes
+// each verification token usable once.
+func (ur *UserRepository) MarkEmailVerified(ctx context.Context, tx *gorm.DB, userID, email string, at time.Time) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := tx.WithContext(ctx).Model(&User{}).
+		Where("id = ? AND email = ? AND email_verified_at IS NULL", userID, email).
+		Update("email_verified_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// CreatePasswordResetToken stores token and drops any earlier token of the
+// same user, so only the latest reset link works.
+func (ur *UserRepository) CreatePasswordResetToken(ctx context.Context, tx *gorm.DB, token PasswordResetToken) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", token.UserID).Delete(&PasswordResetToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&token).Error
+	})
+}
+
+// ResetPassword consumes the unused, unexpired token with the given hash and
+// sets the user's password (already hashed), revoking the user's sessions
+// and clearing any lockout in the same transaction. It reports false when
+// no such token exists.
+func (ur *UserRepository) ResetPassword(ctx context.Context, tx *gorm.DB, tokenHash, password string, now time.Time) (User, bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var user User
+	found := false
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var token PasswordResetToken
+		err := tx.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).Take(&token).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.ID).
+			Update("used_at", now)
+		if result.Error != nil || result.RowsAffected == 0 {
+			// Consumed concurrently.
+			return result.Error
+		}
+
+		err = tx.Model(&User{}).
+			Where("id = ?", token.UserID).
+			Updates(map[string]interface{}{
+				"password":              password,
+				"sessions_revoked_at":   now.Truncate(time.Second),
+				"failed_login_attempts": 0,
+				"lockout_count":         0,
+				"locked_until":          nil,
+				"updated_at":            now,
+				"version":               gorm.Expr("version + 1"),
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", token.UserID).
+			Update("revoked_at", now).Error
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ?", token.UserID).Take(&user).Error; err != nil {
+			return err
+		}
+
+		found = true
+		return nil
+	})
+	if err != nil {
+		return User{}, false, err
+	}
+
+	return user, found, nil
+}
+
+// GetPasswordResetToken returns the unused, unexpired token with the given
+// hash without consuming it.
+func (ur *UserRepository) GetPasswordResetToken(ctx context.Context, tx *gorm.DB, tokenHash string, now time.Time) (PasswordResetToken, bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var token PasswordResetToken
+	err := tx.WithContext(ctx).Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", tokenHash, now).Take(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return PasswordResetToken{}, false, nil
+	}
+	if err != nil {
+		return PasswordResetToken{}, false, err
+	}
+
+	return token, true, nil
+}
+
+// RehashPassword swaps the stored hash for one of the same password made
+// with stronger parameters. It does nothing when the password was changed
+// meanwhile, and is not audited since the password itself stays the same.
+func (ur *UserRepository) RehashPassword(ctx context.Context, tx *gorm.DB, userID, oldHash, newHash string) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return audit.Skip(tx.WithContext(ctx)).Model(&User{}).
+		Where("id = ? AND password = ?", userID, oldHash).
+		UpdateColumn("password", newHash).Error
+}
+
+func (ur *UserRepository) GetSessionsRevokedAt(ctx context.Context, tx *gorm.DB, userID string) (*time.Time, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var user User
+	err := tx.WithContext(ctx).Select("sessions_revoked_at").Where("id = ?", userID).Take(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return user.SessionsRevokedAt, nil
+}
+
+// SetPendingTOTPSecret stores a secret being enrolled, replacing any earlier
+// one. It only takes effect once confirmed, see EnableTOTP.
+func (ur *UserRepository) SetPendingTOTPSecret(ctx context.Context, tx *gorm.DB, userID, secret string) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return audit.Skip(tx.WithContext(ctx)).Model(&User{}).
+		Where("id = ?", userID).
+		UpdateColumn("pending_totp_secret", secret).Error
+}
+
+// EnableTOTP promotes the pending secret to the active one and replaces the
+// user's recovery codes. It reports false when secret is no longer the
+// pending one, e.g. because enrollment was restarted meanwhile.
+func (ur *UserRepository) EnableTOTP(ctx context.Context, tx *gorm.DB, userID, secret string, step int64, at time.Time, codes []RecoveryCode) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	enabled := false
+	err := tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := audit.WithAction(tx, audit.ACTION_ENABLE_MFA).Model(&User{}).
+			Where("id = ? AND pending_totp_secret = ?", userID, secret).
+			UpdateColumns(map[string]interface{}{
+				"totp_secret":         secret,
+				"pending_totp_secret": "",
+				"totp_enabled_at":     at,
+				"totp_last_step":      step,
+			})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		if err := tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&codes).Error; err != nil {
+			return err
+		}
+
+		enabled = true
+		return nil
+	})
+
+	return enabled, err
+}
+
+// DisableTOTP turns two-factor authentication off and drops the recovery
+// codes.
+func (ur *UserRepository) DisableTOTP(ctx context.Context, tx *gorm.DB, userID string) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := audit.WithAction(tx, audit.ACTION_DISABLE_MFA).Model(&User{}).
+			Where("id = ?", userID).
+			UpdateColumns(map[string]interface{}{
+				"totp_secret":         "",
+				"pending_totp_secret": "",
+				"totp_enabled_at":     nil,
+				"totp_last_step":      0,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("user_id = ?", userID).Delete(&RecoveryCode{}).Error
+	})
+}
+
+// UseTOTPStep records that a code for step was accepted. It reports false
+// when a code for that step or a later one was used already.
+func (ur *UserRepository) UseTOTPStep(ctx context.Context, tx *gorm.DB, userID string, step int64) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := audit.Skip(tx.WithContext(ctx)).Model(&User{}).
+		Where("id = ? AND totp_last_step < ?", userID, step).
+		UpdateColumn("totp_last_step", step)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// UseRecoveryCode marks the user's unused recovery code with the given hash
+// as used. It reports false when there is no such code.
+func (ur *UserRepository) UseRecoveryCode(ctx context.Context, tx *gorm.DB, userID, codeHash string, at time.Time) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := tx.WithContext(ctx).Model(&RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (ur *UserRepository) GetMFAPolicies(ctx context.Context, tx *gorm.DB) ([]MFAPolicy, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var policies []MFAPolicy
+	if err := tx.WithContext(ctx).Order("role").Find(&policies).Error; err != nil {
+		return nil, err
+	}
+
+	return policies, nil
+}
+
+func (ur *UserRepository) IsMFARequired(ctx context.Context, tx *gorm.DB, role string) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var count int64
+	err := tx.WithContext(ctx).Model(&MFAPolicy{}).
+		Where("role = ? AND required = ?", role, true).
+		Count(&count).Error
+
+	return count > 0, err
+}
+
+func (ur *UserRepository) SetMFAPolicy(ctx context.Context, tx *gorm.DB, policy MFAPolicy) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "role"}},
+		DoUpdates: clause.AssignmentColumns([]string{"required", "updated_at"}),
+	}).Create(&policy).Error
+}
+
+func (ur *UserRepository) CreateSession(ctx context.Context, tx *gorm.DB, session Session) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Create(&session).Error
+}
+
+func (ur *UserRepository) GetSession(ctx context.Context, tx *gorm.DB, sessionID string) (Session, bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var session Session
+	if err := tx.WithContext(ctx).Where("id = ?", sessionID).Take(&session).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Session{}, false, nil
+		}
+		return Session{}, false, err
+	}
+
+	return session, true, nil
+}
+
+// GetActiveSessions returns the user's sessions that are neither revoked nor
+// expired, most recently seen first.
+func (ur *UserRepository) GetActiveSessions(ctx context.Context, tx *gorm.DB, userID string, now time.Time) ([]Session, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var sessions []Session
+	err := tx.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// TouchSession records activity on the session unless some was already
+// recorded since before, so it is not written on every request.
+func (ur *UserRepository) TouchSession(ctx context.Context, tx *gorm.DB, sessionID string, now, before time.Time) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Model(&Session{}).
+		Where("id = ? AND last_seen_at < ?", sessionID, before).
+		UpdateColumn("last_seen_at", now).Error
+}
+
+// RevokeSession revokes one of the user's sessions. It reports false when
+// the user has no such active session.
+func (ur *UserRepository) RevokeSession(ctx context.Context, tx *gorm.DB, userID, sessionID string, now time.Time) (bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := tx.WithContext(ctx).Model(&Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		UpdateColumn("revoked_at", now)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// RevokeOtherSessions revokes every active session of the user but keepID
+// and returns how many there were.
+func (ur *UserRepository) RevokeOtherSessions(ctx context.Context, tx *gorm.DB, userID, keepID string, now time.Time) (int64, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	result := tx.WithContext(ctx).Model(&Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		UpdateColumn("revoked_at", now)
+
+	return result.RowsAffected, result.Error
+}
+
+func (ur *UserRepository) GetExternalIdentity(ctx context.Context, tx *gorm.DB, provider, subject string) (ExternalIdentity, bool, error) {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	var identity ExternalIdentity
+	if err := tx.WithContext(ctx).Where("provider = ? AND subject = ?", provider, subject).Take(&identity).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ExternalIdentity{}, false, nil
+		}
+		return ExternalIdentity{}, false, err
+	}
+
+	return identity, true, nil
+}
+
+func (ur *UserRepository) CreateExternalIdentity(ctx context.Context, tx *gorm.DB, identity ExternalIdentity) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Create(&identity).Error
+}
+
+// RegisterWithIdentity creates a user signing in with an identity provider
+// for the first time together with the link to their external account.
+func (ur *UserRepository) RegisterWithIdentity(ctx context.Context, tx *gorm.DB, user User, identity ExternalIdentity) error {
+	if tx == nil {
+		tx = ur.db
+	}
+
+	return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		return tx.Create(&identity).Error
+	})
 }
@@ -0,0 +1,91 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB returns an empty in-memory database private to the test.
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&User{}, &Session{}, &MFAPolicy{}, &ExternalIdentity{}, &PasswordResetToken{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+func createTestUser(t *testing.T, db *gorm.DB, email string) User {
+	t.Helper()
+
+	user := User{
+		ID:       uuid.New(),
+		Name:     "Test user",
+		Email:    email,
+		Password: "Correct-Horse-9-battery",
+		Role:     constants.ENUM_ROLE_USER,
+		Version:  1,
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+
+	return user
+}
+
+func TestLockUserThreshold(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	ctx := context.Background()
+	user := createTestUser(t, db, "alice@example.com")
+	until := time.Now().Add(time.Hour)
+
+	for i := 1; i <= 2; i++ {
+		if _, err := repo.IncrementFailedLogins(ctx, nil, user.ID.String()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	locked, err := repo.LockUser(ctx, nil, user.ID.String(), 3, until)
+	if err != nil || locked {
+		t.Fatalf("LockUser below threshold = %t, %v; want false", locked, err)
+	}
+
+	updated, err := repo.IncrementFailedLogins(ctx, nil, user.ID.String())
+	if err != nil || updated.FailedLoginAttempts != 3 {
+		t.Fatalf("IncrementFailedLogins = %d, %v; want 3", updated.FailedLoginAttempts, err)
+	}
+
+	locked, err = repo.LockUser(ctx, nil, user.ID.String(), 3, until)
+	if err != nil || !locked {
+		t.Fatalf("LockUser at threshold = %t, %v; want true", locked, err)
+	}
+
+	// A concurrent failure that saw the same count must not lock again.
+	locked, err = repo.LockUser(ctx, nil, user.ID.String(), 3, until.Add(time.Hour))
+	if err != nil || locked {
+		t.Fatalf("second LockUser = %t, %v; want false", locked, err)
+	}
+
+	got, _, _ := repo.GetUserByID(ctx, nil, user.ID.String())
+	if got.FailedLoginAttempts != 0 || got.LockoutCount != 1 || got.LockedUntil == nil || !got.LockedUntil.Equal(until) {
+		t.Errorf("after lock: attempts %d, lockouts %d, until %v; want 0, 1, %v", got.FailedLoginAttempts, got.LockoutCount, got.LockedUntil, until)
+	}
+}
@@ -2,12 +2,17 @@ package user
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
 	"github.com/mferdian/Go-GraphQL/constants"
 	"github.com/mferdian/Go-GraphQL/helpers"
 	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/mailer"
+	"github.com/mferdian/Go-GraphQL/oidc"
+	"github.com/mferdian/Go-GraphQL/policy"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
 )
 
 type (
@@ -19,20 +24,69 @@ type (
 		GetuserByID(ctx context.Context, userID string) (UserResponse, error)
 		GetAllUser(ctx context.Context, search string) ([]UserResponse, error)
 		GetAllUserWithPagination(ctx context.Context, req UserPaginationRequest) (UserPaginationResponse, error)
+		ExportUsers(ctx context.Context, req UserPaginationRequest, fn func(user UserResponse) error) error
 		UpdateUser(ctx context.Context, req UpdateUserRequest) (UserResponse, error)
 		DeleteUser(ctx context.Context, req DeleteUserRequest) (UserResponse, error)
+
+		GetTrashedUsers(ctx context.Context, req UserPaginationRequest) (UserPaginationResponse, error)
+		RestoreUser(ctx context.Context, req RestoreUserRequest) (UserResponse, error)
+		PurgeUser(ctx context.Context, req PurgeUserRequest) (UserResponse, error)
+		PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error)
+
+		UnlockUser(ctx context.Context, req UnlockUserRequest) (UserResponse, error)
+
+		VerifyEmail(ctx context.Context, req VerifyEmailRequest) (UserResponse, error)
+		ResendVerificationEmail(ctx context.Context, req ResendVerificationRequest) error
+
+		ForgotPassword(ctx context.Context, req ForgotPasswordRequest)
+		ResetPassword(ctx context.Context, req ResetPasswordRequest) error
+		TokensRevoked(userID, sessionID string, issuedAt time.Time) bool
+
+		GetSessions(ctx context.Context, userID string) ([]SessionResponse, error)
+		RevokeSession(ctx context.Context, req RevokeSessionRequest) error
+		RevokeOtherSessions(ctx context.Context, userID string) (RevokeSessionsResponse, error)
+
+		StartOIDCLogin(ctx context.Context, provider string) (OIDCLoginResponse, error)
+		FinishOIDCLogin(ctx context.Context, req OIDCCallbackRequest) (LoginResponse, error)
+
+		VerifyMFA(ctx context.Context, req MFALoginRequest) (LoginResponse, error)
+		StartMFAEnrollment(ctx context.Context, req MFAEnrollmentRequest) (TOTPEnrollmentResponse, error)
+		ConfirmMFAEnrollment(ctx context.Context, req MFALoginRequest) (LoginResponse, error)
+		EnrollTOTP(ctx context.Context, userID string) (TOTPEnrollmentResponse, error)
+		ConfirmTOTP(ctx context.Context, userID string, req TOTPCodeRequest) (RecoveryCodesResponse, error)
+		DisableTOTP(ctx context.Context, userID string, req TOTPCodeRequest) error
+		GetMFAPolicies(ctx context.Context) ([]MFAPolicyResponse, error)
+		SetMFAPolicy(ctx context.Context, req MFAPolicyRequest) (MFAPolicyResponse, error)
 	}
 
 	UserService struct {
-		userRepo   IUserRepository
-		jwtService jwt.InterfaceJWTService
+		userRepo      IUserRepository
+		jwtService    jwt.InterfaceJWTService
+		loginThrottle *ratelimit.LoginThrottle
+		lockout       ratelimit.AccountLockout
+		mailer        mailer.Mailer
+		verification  emailVerification
+		passwordReset passwordResetConfig
+		mfa           mfaConfig
+		oidc          oidcConfig
+
+		passwordPolicy helpers.PasswordPolicy
 	}
 )
 
-func NewUserService(userRepo IUserRepository, jwtService jwt.InterfaceJWTService) *UserService {
+func NewUserService(userRepo IUserRepository, jwtService jwt.InterfaceJWTService, mail mailer.Mailer, oidcProviders oidc.Providers) *UserService {
 	return &UserService{
-		userRepo:   userRepo,
-		jwtService: jwtService,
+		userRepo:      userRepo,
+		jwtService:    jwtService,
+		loginThrottle: ratelimit.NewLoginThrottle(),
+		lockout:       ratelimit.NewAccountLockout(),
+		mailer:        mail,
+		verification:  newEmailVerification(),
+		passwordReset: newPasswordResetConfig(),
+		mfa:           newMFAConfig(),
+		oidc:          newOIDCConfig(oidcProviders),
+
+		passwordPolicy: helpers.NewPasswordPolicy(),
 	}
 }
 
@@ -53,9 +107,9 @@ func (us *UserService) Register(ctx context.Context, req RegisterUserRequest) (R
 		return RegisterUserResponse{}, constants.ErrEmailAlreadyExists
 	}
 
-	if len(req.Password) < 8 {
-		logging.Log.Warn(constants.MESSAGE_FAILED_REGISTER + ": password too short")
-		return RegisterUserResponse{}, constants.ErrInvalidPassword
+	if err := us.passwordPolicy.Validate(req.Password, req.Email); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_REGISTER)
+		return RegisterUserResponse{}, err
 	}
 
 	user := User{
@@ -64,6 +118,7 @@ func (us *UserService) Register(ctx context.Context, req RegisterUserRequest) (R
 		Email:    req.Email,
 		Password: req.Password,
 		Role:     constants.ENUM_ROLE_USER,
+		Version:  1,
 	}
 
 	err = us.userRepo.Register(ctx, nil, user)
@@ -74,6 +129,8 @@ func (us *UserService) Register(ctx context.Context, req RegisterUserRequest) (R
 
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_REGISTER+": %s", user.Email)
 
+	us.sendVerificationEmail(ctx, user)
+
 	return RegisterUserResponse{
 		ID:    user.ID,
 		Name:  user.Name,
@@ -81,24 +138,99 @@ func (us *UserService) Register(ctx context.Context, req RegisterUserRequest) (R
 	}, nil
 }
 
+// Login is throttled per account and per client IP: after too many recent
+// failures it returns a *helpers.TooManyRequestsError without checking the
+// password, whether or not the account exists. Accounts are also locked for
+// a growing period after repeated wrong passwords, see recordFailedLogin.
+// Users with TOTP enabled, or whose role requires MFA, get an MFA token
+// instead of the token pair, see mfaChallenge.
 func (us *UserService) Login(ctx context.Context, req LoginUserRequest) (LoginResponse, error) {
+	ip := helpers.GetClientIP(ctx)
+	if retryAfter, blocked := us.loginThrottle.Check(req.Email, ip); blocked {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_LOGIN_USER+": throttled %s from %s", req.Email, ip)
+		return LoginResponse{}, &helpers.TooManyRequestsError{RetryAfter: retryAfter, Err: constants.ErrTooManyLoginAttempts}
+	}
+
 	user, found, err := us.userRepo.GetUserByEmail(ctx, nil, req.Email)
 	if err != nil || !found {
 		logging.Log.Warn(constants.MESSAGE_FAILED_LOGIN_USER + ": email not found")
+		us.loginThrottle.Fail(req.Email, ip)
 		return LoginResponse{}, constants.ErrInvalidLoginCredential
 	}
 
+	if user.ServiceAccount {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_LOGIN_USER+": %s is a service account", user.Email)
+		us.loginThrottle.Fail(req.Email, ip)
+		return LoginResponse{}, constants.ErrInvalidLoginCredential
+	}
+
+	if lockedUntil := activeLock(user); lockedUntil != nil {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_LOGIN_USER+": %s locked until %s", user.Email, lockedUntil.Format(time.RFC3339))
+		return LoginResponse{}, &helpers.TooManyRequestsError{RetryAfter: time.Until(*lockedUntil), Err: constants.ErrAccountLocked}
+	}
+
 	if ok, err := helpers.CheckPassword(user.Password, []byte(req.Password)); !ok || err != nil {
 		logging.Log.Warn(constants.MESSAGE_FAILED_LOGIN_USER + ": password mismatch")
-		return LoginResponse{}, constants.ErrInvalidLoginCredential
+		us.loginThrottle.Fail(req.Email, ip)
+		return LoginResponse{}, us.recordFailedLogin(ctx, user)
+	}
+
+	if helpers.NeedsRehash(user.Password) {
+		us.rehashPassword(ctx, user, req.Password)
 	}
 
-	accessToken, refreshToken, err := us.jwtService.GenerateToken(user.ID.String(), user.Role)
+	if us.verification.required && user.EmailVerifiedAt == nil {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_LOGIN_USER+": %s not verified", user.Email)
+		return LoginResponse{}, constants.ErrEmailNotVerified
+	}
+
+	if user.FailedLoginAttempts > 0 || user.LockoutCount > 0 {
+		if err := us.userRepo.ResetFailedLogins(ctx, nil, user.ID.String()); err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed reset login attempts")
+		}
+	}
+
+	if challenge, required, err := us.mfaChallenge(ctx, user); err != nil || required {
+		return challenge, err
+	}
+
+	return us.issueTokens(ctx, user)
+}
+
+// rehashPassword replaces a bcrypt hash, or an argon2id hash with outdated
+// parameters, now that the plain password is at hand. Failing only means it
+// is tried again at the next login.
+func (us *UserService) rehashPassword(ctx context.Context, user User, password string) {
+	hashed, err := helpers.HashPassword(password)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed rehash password")
+		return
+	}
+
+	if err := us.userRepo.RehashPassword(ctx, nil, user.ID.String(), user.Password, hashed); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed rehash password")
+		return
+	}
+
+	logging.Log.Infof("rehashed password of %s", user.Email)
+}
+
+// issueTokens signs user in once every factor has been checked, starting a
+// new session.
+func (us *UserService) issueTokens(ctx context.Context, user User) (LoginResponse, error) {
+	session, err := us.startSession(ctx, user)
+	if err != nil {
+		return LoginResponse{}, err
+	}
+
+	accessToken, refreshToken, err := us.jwtService.GenerateToken(user.ID.String(), user.Role, session.ID.String())
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed generate token")
 		return LoginResponse{}, constants.ErrGenerateAccessToken
 	}
 
+	us.loginThrottle.Succeed(user.Email)
+
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_LOGIN_USER+": %s", user.Email)
 
 	return LoginResponse{
@@ -124,11 +256,12 @@ func (us *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (U
 		return UserResponse{}, constants.ErrEmailAlreadyExists
 	}
 
-	if len(req.Password) < 8 {
-		logging.Log.Warn(constants.MESSAGE_FAILED_CREATE_USER + ": password too short")
-		return UserResponse{}, constants.ErrInvalidPassword
+	if err := us.passwordPolicy.Validate(req.Password, req.Email); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_CREATE_USER)
+		return UserResponse{}, err
 	}
 
+	now := time.Now()
 	user := User{
 		ID:          uuid.New(),
 		Name:        req.Name,
@@ -137,6 +270,12 @@ func (us *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (U
 		PhoneNumber: req.PhoneNumber,
 		Address:     req.Address,
 		Role:        constants.ENUM_ROLE_ADMIN,
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+
+		// Accounts created by an admin are vouched for and skip verification.
+		EmailVerifiedAt: &now,
 	}
 
 	err = us.userRepo.CreateUser(ctx, nil, user)
@@ -153,6 +292,8 @@ func (us *UserService) CreateUser(ctx context.Context, req CreateUserRequest) (U
 		Email:       user.Email,
 		PhoneNumber: user.PhoneNumber,
 		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
 	}, nil
 }
 
@@ -171,6 +312,9 @@ func (us *UserService) GetAllUser(ctx context.Context, search string) ([]UserRes
 			Email:       user.Email,
 			PhoneNumber: user.PhoneNumber,
 			Address:     user.Address,
+			Version:     user.Version,
+			UpdatedAt:   user.UpdatedAt,
+			LockedUntil: activeLock(user),
 		}
 
 		datas = append(datas, data)
@@ -195,6 +339,9 @@ func (us *UserService) GetAllUserWithPagination(ctx context.Context, req UserPag
 			Email:       user.Email,
 			PhoneNumber: user.PhoneNumber,
 			Address:     user.Address,
+			Version:     user.Version,
+			UpdatedAt:   user.UpdatedAt,
+			LockedUntil: activeLock(user),
 		})
 	}
 
@@ -209,12 +356,48 @@ func (us *UserService) GetAllUserWithPagination(ctx context.Context, req UserPag
 	}, nil
 }
 
+// ExportUsers hands every user matching the list filters to fn, one at a
+// time, without loading them all. Errors returned by fn are passed back
+// unchanged.
+func (us *UserService) ExportUsers(ctx context.Context, req UserPaginationRequest, fn func(user UserResponse) error) error {
+	var writeErr error
+	count := 0
+	err := us.userRepo.StreamUsers(ctx, nil, req, func(user User) error {
+		writeErr = fn(UserResponse{
+			ID:          user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			PhoneNumber: user.PhoneNumber,
+			Address:     user.Address,
+			Version:     user.Version,
+			UpdatedAt:   user.UpdatedAt,
+		})
+		count++
+		return writeErr
+	})
+	if writeErr != nil {
+		return writeErr
+	}
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_EXPORT_USER)
+		return constants.ErrExportUser
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_EXPORT_USER+": %d rows", count)
+	return nil
+}
+
 func (us *UserService) GetuserByID(ctx context.Context, userID string) (UserResponse, error) {
 	if _, err := uuid.Parse(userID); err != nil {
 		logging.Log.Warn(constants.MESSAGE_FAILED_GET_DETAIL_USER + ": invalid UUID")
 		return UserResponse{}, constants.ErrInvalidUUID
 	}
 
+	if err := policy.SelfOrAdmin(ctx, userID); err != nil {
+		logging.Log.WithError(err).WithField("id", userID).Warn(constants.MESSAGE_FAILED_GET_DETAIL_USER)
+		return UserResponse{}, err
+	}
+
 	user, _, err := us.userRepo.GetUserByID(ctx, nil, userID)
 	if err != nil {
 		logging.Log.WithError(err).WithField("id", userID).Error(constants.MESSAGE_FAILED_GET_DETAIL_USER)
@@ -223,22 +406,39 @@ func (us *UserService) GetuserByID(ctx context.Context, userID string) (UserResp
 
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_GET_DETAIL_USER+": %s", userID)
 
-	return UserResponse{
+	res := UserResponse{
 		ID:          user.ID,
 		Name:        user.Name,
 		Email:       user.Email,
 		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
 		PhoneNumber: user.PhoneNumber,
-	}, nil
+	}
+	if policy.IsAdmin(ctx) {
+		res.LockedUntil = activeLock(user)
+	}
+
+	return res, nil
 }
 
 func (us *UserService) UpdateUser(ctx context.Context, req UpdateUserRequest) (UserResponse, error) {
+	if err := policy.SelfOrAdmin(ctx, req.ID); err != nil {
+		logging.Log.WithError(err).WithField("id", req.ID).Warn(constants.MESSAGE_FAILED_UPDATE_USER)
+		return UserResponse{}, err
+	}
+
 	user, _, err := us.userRepo.GetUserByID(ctx, nil, req.ID)
 	if err != nil {
 		logging.Log.WithError(err).WithField("id", req.ID).Error(constants.MESSAGE_FAILED_UPDATE_USER)
 		return UserResponse{}, constants.ErrGetUserByID
 	}
 
+	if req.Version != nil && *req.Version != user.Version {
+		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_USER + ": stale version")
+		return UserResponse{}, &helpers.VersionConflictError{CurrentVersion: user.Version}
+	}
+
 	if req.Name != nil && len(*req.Name) < 5 {
 		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_USER + ": invalid name")
 		return UserResponse{}, constants.ErrInvalidName
@@ -246,6 +446,7 @@ func (us *UserService) UpdateUser(ctx context.Context, req UpdateUserRequest) (U
 		user.Name = *req.Name
 	}
 
+	emailChanged := false
 	if req.Email != nil {
 		if !helpers.IsValidEmail(*req.Email) {
 			logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_USER + ": invalid email format")
@@ -258,10 +459,20 @@ func (us *UserService) UpdateUser(ctx context.Context, req UpdateUserRequest) (U
 			return UserResponse{}, constants.ErrEmailAlreadyExists
 		}
 
-		user.Email = *req.Email
+		if *req.Email != user.Email {
+			// The new address has not been proven yet.
+			user.Email = *req.Email
+			user.EmailVerifiedAt = nil
+			emailChanged = true
+		}
 	}
 
 	if req.Password != nil {
+		if err := us.passwordPolicy.Validate(*req.Password, user.Email); err != nil {
+			logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_UPDATE_USER)
+			return UserResponse{}, err
+		}
+
 		if ok, _ := helpers.CheckPassword(user.Password, []byte(*req.Password)); ok {
 			logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_USER + ": new password same as old")
 			return UserResponse{}, constants.ErrPasswordSame
@@ -283,12 +494,30 @@ func (us *UserService) UpdateUser(ctx context.Context, req UpdateUserRequest) (U
 		user.Address = *req.Address
 	}
 
-	err = us.userRepo.UpdateUser(ctx, nil, user)
+	user.UpdatedAt = time.Now()
+	updated, err := us.userRepo.UpdateUser(ctx, nil, user)
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_UPDATE_USER)
 		return UserResponse{}, constants.ErrUpdateUser
 	}
 
+	if !updated {
+		// Someone else updated the user between our read and write.
+		current, found, err := us.userRepo.GetUserByID(ctx, nil, req.ID)
+		if err != nil || !found {
+			logging.Log.WithError(err).WithField("id", req.ID).Error(constants.MESSAGE_FAILED_UPDATE_USER)
+			return UserResponse{}, constants.ErrGetUserByID
+		}
+
+		logging.Log.Warn(constants.MESSAGE_FAILED_UPDATE_USER + ": concurrent update")
+		return UserResponse{}, &helpers.VersionConflictError{CurrentVersion: current.Version}
+	}
+	user.Version++
+
+	if emailChanged {
+		us.sendVerificationEmail(ctx, user)
+	}
+
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_UPDATE_USER+": %s", user.ID)
 
 	return UserResponse{
@@ -297,10 +526,17 @@ func (us *UserService) UpdateUser(ctx context.Context, req UpdateUserRequest) (U
 		Email:       user.Email,
 		PhoneNumber: user.PhoneNumber,
 		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
 	}, nil
 }
 
 func (us *UserService) DeleteUser(ctx context.Context, req DeleteUserRequest) (UserResponse, error) {
+	if err := policy.SelfOrAdmin(ctx, req.UserID); err != nil {
+		logging.Log.WithError(err).WithField("id", req.UserID).Warn(constants.MESSAGE_FAILED_DELETE_USER)
+		return UserResponse{}, err
+	}
+
 	user, _, err := us.userRepo.GetUserByID(ctx, nil, req.UserID)
 	if err != nil {
 		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_DELETE_USER)
@@ -315,11 +551,127 @@ func (us *UserService) DeleteUser(ctx context.Context, req DeleteUserRequest) (U
 
 	logging.Log.Infof(constants.MESSAGE_SUCCESS_DELETE_USER+": %s", req.UserID)
 
+	// The user is only moved to the trash; it can be restored until purged.
+	deletedAt := time.Now()
+
+	return UserResponse{
+		ID:          user.ID,
+		Name:        user.Name,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
+		DeletedAt:   &deletedAt,
+	}, nil
+}
+
+func (us *UserService) GetTrashedUsers(ctx context.Context, req UserPaginationRequest) (UserPaginationResponse, error) {
+	dataWithPaginate, err := us.userRepo.GetTrashedUsers(ctx, nil, req)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_TRASHED_USER)
+		return UserPaginationResponse{}, constants.ErrGetTrashedUser
+	}
+
+	datas := make([]UserResponse, 0, len(dataWithPaginate.Users))
+	for _, user := range dataWithPaginate.Users {
+		deletedAt := user.DeletedAt.Time
+		datas = append(datas, UserResponse{
+			ID:          user.ID,
+			Name:        user.Name,
+			Email:       user.Email,
+			PhoneNumber: user.PhoneNumber,
+			Address:     user.Address,
+			Version:     user.Version,
+			UpdatedAt:   user.UpdatedAt,
+			DeletedAt:   &deletedAt,
+		})
+	}
+
+	return UserPaginationResponse{
+		Data: datas,
+		PaginationResponse: PaginationResponse{
+			Page:    dataWithPaginate.Page,
+			PerPage: dataWithPaginate.PerPage,
+			MaxPage: dataWithPaginate.MaxPage,
+			Count:   dataWithPaginate.Count,
+		},
+	}, nil
+}
+
+func (us *UserService) RestoreUser(ctx context.Context, req RestoreUserRequest) (UserResponse, error) {
+	if _, err := uuid.Parse(req.UserID); err != nil {
+		return UserResponse{}, constants.ErrInvalidUUID
+	}
+
+	user, _, err := us.userRepo.GetTrashedUserByID(ctx, nil, req.UserID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("id", req.UserID).Warn(constants.MESSAGE_FAILED_RESTORE_USER)
+		return UserResponse{}, constants.ErrTrashedRecordNotFound
+	}
+
+	// Email is only unique among active users, so another account may have
+	// claimed it while this one was in the trash.
+	if existing, found, err := us.userRepo.GetUserByEmail(ctx, nil, user.Email); err == nil && found && existing.ID != user.ID {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_RESTORE_USER+": email %s already in use", user.Email)
+		return UserResponse{}, constants.ErrRestoreEmailConflict
+	}
+
+	if err := us.userRepo.RestoreUserByID(ctx, nil, req.UserID); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_RESTORE_USER)
+		return UserResponse{}, constants.ErrRestoreUser
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_RESTORE_USER+": %s", req.UserID)
+
 	return UserResponse{
 		ID:          user.ID,
 		Name:        user.Name,
 		Email:       user.Email,
 		PhoneNumber: user.PhoneNumber,
 		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
 	}, nil
 }
+
+func (us *UserService) PurgeUser(ctx context.Context, req PurgeUserRequest) (UserResponse, error) {
+	if _, err := uuid.Parse(req.UserID); err != nil {
+		return UserResponse{}, constants.ErrInvalidUUID
+	}
+
+	user, _, err := us.userRepo.GetTrashedUserByID(ctx, nil, req.UserID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("id", req.UserID).Warn(constants.MESSAGE_FAILED_PURGE_USER)
+		return UserResponse{}, constants.ErrTrashedRecordNotFound
+	}
+
+	if err := us.userRepo.PurgeUserByID(ctx, nil, req.UserID); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_PURGE_USER)
+		return UserResponse{}, constants.ErrPurgeUser
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_PURGE_USER+": %s", req.UserID)
+
+	deletedAt := user.DeletedAt.Time
+	return UserResponse{
+		ID:          user.ID,
+		Name:        user.Name,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
+		DeletedAt:   &deletedAt,
+	}, nil
+}
+
+func (us *UserService) PurgeDeletedUsers(ctx context.Context, before time.Time) (int64, error) {
+	count, err := us.userRepo.PurgeUsersDeletedBefore(ctx, nil, before)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_PURGE_USER)
+		return 0, constants.ErrPurgeUser
+	}
+
+	return count, nil
+}
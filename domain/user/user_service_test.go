@@ -0,0 +1,58 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+)
+
+func TestUpdateUserEmailClearsVerification(t *testing.T) {
+	db := newTestDB(t)
+	user := createTestUser(t, db, "alice@example.com")
+	now := time.Now()
+	db.Model(&user).Update("email_verified_at", &now)
+
+	mail := newRecordingMailer()
+	us := &UserService{
+		userRepo:     NewUserRepository(db),
+		mailer:       mail,
+		verification: emailVerification{secret: []byte("secret"), ttl: time.Hour, url: "http://localhost/verify"},
+	}
+	ctx := helpers.WithActor(context.Background(), user.ID.String(), constants.ENUM_ROLE_USER)
+
+	name := "Alice Example"
+	if _, err := us.UpdateUser(ctx, UpdateUserRequest{ID: user.ID.String(), Name: &name, Email: &user.Email}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if got, _, _ := us.userRepo.GetUserByID(ctx, nil, user.ID.String()); got.EmailVerifiedAt == nil {
+		t.Error("verification cleared without an email change")
+	}
+
+	email := "alice@example.org"
+	if _, err := us.UpdateUser(ctx, UpdateUserRequest{ID: user.ID.String(), Email: &email}); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	got, _, _ := us.userRepo.GetUserByID(ctx, nil, user.ID.String())
+	if got.Email != email || got.EmailVerifiedAt != nil {
+		t.Errorf("email = %s, verified at %v; want %s, unverified", got.Email, got.EmailVerifiedAt, email)
+	}
+
+	select {
+	case msg := <-mail.sent:
+		if msg.To != email || !strings.Contains(msg.Body, "http://localhost/verify?token=") {
+			t.Errorf("sent %+v, want a verification link to %s", msg, email)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no verification email sent")
+	}
+
+	select {
+	case msg := <-mail.sent:
+		t.Errorf("unexpected email to %s", msg.To)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
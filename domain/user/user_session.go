@@ -0,0 +1,131 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+// Activity on a session is recorded at most once per sessionTouchInterval.
+const sessionTouchInterval = time.Minute
+
+// startSession records a sign in from the client of ctx. It lasts as long
+// as the refresh token issued with it.
+func (us *UserService) startSession(ctx context.Context, user User) (Session, error) {
+	now := time.Now()
+	session := Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		UserAgent:  helpers.GetUserAgent(ctx),
+		IP:         helpers.GetClientIP(ctx),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(jwt.RefreshTokenTTL),
+	}
+
+	if err := us.userRepo.CreateSession(ctx, nil, session); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_LOGIN_USER + ": failed create session")
+		return Session{}, constants.ErrCreateSession
+	}
+
+	return session, nil
+}
+
+// sessionActive reports whether the user's session may still be used, and
+// records that it was. Tokens issued before sessions existed carry none and
+// are not accepted.
+func (us *UserService) sessionActive(userID, sessionID string) bool {
+	if sessionID == "" {
+		return false
+	}
+
+	ctx := context.Background()
+	session, found, err := us.userRepo.GetSession(ctx, nil, sessionID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("session_id", sessionID).Error(constants.MESSAGE_FAILED_GET_SESSION)
+		return false
+	}
+
+	now := time.Now()
+	if !found || session.UserID.String() != userID || session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return false
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := us.userRepo.TouchSession(ctx, nil, sessionID, now, now.Add(-sessionTouchInterval)); err != nil {
+			logging.Log.WithError(err).WithField("session_id", sessionID).Warn("failed record session activity")
+		}
+	}
+
+	return true
+}
+
+// GetSessions lists where the user is signed in.
+func (us *UserService) GetSessions(ctx context.Context, userID string) ([]SessionResponse, error) {
+	sessions, err := us.userRepo.GetActiveSessions(ctx, nil, userID, time.Now())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_SESSION)
+		return nil, constants.ErrGetSession
+	}
+
+	current := helpers.GetSessionID(ctx)
+
+	datas := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		datas = append(datas, SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			Current:    session.ID.String() == current,
+		})
+	}
+
+	return datas, nil
+}
+
+// RevokeSession signs the user out of one session, which may be the
+// current one. Its tokens stop working right away.
+func (us *UserService) RevokeSession(ctx context.Context, req RevokeSessionRequest) error {
+	if _, err := uuid.Parse(req.SessionID); err != nil {
+		return constants.ErrInvalidUUID
+	}
+
+	revoked, err := us.userRepo.RevokeSession(ctx, nil, req.UserID, req.SessionID, time.Now())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_REVOKE_SESSION)
+		return constants.ErrRevokeSession
+	}
+	if !revoked {
+		return constants.ErrSessionNotFound
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_REVOKE_SESSION+": %s of %s", req.SessionID, req.UserID)
+
+	return nil
+}
+
+// RevokeOtherSessions signs the user out everywhere but the session the
+// request was made with.
+func (us *UserService) RevokeOtherSessions(ctx context.Context, userID string) (RevokeSessionsResponse, error) {
+	current := helpers.GetSessionID(ctx)
+	if current == "" {
+		return RevokeSessionsResponse{}, constants.ErrGetIDFromToken
+	}
+
+	revoked, err := us.userRepo.RevokeOtherSessions(ctx, nil, userID, current, time.Now())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_REVOKE_SESSION)
+		return RevokeSessionsResponse{}, constants.ErrRevokeSession
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_REVOKE_SESSION+": %d other sessions of %s", revoked, userID)
+
+	return RevokeSessionsResponse{Revoked: revoked}, nil
+}
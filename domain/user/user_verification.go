@@ -0,0 +1,172 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/mailer"
+)
+
+const (
+	TOKEN_PURPOSE_VERIFY_EMAIL = "verify_email"
+
+	defaultEmailVerificationTTL = 24 * time.Hour
+	defaultEmailVerificationURL = "http://localhost:8000/verify-email"
+
+	// Emails are sent in the background so a slow mail server does not hold
+	// up requests, nor reveal through timing whether an account exists.
+	sendEmailTimeout = 30 * time.Second
+)
+
+type emailVerification struct {
+	secret   []byte
+	ttl      time.Duration
+	url      string
+	required bool
+}
+
+// newEmailVerification signs tokens with EMAIL_VERIFICATION_SECRET, falling
+// back to JWT_SECRET; without either no token is issued or accepted. Tokens
+// are valid for EMAIL_VERIFICATION_TTL (default 24h). Links point at
+// EMAIL_VERIFICATION_URL with the token in ?token=. Login is only refused
+// for unverified accounts when REQUIRE_EMAIL_VERIFICATION is "true".
+func newEmailVerification() emailVerification {
+	secret := helpers.SecretEnv("EMAIL_VERIFICATION_SECRET", "JWT_SECRET")
+	if len(secret) == 0 {
+		logging.Log.Error("neither EMAIL_VERIFICATION_SECRET nor JWT_SECRET is set, email verification is disabled")
+	}
+
+	ttl := helpers.DurationEnv("EMAIL_VERIFICATION_TTL", defaultEmailVerificationTTL)
+
+	link := os.Getenv("EMAIL_VERIFICATION_URL")
+	if link == "" {
+		link = defaultEmailVerificationURL
+	}
+
+	return emailVerification{
+		secret:   secret,
+		ttl:      ttl,
+		url:      link,
+		required: os.Getenv("REQUIRE_EMAIL_VERIFICATION") == "true",
+	}
+}
+
+// VerifyEmail marks the account in the token as verified. Tokens are bound
+// to the address they were sent to and stop working once it is verified.
+func (us *UserService) VerifyEmail(ctx context.Context, req VerifyEmailRequest) (UserResponse, error) {
+	token, err := helpers.VerifySignedToken(us.verification.secret, TOKEN_PURPOSE_VERIFY_EMAIL, req.Token)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_VERIFY_EMAIL)
+		return UserResponse{}, err
+	}
+
+	verified, err := us.userRepo.MarkEmailVerified(ctx, nil, token.Subject, token.Binding, time.Now())
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_VERIFY_EMAIL)
+		return UserResponse{}, constants.ErrVerifyEmail
+	}
+
+	user, found, err := us.userRepo.GetUserByID(ctx, nil, token.Subject)
+	if err != nil || !found || user.Email != token.Binding {
+		logging.Log.Warnf(constants.MESSAGE_FAILED_VERIFY_EMAIL+": %s no longer has address %s", token.Subject, token.Binding)
+		return UserResponse{}, constants.ErrTokenInvalid
+	}
+
+	if !verified {
+		return UserResponse{}, constants.ErrEmailAlreadyVerified
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_VERIFY_EMAIL+": %s", user.Email)
+
+	return UserResponse{
+		ID:          user.ID,
+		Name:        user.Name,
+		Email:       user.Email,
+		PhoneNumber: user.PhoneNumber,
+		Address:     user.Address,
+		Version:     user.Version,
+		UpdatedAt:   user.UpdatedAt,
+	}, nil
+}
+
+// ResendVerificationEmail sends a fresh link to an unverified account. It
+// succeeds whether or not such an account exists so it cannot be used to
+// probe for registered addresses.
+func (us *UserService) ResendVerificationEmail(ctx context.Context, req ResendVerificationRequest) error {
+	if !helpers.IsValidEmail(req.Email) {
+		return constants.ErrInvalidEmail
+	}
+
+	user, found, err := us.userRepo.GetUserByEmail(ctx, nil, req.Email)
+	if err != nil || !found || user.EmailVerifiedAt != nil {
+		logging.Log.Infof(constants.MESSAGE_FAILED_RESEND_VERIFICATION+": no unverified account for %s", req.Email)
+		return nil
+	}
+
+	us.sendVerificationEmail(ctx, user)
+	return nil
+}
+
+func (us *UserService) sendVerificationEmail(ctx context.Context, user User) {
+	token, err := helpers.SignToken(us.verification.secret, helpers.SignedToken{
+		Purpose:   TOKEN_PURPOSE_VERIFY_EMAIL,
+		Subject:   user.ID.String(),
+		Binding:   user.Email,
+		ExpiresAt: time.Now().Add(us.verification.ttl).Unix(),
+	})
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_SEND_EMAIL)
+		return
+	}
+
+	us.sendEmail(ctx, mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %s. If you did not create an account, you can ignore this email.\n",
+			user.Name, withToken(us.verification.url, token), humanDuration(us.verification.ttl)),
+	})
+}
+
+// sendEmail delivers msg in the background, detached from the request.
+func (us *UserService) sendEmail(ctx context.Context, msg mailer.Message) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), sendEmailTimeout)
+	go func() {
+		defer cancel()
+		if err := us.mailer.Send(ctx, msg); err != nil {
+			logging.Log.WithError(err).Errorf(constants.MESSAGE_FAILED_SEND_EMAIL+": %s", msg.To)
+		}
+	}()
+}
+
+func withToken(link, token string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link + "?token=" + url.QueryEscape(token)
+	}
+
+	query := u.Query()
+	query.Set("token", token)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// humanDuration formats d for email text, e.g. "24 hours" or "30 minutes".
+func humanDuration(d time.Duration) string {
+	if d >= time.Hour && d%time.Hour == 0 {
+		return plural(int(d/time.Hour), "hour")
+	}
+	return plural(max(int(d/time.Minute), 1), "minute")
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
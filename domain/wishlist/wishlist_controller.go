@@ -0,0 +1,97 @@
+package wishlist
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/utils"
+)
+
+type (
+	IWishlistController interface {
+		AddWishlist(ctx *gin.Context)
+		RemoveWishlist(ctx *gin.Context)
+		GetWishlist(ctx *gin.Context)
+		GetNotifications(ctx *gin.Context)
+	}
+
+	WishlistController struct {
+		wishlistService IWishlistService
+	}
+)
+
+func NewWishlistController(wishlistService IWishlistService) *WishlistController {
+	return &WishlistController{
+		wishlistService: wishlistService,
+	}
+}
+
+func (wc *WishlistController) AddWishlist(ctx *gin.Context) {
+	var payload AddWishlistRequest
+	if err := ctx.ShouldBindJSON(&payload); err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	result, err := wc.wishlistService.AddWishlist(ctx.Request.Context(), ctx.GetString("id"), payload)
+	if err != nil {
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_ADD_WISHLIST)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_ADD_WISHLIST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_ADD_WISHLIST, result)
+	ctx.JSON(http.StatusCreated, res)
+}
+
+func (wc *WishlistController) RemoveWishlist(ctx *gin.Context) {
+	payload := RemoveWishlistRequest{ProductID: ctx.Param("product_id")}
+
+	err := wc.wishlistService.RemoveWishlist(ctx.Request.Context(), ctx.GetString("id"), payload)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, constants.ErrWishlistNotFound) {
+			status = http.StatusNotFound
+		}
+
+		logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_REMOVE_WISHLIST)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_REMOVE_WISHLIST, err.Error(), nil)
+		ctx.JSON(status, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_REMOVE_WISHLIST, nil)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (wc *WishlistController) GetWishlist(ctx *gin.Context) {
+	result, err := wc.wishlistService.GetWishlist(ctx.Request.Context(), ctx.GetString("id"))
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_WISHLIST)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_WISHLIST, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_WISHLIST, result)
+	ctx.JSON(http.StatusOK, res)
+}
+
+func (wc *WishlistController) GetNotifications(ctx *gin.Context) {
+	result, err := wc.wishlistService.GetNotifications(ctx.Request.Context(), ctx.GetString("id"))
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_NOTIFICATION)
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_NOTIFICATION, err.Error(), nil)
+		ctx.JSON(http.StatusBadRequest, res)
+		return
+	}
+
+	res := utils.BuildResponseSuccess(constants.MESSAGE_SUCCESS_GET_NOTIFICATION, result)
+	ctx.JSON(http.StatusOK, res)
+}
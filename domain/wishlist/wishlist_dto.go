@@ -0,0 +1,37 @@
+package wishlist
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type (
+	AddWishlistRequest struct {
+		ProductID string `json:"product_id"`
+	}
+
+	RemoveWishlistRequest struct {
+		ProductID string `json:"-"`
+	}
+
+	WishlistResponse struct {
+		ProductID   uuid.UUID `json:"product_id"`
+		Name        string    `json:"name"`
+		Description string    `json:"description"`
+		Merk        string    `json:"merk"`
+		Material    string    `json:"material"`
+		Price       float32   `json:"price"`
+		Version     int64     `json:"version"`
+		AddedAt     time.Time `json:"added_at"`
+	}
+
+	NotificationResponse struct {
+		ID        uuid.UUID  `json:"id"`
+		ProductID uuid.UUID  `json:"product_id"`
+		OldPrice  float32    `json:"old_price"`
+		NewPrice  float32    `json:"new_price"`
+		ReadAt    *time.Time `json:"read_at"`
+		CreatedAt time.Time  `json:"created_at"`
+	}
+)
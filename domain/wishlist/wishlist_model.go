@@ -0,0 +1,28 @@
+package wishlist
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/domain/product"
+)
+
+type Wishlist struct {
+	ID        uuid.UUID       `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_wishlist_user_product" json:"user_id"`
+	ProductID uuid.UUID       `gorm:"type:uuid;not null;uniqueIndex:idx_wishlist_user_product;index" json:"product_id"`
+	Product   product.Product `gorm:"foreignKey:ProductID;constraint:OnDelete:CASCADE" json:"product"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type PriceDropNotification struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	ProductID uuid.UUID  `gorm:"type:uuid;not null" json:"product_id"`
+	OldPrice  float32    `json:"old_price"`
+	NewPrice  float32    `json:"new_price"`
+	ReadAt    *time.Time `json:"read_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,130 @@
+package wishlist
+
+import (
+	"context"
+
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type (
+	IWishlistRepository interface {
+		AddWishlist(ctx context.Context, tx *gorm.DB, wishlist Wishlist) error
+		RemoveWishlist(ctx context.Context, tx *gorm.DB, userID, productID string) (bool, error)
+		GetWishlistByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]Wishlist, error)
+		IsWishlisted(ctx context.Context, tx *gorm.DB, userID, productID string) (bool, error)
+		GetUserIDsByProductID(ctx context.Context, tx *gorm.DB, productID string) ([]string, error)
+		CreateNotifications(ctx context.Context, tx *gorm.DB, notifications []PriceDropNotification) error
+		GetNotificationsByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]PriceDropNotification, error)
+	}
+
+	WishlistRepository struct {
+		db *gorm.DB
+	}
+)
+
+func NewWishlistRepository(db *gorm.DB) *WishlistRepository {
+	return &WishlistRepository{
+		db: db,
+	}
+}
+
+func (wr *WishlistRepository) AddWishlist(ctx context.Context, tx *gorm.DB, wishlist Wishlist) error {
+	if tx == nil {
+		tx = wr.db
+	}
+
+	// Adding a product twice is a no-op rather than an error.
+	return tx.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Omit("Product").Create(&wishlist).Error
+}
+
+func (wr *WishlistRepository) RemoveWishlist(ctx context.Context, tx *gorm.DB, userID, productID string) (bool, error) {
+	if tx == nil {
+		tx = wr.db
+	}
+
+	result := tx.WithContext(ctx).Where("user_id = ? AND product_id = ?", userID, productID).Delete(&Wishlist{})
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// GetWishlistByUserID leaves out products in the trash; they come back when
+// the product is restored.
+func (wr *WishlistRepository) GetWishlistByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]Wishlist, error) {
+	if tx == nil {
+		tx = wr.db
+	}
+
+	var wishlists []Wishlist
+	if err := tx.WithContext(ctx).
+		InnerJoins("Product", tx.Session(&gorm.Session{NewDB: true}).Omit(product.SearchColumns...)).
+		Where("wishlists.user_id = ?", userID).
+		Order("wishlists.created_at DESC").
+		Find(&wishlists).Error; err != nil {
+		return nil, err
+	}
+
+	return wishlists, nil
+}
+
+func (wr *WishlistRepository) IsWishlisted(ctx context.Context, tx *gorm.DB, userID, productID string) (bool, error) {
+	if tx == nil {
+		tx = wr.db
+	}
+
+	var count int64
+	if err := tx.WithContext(ctx).Model(&Wishlist{}).
+		Where("user_id = ? AND product_id = ?", userID, productID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+func (wr *WishlistRepository) GetUserIDsByProductID(ctx context.Context, tx *gorm.DB, productID string) ([]string, error) {
+	if tx == nil {
+		tx = wr.db
+	}
+
+	var userIDs []string
+	if err := tx.WithContext(ctx).Model(&Wishlist{}).
+		Where("product_id = ?", productID).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return nil, err
+	}
+
+	return userIDs, nil
+}
+
+func (wr *WishlistRepository) CreateNotifications(ctx context.Context, tx *gorm.DB, notifications []PriceDropNotification) error {
+	if tx == nil {
+		tx = wr.db
+	}
+
+	if len(notifications) == 0 {
+		return nil
+	}
+
+	return tx.WithContext(ctx).Create(&notifications).Error
+}
+
+func (wr *WishlistRepository) GetNotificationsByUserID(ctx context.Context, tx *gorm.DB, userID string) ([]PriceDropNotification, error) {
+	if tx == nil {
+		tx = wr.db
+	}
+
+	var notifications []PriceDropNotification
+	if err := tx.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&notifications).Error; err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
@@ -0,0 +1,93 @@
+package wishlist
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := "file:" + strings.ReplaceAll(t.Name(), "/", "_") + "?mode=memory&cache=shared"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	if err := db.AutoMigrate(&product.Product{}, &Wishlist{}); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	sqlDB, _ := db.DB()
+	t.Cleanup(func() { sqlDB.Close() })
+
+	return db
+}
+
+// wishlistProducts creates a product per merk and puts each on the user's
+// wishlist.
+func wishlistProducts(t *testing.T, db *gorm.DB, userID uuid.UUID, merks ...string) []product.Product {
+	t.Helper()
+
+	repo := NewWishlistRepository(db)
+	var products []product.Product
+	for _, merk := range merks {
+		p := product.Product{ID: uuid.New(), Name: "Product " + merk, Merk: merk, Price: 10, Version: 1}
+		if err := db.Create(&p).Error; err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.AddWishlist(context.Background(), nil, Wishlist{ID: uuid.New(), UserID: userID, ProductID: p.ID, CreatedAt: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+		products = append(products, p)
+	}
+
+	return products
+}
+
+func TestGetWishlistByUserID(t *testing.T) {
+	db := newTestDB(t)
+	userID := uuid.New()
+	products := wishlistProducts(t, db, userID, "first")
+	wishlistProducts(t, db, uuid.New(), "other")
+
+	wishlists, err := NewWishlistRepository(db).GetWishlistByUserID(context.Background(), nil, userID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wishlists) != 1 || wishlists[0].Product.ID != products[0].ID || wishlists[0].Product.Name != products[0].Name {
+		t.Errorf("wishlist = %+v, want only %s with its product", wishlists, products[0].Name)
+	}
+}
+
+func TestGetWishlistByUserIDSkipsTrashedProducts(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewWishlistRepository(db)
+	ctx := context.Background()
+	userID := uuid.New()
+	products := wishlistProducts(t, db, userID, "kept", "trashed")
+
+	if err := db.Delete(&products[1]).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	wishlists, err := repo.GetWishlistByUserID(ctx, nil, userID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wishlists) != 1 || wishlists[0].Product.ID != products[0].ID {
+		t.Fatalf("wishlist = %+v, want only %s", wishlists, products[0].Name)
+	}
+
+	db.Unscoped().Model(&products[1]).Update("deleted_at", nil)
+	if wishlists, _ := repo.GetWishlistByUserID(ctx, nil, userID.String()); len(wishlists) != 2 {
+		t.Errorf("after restore: %d entries, want 2", len(wishlists))
+	}
+}
@@ -0,0 +1,188 @@
+package wishlist
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+type (
+	IWishlistService interface {
+		AddWishlist(ctx context.Context, userID string, req AddWishlistRequest) (WishlistResponse, error)
+		RemoveWishlist(ctx context.Context, userID string, req RemoveWishlistRequest) error
+		GetWishlist(ctx context.Context, userID string) ([]WishlistResponse, error)
+		IsWishlisted(ctx context.Context, userID, productID string) (bool, error)
+		GetNotifications(ctx context.Context, userID string) ([]NotificationResponse, error)
+	}
+
+	WishlistService struct {
+		wishlistRepo IWishlistRepository
+		productRepo  product.IProductRepository
+	}
+)
+
+func NewWishlistService(wishlistRepo IWishlistRepository, productRepo product.IProductRepository) *WishlistService {
+	return &WishlistService{
+		wishlistRepo: wishlistRepo,
+		productRepo:  productRepo,
+	}
+}
+
+func (ws *WishlistService) AddWishlist(ctx context.Context, userID string, req AddWishlistRequest) (WishlistResponse, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return WishlistResponse{}, constants.ErrGetIDFromToken
+	}
+
+	productUUID, err := uuid.Parse(req.ProductID)
+	if err != nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_ADD_WISHLIST + ": invalid UUID")
+		return WishlistResponse{}, constants.ErrInvalidUUID
+	}
+
+	p, _, err := ws.productRepo.GetProductByID(ctx, nil, req.ProductID)
+	if err != nil {
+		logging.Log.WithError(err).WithField("product_id", req.ProductID).Warn(constants.MESSAGE_FAILED_ADD_WISHLIST)
+		return WishlistResponse{}, constants.ErrGetProductByID
+	}
+
+	wishlist := Wishlist{
+		ID:        uuid.New(),
+		UserID:    userUUID,
+		ProductID: productUUID,
+	}
+
+	if err := ws.wishlistRepo.AddWishlist(ctx, nil, wishlist); err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_ADD_WISHLIST)
+		return WishlistResponse{}, constants.ErrAddWishlist
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_ADD_WISHLIST+": user %s product %s", userID, req.ProductID)
+
+	wishlist.Product = p
+	return toWishlistResponse(wishlist), nil
+}
+
+func (ws *WishlistService) RemoveWishlist(ctx context.Context, userID string, req RemoveWishlistRequest) error {
+	if _, err := uuid.Parse(req.ProductID); err != nil {
+		logging.Log.Warn(constants.MESSAGE_FAILED_REMOVE_WISHLIST + ": invalid UUID")
+		return constants.ErrInvalidUUID
+	}
+
+	removed, err := ws.wishlistRepo.RemoveWishlist(ctx, nil, userID, req.ProductID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_REMOVE_WISHLIST)
+		return constants.ErrRemoveWishlist
+	}
+
+	if !removed {
+		return constants.ErrWishlistNotFound
+	}
+
+	logging.Log.Infof(constants.MESSAGE_SUCCESS_REMOVE_WISHLIST+": user %s product %s", userID, req.ProductID)
+	return nil
+}
+
+func (ws *WishlistService) GetWishlist(ctx context.Context, userID string) ([]WishlistResponse, error) {
+	wishlists, err := ws.wishlistRepo.GetWishlistByUserID(ctx, nil, userID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_WISHLIST)
+		return nil, constants.ErrGetWishlist
+	}
+
+	datas := make([]WishlistResponse, 0, len(wishlists))
+	for _, wishlist := range wishlists {
+		datas = append(datas, toWishlistResponse(wishlist))
+	}
+
+	return datas, nil
+}
+
+func (ws *WishlistService) IsWishlisted(ctx context.Context, userID, productID string) (bool, error) {
+	if userID == "" {
+		return false, nil
+	}
+
+	found, err := ws.wishlistRepo.IsWishlisted(ctx, nil, userID, productID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_WISHLIST)
+		return false, constants.ErrGetWishlist
+	}
+
+	return found, nil
+}
+
+func (ws *WishlistService) GetNotifications(ctx context.Context, userID string) ([]NotificationResponse, error) {
+	notifications, err := ws.wishlistRepo.GetNotificationsByUserID(ctx, nil, userID)
+	if err != nil {
+		logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_GET_NOTIFICATION)
+		return nil, constants.ErrGetNotification
+	}
+
+	datas := make([]NotificationResponse, 0, len(notifications))
+	for _, n := range notifications {
+		datas = append(datas, NotificationResponse{
+			ID:        n.ID,
+			ProductID: n.ProductID,
+			OldPrice:  n.OldPrice,
+			NewPrice:  n.NewPrice,
+			ReadAt:    n.ReadAt,
+			CreatedAt: n.CreatedAt,
+		})
+	}
+
+	return datas, nil
+}
+
+// OnPriceChanged implements product.IPriceObserver and records a price drop
+// notification for every user who has the product on their wishlist.
+func (ws *WishlistService) OnPriceChanged(ctx context.Context, p product.Product, oldPrice float32) {
+	if p.Price >= oldPrice {
+		return
+	}
+
+	userIDs, err := ws.wishlistRepo.GetUserIDsByProductID(ctx, nil, p.ID.String())
+	if err != nil {
+		logging.Log.WithError(err).WithField("product_id", p.ID).Error(constants.MESSAGE_FAILED_CREATE_NOTIFICATION)
+		return
+	}
+
+	var notifications []PriceDropNotification
+	for _, userID := range userIDs {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			continue
+		}
+
+		notifications = append(notifications, PriceDropNotification{
+			ID:        uuid.New(),
+			UserID:    userUUID,
+			ProductID: p.ID,
+			OldPrice:  oldPrice,
+			NewPrice:  p.Price,
+		})
+	}
+
+	if err := ws.wishlistRepo.CreateNotifications(ctx, nil, notifications); err != nil {
+		logging.Log.WithError(err).WithField("product_id", p.ID).Error(constants.MESSAGE_FAILED_CREATE_NOTIFICATION)
+		return
+	}
+
+	logging.Log.Infof("price drop notifications recorded for product %s: %d user(s)", p.ID, len(notifications))
+}
+
+func toWishlistResponse(wishlist Wishlist) WishlistResponse {
+	return WishlistResponse{
+		ProductID:   wishlist.ProductID,
+		Name:        wishlist.Product.Name,
+		Description: wishlist.Product.Description,
+		Merk:        wishlist.Product.Merk,
+		Material:    wishlist.Product.Material,
+		Price:       wishlist.Product.Price,
+		Version:     wishlist.Product.Version,
+		AddedAt:     wishlist.CreatedAt,
+	}
+}
@@ -0,0 +1,139 @@
+package export
+
+import (
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+const (
+	FORMAT_CSV    = "csv"
+	FORMAT_XLSX   = "xlsx"
+	FORMAT_NDJSON = "ndjson"
+
+	// Rows written between flushes to the client.
+	flushEvery = 100
+)
+
+var contentTypes = map[string]string{
+	FORMAT_CSV:    "text/csv; charset=utf-8",
+	FORMAT_XLSX:   "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+	FORMAT_NDJSON: "application/x-ndjson",
+}
+
+var acceptedTypes = map[string]string{
+	"text/csv": FORMAT_CSV,
+	"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet": FORMAT_XLSX,
+	"application/x-ndjson": FORMAT_NDJSON,
+	"application/ndjson":   FORMAT_NDJSON,
+	"application/jsonl":    FORMAT_NDJSON,
+}
+
+// Writer encodes rows of an export. Values may be strings, numbers, bools,
+// time.Time, fmt.Stringer, pointers to those, or nil.
+type Writer interface {
+	WriteRow(values ...any) error
+	Flush() error
+	Close() error
+}
+
+// Negotiate picks the export format from an explicit ?format= value or else
+// from the Accept header, defaulting to CSV when the client accepts anything.
+func Negotiate(format, accept string) (string, error) {
+	if format != "" {
+		format = strings.ToLower(format)
+		if _, ok := contentTypes[format]; !ok {
+			return "", constants.ErrExportFormat
+		}
+		return format, nil
+	}
+
+	if accept == "" {
+		return FORMAT_CSV, nil
+	}
+
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		if format, ok := acceptedTypes[mediaType]; ok {
+			return format, nil
+		}
+
+		if mediaType == "*/*" || mediaType == "text/*" {
+			return FORMAT_CSV, nil
+		}
+	}
+
+	return "", constants.ErrExportFormat
+}
+
+// Stream sends an export as an attachment named name.<format>. produce is
+// called once and must hand every row to write. Headers are only committed
+// with the first row, so when produce fails before that, started is false
+// and the caller can still answer with a regular error response.
+func Stream(ctx *gin.Context, format, name string, columns []string, produce func(write func(values ...any) error) error) (started bool, err error) {
+	var writer Writer
+	rows := 0
+
+	start := func() error {
+		ctx.Header("Content-Type", contentTypes[format])
+		ctx.Header("Content-Disposition", `attachment; filename="`+name+"-"+time.Now().Format("20060102-150405")+"."+format+`"`)
+		ctx.Header("Cache-Control", "no-store")
+		ctx.Status(http.StatusOK)
+
+		var err error
+		writer, err = NewWriter(format, ctx.Writer, columns)
+		started = true
+		return err
+	}
+
+	write := func(values ...any) error {
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := writer.WriteRow(values...); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%flushEvery == 0 {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+			ctx.Writer.Flush()
+		}
+		return nil
+	}
+
+	if err := produce(write); err != nil {
+		if writer != nil {
+			// Close what has been written so far; the client still sees a
+			// truncated file rather than a hanging connection.
+			writer.Close()
+		}
+		return started, err
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			return started, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return started, err
+	}
+
+	ctx.Writer.Flush()
+	return started, nil
+}
@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+// NewWriter returns a Writer for format that has already written the header
+// row, where the format has one.
+func NewWriter(format string, w io.Writer, columns []string) (Writer, error) {
+	switch format {
+	case FORMAT_CSV:
+		return newCSVWriter(w, columns)
+	case FORMAT_NDJSON:
+		return newNDJSONWriter(w, columns), nil
+	case FORMAT_XLSX:
+		return newXLSXWriter(w, columns)
+	default:
+		return nil, constants.ErrExportFormat
+	}
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer, columns []string) (*csvWriter, error) {
+	cw := &csvWriter{w: csv.NewWriter(w)}
+	return cw, cw.w.Write(columns)
+}
+
+func (cw *csvWriter) WriteRow(values ...any) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		value = indirect(value)
+		record[i] = formatValue(value)
+
+		// Spreadsheets evaluate cells starting with these characters as
+		// formulas, so text is quoted to keep reports from executing it.
+		if _, isText := value.(string); isText && len(record[i]) > 0 {
+			switch record[i][0] {
+			case '=', '+', '-', '@', '\t', '\r':
+				record[i] = "'" + record[i]
+			}
+		}
+	}
+	return cw.w.Write(record)
+}
+
+func (cw *csvWriter) Flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+func (cw *csvWriter) Close() error {
+	return cw.Flush()
+}
+
+type ndjsonWriter struct {
+	w       *bufio.Writer
+	columns [][]byte
+}
+
+func newNDJSONWriter(w io.Writer, columns []string) *ndjsonWriter {
+	nw := &ndjsonWriter{w: bufio.NewWriter(w)}
+	for _, column := range columns {
+		name, _ := json.Marshal(column)
+		nw.columns = append(nw.columns, name)
+	}
+	return nw
+}
+
+// WriteRow writes one JSON object per line with keys in column order.
+func (nw *ndjsonWriter) WriteRow(values ...any) error {
+	nw.w.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			nw.w.WriteByte(',')
+		}
+
+		data, err := json.Marshal(indirect(value))
+		if err != nil {
+			return err
+		}
+
+		nw.w.Write(nw.columns[i])
+		nw.w.WriteByte(':')
+		nw.w.Write(data)
+	}
+	nw.w.WriteString("}\n")
+	return nil
+}
+
+func (nw *ndjsonWriter) Flush() error {
+	return nw.w.Flush()
+}
+
+func (nw *ndjsonWriter) Close() error {
+	return nw.Flush()
+}
+
+// indirect dereferences pointers so nil pointers export as empty values.
+func indirect(value any) any {
+	if value == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	return v.Interface()
+}
+
+func formatValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -2,6 +2,40 @@
 
 package model
 
+import (
+	"time"
+)
+
+// Either the token pair, or, when a second factor is needed, an mfaToken to
+// pass to verifyMfa (mfaRequired) or to the REST enrollment endpoints
+// (mfaEnrollmentRequired).
+type AuthPayload struct {
+	AccessToken           *string `json:"accessToken,omitempty"`
+	RefreshToken          *string `json:"refreshToken,omitempty"`
+	MfaRequired           bool    `json:"mfaRequired"`
+	MfaEnrollmentRequired bool    `json:"mfaEnrollmentRequired"`
+	MfaToken              *string `json:"mfaToken,omitempty"`
+}
+
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+type Me struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	PhoneNumber *string    `json:"phoneNumber,omitempty"`
+	Address     *string    `json:"address,omitempty"`
+	Wishlist    []*Product `json:"wishlist"`
+	// Where the user is signed in, most recently active first.
+	Sessions []*Session `json:"sessions"`
+}
+
+type Mutation struct {
+}
+
 type Pagination struct {
 	Page    int `json:"page"`
 	PerPage int `json:"perPage"`
@@ -9,6 +43,21 @@ type Pagination struct {
 	Count   int `json:"count"`
 }
 
+type PriceBucket struct {
+	Min   float64  `json:"min"`
+	Max   *float64 `json:"max,omitempty"`
+	Count int      `json:"count"`
+}
+
+type PriceHistory struct {
+	ID        string    `json:"id"`
+	OldPrice  float64   `json:"oldPrice"`
+	NewPrice  float64   `json:"newPrice"`
+	ChangedBy *string   `json:"changedBy,omitempty"`
+	Reason    string    `json:"reason"`
+	ChangedAt time.Time `json:"changedAt"`
+}
+
 type Product struct {
 	ID          string  `json:"id"`
 	Name        string  `json:"name"`
@@ -16,6 +65,33 @@ type Product struct {
 	Merk        *string `json:"merk,omitempty"`
 	Material    *string `json:"material,omitempty"`
 	Price       float64 `json:"price"`
+	// The user who created the product. Only the owner and admins may change it.
+	OwnerID      *string           `json:"ownerId,omitempty"`
+	Version      int               `json:"version"`
+	IsWishlisted bool              `json:"isWishlisted"`
+	Highlight    *ProductHighlight `json:"highlight,omitempty"`
+	DeletedAt    *time.Time        `json:"deletedAt,omitempty"`
+	PriceHistory []*PriceHistory   `json:"priceHistory"`
+}
+
+type ProductFacets struct {
+	Merk     []*FacetCount  `json:"merk"`
+	Material []*FacetCount  `json:"material"`
+	Price    []*PriceBucket `json:"price"`
+	Category []*FacetCount  `json:"category,omitempty"`
+}
+
+type ProductFilterInput struct {
+	Merk     []string `json:"merk,omitempty"`
+	Material []string `json:"material,omitempty"`
+	MinPrice *float64 `json:"minPrice,omitempty"`
+	MaxPrice *float64 `json:"maxPrice,omitempty"`
+}
+
+type ProductHighlight struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Rank        float64 `json:"rank"`
 }
 
 type ProductPagination struct {
@@ -23,5 +99,63 @@ type ProductPagination struct {
 	Pagination *Pagination `json:"pagination"`
 }
 
+type ProductSearchResult struct {
+	Items      []*Product     `json:"items"`
+	Facets     *ProductFacets `json:"facets"`
+	Pagination *Pagination    `json:"pagination"`
+}
+
 type Query struct {
 }
+
+type Session struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"userAgent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"createdAt"`
+	LastSeenAt time.Time `json:"lastSeenAt"`
+	// Whether this is the session the request was made with.
+	Current bool `json:"current"`
+}
+
+type Suggestion struct {
+	Value string `json:"value"`
+	// Either product (a product name) or brand (a merk).
+	Type  string  `json:"type"`
+	Score float64 `json:"score"`
+}
+
+type UpdateProductInput struct {
+	Name        *string  `json:"name,omitempty"`
+	Description *string  `json:"description,omitempty"`
+	Merk        *string  `json:"merk,omitempty"`
+	Material    *string  `json:"material,omitempty"`
+	Price       *float64 `json:"price,omitempty"`
+	// Version the client last read. A stale version fails with a CONFLICT error carrying currentVersion.
+	Version int `json:"version"`
+}
+
+type UpdateUserInput struct {
+	Name        *string `json:"name,omitempty"`
+	Email       *string `json:"email,omitempty"`
+	Password    *string `json:"password,omitempty"`
+	PhoneNumber *string `json:"phoneNumber,omitempty"`
+	Address     *string `json:"address,omitempty"`
+	// Version the client last read. A stale version fails with a CONFLICT error carrying currentVersion.
+	Version int `json:"version"`
+}
+
+type User struct {
+	ID          string     `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	PhoneNumber *string    `json:"phoneNumber,omitempty"`
+	Address     *string    `json:"address,omitempty"`
+	Version     int        `json:"version"`
+	DeletedAt   *time.Time `json:"deletedAt,omitempty"`
+}
+
+type UserPagination struct {
+	Data       []*User     `json:"data"`
+	Pagination *Pagination `json:"pagination"`
+}
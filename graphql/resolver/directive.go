@@ -0,0 +1,29 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/helpers"
+)
+
+// HasPermission implements the @hasPermission directive with checker.
+func HasPermission(checker rbac.PermissionChecker) func(ctx context.Context, obj any, next graphql.Resolver, permission string) (any, error) {
+	return func(ctx context.Context, obj any, next graphql.Resolver, permission string) (any, error) {
+		if helpers.GetUserID(ctx) == "" {
+			return nil, constants.ErrGetIDFromToken
+		}
+
+		allowed, err := checker.HasPermission(ctx, helpers.GetRole(ctx), permission)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, constants.ErrDeniedAccess
+		}
+
+		return next(ctx)
+	}
+}
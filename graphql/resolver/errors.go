@@ -0,0 +1,54 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"math"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const (
+	ERROR_CODE_CONFLICT          = "CONFLICT"
+	ERROR_CODE_TOO_MANY_REQUESTS = "TOO_MANY_REQUESTS"
+)
+
+// conflictError turns a stale-version update into a GraphQL error with a
+// CONFLICT code and the stored version in its extensions. Other errors are
+// returned unchanged.
+func conflictError(ctx context.Context, err error) error {
+	var conflict *helpers.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return err
+	}
+
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: err.Error(),
+		Extensions: map[string]interface{}{
+			"code":           ERROR_CODE_CONFLICT,
+			"currentVersion": conflict.CurrentVersion,
+		},
+	}
+}
+
+// tooManyRequestsError turns a throttled request into a GraphQL error with a
+// TOO_MANY_REQUESTS code and the seconds to wait in retryAfter. Other errors
+// are returned unchanged.
+func tooManyRequestsError(ctx context.Context, err error) error {
+	var throttled *helpers.TooManyRequestsError
+	if !errors.As(err, &throttled) {
+		return err
+	}
+
+	return &gqlerror.Error{
+		Path:    graphql.GetPath(ctx),
+		Message: err.Error(),
+		Extensions: map[string]interface{}{
+			"code":       ERROR_CODE_TOO_MANY_REQUESTS,
+			"retryAfter": int(math.Ceil(throttled.RetryAfter.Seconds())),
+		},
+	}
+}
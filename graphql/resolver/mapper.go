@@ -0,0 +1,100 @@
+package resolver
+
+import (
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/graphql/model"
+)
+
+func toProductHighlightModel(h *product.ProductHighlight) *model.ProductHighlight {
+	if h == nil {
+		return nil
+	}
+
+	return &model.ProductHighlight{
+		Name:        h.Name,
+		Description: h.Description,
+		Rank:        float64(h.Rank),
+	}
+}
+
+func toProductModel(p product.ProductResponse) *model.Product {
+	return &model.Product{
+		ID:          p.ID.String(),
+		Name:        p.Name,
+		Description: p.Description,
+		Merk:        &p.Merk,
+		Material:    &p.Material,
+		Price:       float64(p.Price),
+		OwnerID:     toIDPtr(p.OwnerID),
+		Version:     int(p.Version),
+		Highlight:   toProductHighlightModel(p.Highlight),
+		DeletedAt:   p.DeletedAt,
+	}
+}
+
+func toFacetCountModels(counts []product.FacetCount) []*model.FacetCount {
+	result := make([]*model.FacetCount, 0, len(counts))
+	for _, c := range counts {
+		result = append(result, &model.FacetCount{
+			Value: c.Value,
+			Count: int(c.Count),
+		})
+	}
+	return result
+}
+
+func toFloat32Ptr(v *float64) *float32 {
+	if v == nil {
+		return nil
+	}
+	f := float32(*v)
+	return &f
+}
+
+func toIDPtr(id *uuid.UUID) *string {
+	if id == nil {
+		return nil
+	}
+	s := id.String()
+	return &s
+}
+
+func toUserModel(u user.UserResponse) *model.User {
+	return &model.User{
+		ID:          u.ID.String(),
+		Name:        u.Name,
+		Email:       u.Email,
+		PhoneNumber: &u.PhoneNumber,
+		Address:     &u.Address,
+		Version:     int(u.Version),
+		DeletedAt:   u.DeletedAt,
+	}
+}
+
+func toAuthPayloadModel(res user.LoginResponse) *model.AuthPayload {
+	payload := &model.AuthPayload{
+		MfaRequired:           res.MFARequired,
+		MfaEnrollmentRequired: res.MFAEnrollmentRequired,
+	}
+	if res.AccessToken != "" {
+		payload.AccessToken = &res.AccessToken
+		payload.RefreshToken = &res.RefreshToken
+	}
+	if res.MFAToken != "" {
+		payload.MfaToken = &res.MFAToken
+	}
+	return payload
+}
+
+func toSessionModel(s user.SessionResponse) *model.Session {
+	return &model.Session{
+		ID:         s.ID.String(),
+		UserAgent:  s.UserAgent,
+		IP:         s.IP,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+		Current:    s.Current,
+	}
+}
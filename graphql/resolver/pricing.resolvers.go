@@ -0,0 +1,41 @@
+package resolver
+
+import (
+	"context"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/domain/pricing"
+	"github.com/mferdian/Go-GraphQL/graphql/model"
+)
+
+// PriceHistory is the resolver for the priceHistory field.
+func (r *productResolver) PriceHistory(ctx context.Context, obj *model.Product, from *time.Time, to *time.Time) ([]*model.PriceHistory, error) {
+	histories, err := r.PricingService.GetPriceHistory(ctx, pricing.PriceHistoryRequest{
+		ProductID: obj.ID,
+		From:      from,
+		To:        to,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.PriceHistory, 0, len(histories))
+	for _, h := range histories {
+		item := &model.PriceHistory{
+			ID:        h.ID.String(),
+			OldPrice:  float64(h.OldPrice),
+			NewPrice:  float64(h.NewPrice),
+			Reason:    h.Reason,
+			ChangedAt: h.ChangedAt,
+		}
+
+		if h.ChangedBy != nil {
+			changedBy := h.ChangedBy.String()
+			item.ChangedBy = &changedBy
+		}
+
+		result = append(result, item)
+	}
+
+	return result, nil
+}
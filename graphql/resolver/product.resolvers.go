@@ -6,8 +6,33 @@ import (
 	"github.com/mferdian/Go-GraphQL/domain/product"
 	"github.com/mferdian/Go-GraphQL/graphql/generated"
 	"github.com/mferdian/Go-GraphQL/graphql/model"
+	"github.com/mferdian/Go-GraphQL/helpers"
 )
 
+// UpdateProduct is the resolver for the updateProduct field.
+func (r *mutationResolver) UpdateProduct(ctx context.Context, id string, input model.UpdateProductInput) (*model.Product, error) {
+	version := int64(input.Version)
+	p, err := r.ProductService.UpdateProduct(ctx, product.UpdateProductRequest{
+		ID:          id,
+		Name:        input.Name,
+		Description: input.Description,
+		Merk:        input.Merk,
+		Material:    input.Material,
+		Price:       toFloat32Ptr(input.Price),
+		Version:     &version,
+	})
+	if err != nil {
+		return nil, conflictError(ctx, err)
+	}
+
+	return toProductModel(p), nil
+}
+
+// IsWishlisted is the resolver for the isWishlisted field.
+func (r *productResolver) IsWishlisted(ctx context.Context, obj *model.Product) (bool, error) {
+	return r.WishlistService.IsWishlisted(ctx, helpers.GetUserID(ctx), obj.ID)
+}
+
 // Products is the resolver for the products field.
 func (r *queryResolver) Products(ctx context.Context, search *string) ([]*model.Product, error) {
 	var keyword string
@@ -29,6 +54,8 @@ func (r *queryResolver) Products(ctx context.Context, search *string) ([]*model.
 			Merk:        &p.Merk,
 			Material:    &p.Material,
 			Price:       float64(p.Price),
+			Version:     int(p.Version),
+			Highlight:   toProductHighlightModel(p.Highlight),
 		})
 	}
 
@@ -49,6 +76,7 @@ func (r *queryResolver) Product(ctx context.Context, id string) (*model.Product,
 		Merk:        &p.Merk,
 		Material:    &p.Material,
 		Price:       float64(p.Price),
+		Version:     int(p.Version),
 	}, nil
 }
 
@@ -79,6 +107,8 @@ func (r *queryResolver) ProductsWithPagination(ctx context.Context, page int, pe
 			Merk:        &p.Merk,
 			Material:    &p.Material,
 			Price:       float64(p.Price),
+			Version:     int(p.Version),
+			Highlight:   toProductHighlightModel(p.Highlight),
 		})
 	}
 
@@ -93,7 +123,11 @@ func (r *queryResolver) ProductsWithPagination(ctx context.Context, page int, pe
 	}, nil
 }
 
+// Product returns generated.ProductResolver implementation.
+func (r *Resolver) Product() generated.ProductResolver { return &productResolver{r} }
+
 // Query returns generated.QueryResolver implementation.
 func (r *Resolver) Query() generated.QueryResolver { return &queryResolver{r} }
 
+type productResolver struct{ *Resolver }
 type queryResolver struct{ *Resolver }
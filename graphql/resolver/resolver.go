@@ -1,6 +1,11 @@
 package resolver
 
-import "github.com/mferdian/Go-GraphQL/domain/product"
+import (
+	"github.com/mferdian/Go-GraphQL/domain/pricing"
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/domain/wishlist"
+)
 
 // This file will not be regenerated automatically.
 //
@@ -8,5 +13,8 @@ import "github.com/mferdian/Go-GraphQL/domain/product"
 // here.
 
 type Resolver struct {
-	ProductService product.IProductService
-}
\ No newline at end of file
+	ProductService  product.IProductService
+	UserService     user.IUserService
+	WishlistService wishlist.IWishlistService
+	PricingService  pricing.IPricingService
+}
@@ -0,0 +1,96 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/graphql/model"
+)
+
+// ProductSearch is the resolver for the productSearch field.
+func (r *queryResolver) ProductSearch(ctx context.Context, search *string, filter *model.ProductFilterInput, page int, perPage int) (*model.ProductSearchResult, error) {
+	req := product.ProductPaginationRequest{
+		PaginationRequest: product.PaginationRequest{
+			Page:    page,
+			PerPage: perPage,
+		},
+	}
+
+	if search != nil {
+		req.Search = *search
+	}
+
+	if filter != nil {
+		req.Merk = filter.Merk
+		req.Material = filter.Material
+		req.MinPrice = toFloat32Ptr(filter.MinPrice)
+		req.MaxPrice = toFloat32Ptr(filter.MaxPrice)
+	}
+
+	data, err := r.ProductService.SearchProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]*model.Product, 0, len(data.Items))
+	for _, p := range data.Items {
+		items = append(items, toProductModel(p))
+	}
+
+	facets := &model.ProductFacets{
+		Merk:     toFacetCountModels(data.Facets.Merk),
+		Material: toFacetCountModels(data.Facets.Material),
+		Price:    make([]*model.PriceBucket, 0, len(data.Facets.Price)),
+	}
+
+	for _, b := range data.Facets.Price {
+		bucket := &model.PriceBucket{
+			Min:   float64(b.Min),
+			Count: int(b.Count),
+		}
+		if b.Max != nil {
+			max := float64(*b.Max)
+			bucket.Max = &max
+		}
+		facets.Price = append(facets.Price, bucket)
+	}
+
+	if data.Facets.Category != nil {
+		facets.Category = toFacetCountModels(data.Facets.Category)
+	}
+
+	return &model.ProductSearchResult{
+		Items:  items,
+		Facets: facets,
+		Pagination: &model.Pagination{
+			Page:    data.Page,
+			PerPage: data.PerPage,
+			MaxPage: int(data.MaxPage),
+			Count:   int(data.Count),
+		},
+	}, nil
+}
+
+// Suggest is the resolver for the suggest field.
+func (r *queryResolver) Suggest(ctx context.Context, q string, limit *int) ([]*model.Suggestion, error) {
+	req := product.SuggestProductRequest{Q: q}
+	if limit != nil {
+		req.Limit = *limit
+	}
+
+	suggestions, err := r.ProductService.SuggestProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Suggestion, 0, len(suggestions))
+	for _, s := range suggestions {
+		result = append(result, &model.Suggestion{
+			Value: s.Value,
+			Type:  s.Type,
+			Score: float64(s.Score),
+		})
+	}
+
+	return result, nil
+}
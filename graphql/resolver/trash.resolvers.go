@@ -0,0 +1,123 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/graphql/generated"
+	"github.com/mferdian/Go-GraphQL/graphql/model"
+)
+
+// RestoreProduct is the resolver for the restoreProduct field.
+func (r *mutationResolver) RestoreProduct(ctx context.Context, id string) (*model.Product, error) {
+	p, err := r.ProductService.RestoreProduct(ctx, product.RestoreProductRequest{ProductID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return toProductModel(p), nil
+}
+
+// PurgeProduct is the resolver for the purgeProduct field.
+func (r *mutationResolver) PurgeProduct(ctx context.Context, id string) (*model.Product, error) {
+	p, err := r.ProductService.PurgeProduct(ctx, product.PurgeProductRequest{ProductID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return toProductModel(p), nil
+}
+
+// RestoreUser is the resolver for the restoreUser field.
+func (r *mutationResolver) RestoreUser(ctx context.Context, id string) (*model.User, error) {
+	u, err := r.UserService.RestoreUser(ctx, user.RestoreUserRequest{UserID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserModel(u), nil
+}
+
+// PurgeUser is the resolver for the purgeUser field.
+func (r *mutationResolver) PurgeUser(ctx context.Context, id string) (*model.User, error) {
+	u, err := r.UserService.PurgeUser(ctx, user.PurgeUserRequest{UserID: id})
+	if err != nil {
+		return nil, err
+	}
+
+	return toUserModel(u), nil
+}
+
+// TrashedProducts is the resolver for the trashedProducts field.
+func (r *queryResolver) TrashedProducts(ctx context.Context, page int, perPage int, search *string) (*model.ProductPagination, error) {
+	req := product.ProductPaginationRequest{
+		PaginationRequest: product.PaginationRequest{
+			Page:    page,
+			PerPage: perPage,
+		},
+	}
+
+	if search != nil {
+		req.Search = *search
+	}
+
+	data, err := r.ProductService.GetTrashedProducts(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]*model.Product, 0, len(data.Data))
+	for _, p := range data.Data {
+		products = append(products, toProductModel(p))
+	}
+
+	return &model.ProductPagination{
+		Data: products,
+		Pagination: &model.Pagination{
+			Page:    data.Page,
+			PerPage: data.PerPage,
+			MaxPage: int(data.MaxPage),
+			Count:   int(data.Count),
+		},
+	}, nil
+}
+
+// TrashedUsers is the resolver for the trashedUsers field.
+func (r *queryResolver) TrashedUsers(ctx context.Context, page int, perPage int, search *string) (*model.UserPagination, error) {
+	req := user.UserPaginationRequest{
+		PaginationRequest: user.PaginationRequest{
+			Page:    page,
+			PerPage: perPage,
+		},
+	}
+
+	if search != nil {
+		req.Search = *search
+	}
+
+	data, err := r.UserService.GetTrashedUsers(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*model.User, 0, len(data.Data))
+	for _, u := range data.Data {
+		users = append(users, toUserModel(u))
+	}
+
+	return &model.UserPagination{
+		Data: users,
+		Pagination: &model.Pagination{
+			Page:    data.Page,
+			PerPage: data.PerPage,
+			MaxPage: int(data.MaxPage),
+			Count:   int(data.Count),
+		},
+	}, nil
+}
+
+// Mutation returns generated.MutationResolver implementation.
+func (r *Resolver) Mutation() generated.MutationResolver { return &mutationResolver{r} }
+
+type mutationResolver struct{ *Resolver }
@@ -0,0 +1,170 @@
+package resolver
+
+import (
+	"context"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/graphql/generated"
+	"github.com/mferdian/Go-GraphQL/graphql/model"
+	"github.com/mferdian/Go-GraphQL/helpers"
+)
+
+// Wishlist is the resolver for the wishlist field.
+func (r *meResolver) Wishlist(ctx context.Context, obj *model.Me) ([]*model.Product, error) {
+	wishlists, err := r.WishlistService.GetWishlist(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Product, 0, len(wishlists))
+	for _, w := range wishlists {
+		result = append(result, &model.Product{
+			ID:          w.ProductID.String(),
+			Name:        w.Name,
+			Description: w.Description,
+			Merk:        &w.Merk,
+			Material:    &w.Material,
+			Price:       float64(w.Price),
+			Version:     int(w.Version),
+		})
+	}
+
+	return result, nil
+}
+
+// Sessions is the resolver for the sessions field.
+func (r *meResolver) Sessions(ctx context.Context, obj *model.Me) ([]*model.Session, error) {
+	sessions, err := r.UserService.GetSessions(ctx, obj.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*model.Session, 0, len(sessions))
+	for _, session := range sessions {
+		result = append(result, toSessionModel(session))
+	}
+
+	return result, nil
+}
+
+// Login is the resolver for the login field.
+func (r *mutationResolver) Login(ctx context.Context, email string, password string) (*model.AuthPayload, error) {
+	res, err := r.UserService.Login(ctx, user.LoginUserRequest{
+		Email:    email,
+		Password: password,
+	})
+	if err != nil {
+		return nil, tooManyRequestsError(ctx, err)
+	}
+
+	return toAuthPayloadModel(res), nil
+}
+
+// VerifyMfa is the resolver for the verifyMfa field.
+func (r *mutationResolver) VerifyMfa(ctx context.Context, mfaToken string, code string) (*model.AuthPayload, error) {
+	res, err := r.UserService.VerifyMFA(ctx, user.MFALoginRequest{
+		MFAToken: mfaToken,
+		Code:     code,
+	})
+	if err != nil {
+		return nil, tooManyRequestsError(ctx, err)
+	}
+
+	return toAuthPayloadModel(res), nil
+}
+
+// ForgotPassword is the resolver for the forgotPassword field.
+func (r *mutationResolver) ForgotPassword(ctx context.Context, email string) (bool, error) {
+	r.UserService.ForgotPassword(ctx, user.ForgotPasswordRequest{Email: email})
+	return true, nil
+}
+
+// ResetPassword is the resolver for the resetPassword field.
+func (r *mutationResolver) ResetPassword(ctx context.Context, token string, password string) (bool, error) {
+	if err := r.UserService.ResetPassword(ctx, user.ResetPasswordRequest{
+		Token:    token,
+		Password: password,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// UpdateUser is the resolver for the updateUser field.
+func (r *mutationResolver) UpdateUser(ctx context.Context, id string, input model.UpdateUserInput) (*model.User, error) {
+	version := int64(input.Version)
+	u, err := r.UserService.UpdateUser(ctx, user.UpdateUserRequest{
+		ID:          id,
+		Name:        input.Name,
+		Email:       input.Email,
+		Password:    input.Password,
+		PhoneNumber: input.PhoneNumber,
+		Address:     input.Address,
+		Version:     &version,
+	})
+	if err != nil {
+		return nil, conflictError(ctx, err)
+	}
+
+	return toUserModel(u), nil
+}
+
+// RevokeSession is the resolver for the revokeSession field.
+func (r *mutationResolver) RevokeSession(ctx context.Context, id string) (bool, error) {
+	userID := helpers.GetUserID(ctx)
+	if userID == "" {
+		return false, constants.ErrGetIDFromToken
+	}
+
+	if err := r.UserService.RevokeSession(ctx, user.RevokeSessionRequest{
+		UserID:    userID,
+		SessionID: id,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// RevokeOtherSessions is the resolver for the revokeOtherSessions field.
+func (r *mutationResolver) RevokeOtherSessions(ctx context.Context) (int, error) {
+	userID := helpers.GetUserID(ctx)
+	if userID == "" {
+		return 0, constants.ErrGetIDFromToken
+	}
+
+	res, err := r.UserService.RevokeOtherSessions(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return int(res.Revoked), nil
+}
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*model.Me, error) {
+	userID := helpers.GetUserID(ctx)
+	if userID == "" {
+		return nil, constants.ErrGetIDFromToken
+	}
+
+	u, err := r.UserService.GetuserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.Me{
+		ID:          u.ID.String(),
+		Name:        u.Name,
+		Email:       u.Email,
+		PhoneNumber: &u.PhoneNumber,
+		Address:     &u.Address,
+	}, nil
+}
+
+// Me returns generated.MeResolver implementation.
+func (r *Resolver) Me() generated.MeResolver { return &meResolver{r} }
+
+type meResolver struct{ *Resolver }
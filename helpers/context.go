@@ -0,0 +1,56 @@
+package helpers
+
+import "context"
+
+type contextKey string
+
+const (
+	contextKeyUserID   contextKey = "id"
+	contextKeyRole     contextKey = "role"
+	contextKeyClientIP contextKey = "client_ip"
+
+	contextKeySessionID contextKey = "session_id"
+	contextKeyUserAgent contextKey = "user_agent"
+)
+
+func WithActor(ctx context.Context, userID, role string) context.Context {
+	ctx = context.WithValue(ctx, contextKeyUserID, userID)
+	return context.WithValue(ctx, contextKeyRole, role)
+}
+
+func GetUserID(ctx context.Context) string {
+	userID, _ := ctx.Value(contextKeyUserID).(string)
+	return userID
+}
+
+func GetRole(ctx context.Context) string {
+	role, _ := ctx.Value(contextKeyRole).(string)
+	return role
+}
+
+func WithClientIP(ctx context.Context, ip string) context.Context {
+	return context.WithValue(ctx, contextKeyClientIP, ip)
+}
+
+func GetClientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(contextKeyClientIP).(string)
+	return ip
+}
+
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, contextKeySessionID, sessionID)
+}
+
+func GetSessionID(ctx context.Context) string {
+	sessionID, _ := ctx.Value(contextKeySessionID).(string)
+	return sessionID
+}
+
+func WithUserAgent(ctx context.Context, userAgent string) context.Context {
+	return context.WithValue(ctx, contextKeyUserAgent, userAgent)
+}
+
+func GetUserAgent(ctx context.Context) string {
+	userAgent, _ := ctx.Value(contextKeyUserAgent).(string)
+	return userAgent
+}
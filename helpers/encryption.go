@@ -0,0 +1,67 @@
+package helpers
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+// Encrypt seals plaintext with AES-256-GCM under a key derived from secret
+// and returns the nonce and ciphertext base64 encoded, for secrets that must
+// be stored but read back, unlike passwords. It refuses an empty secret.
+func Encrypt(secret []byte, plaintext string) (string, error) {
+	if len(secret) == 0 {
+		return "", constants.ErrSecretNotConfigured
+	}
+
+	aead, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value from Encrypt.
+func Decrypt(secret []byte, ciphertext string) (string, error) {
+	if len(secret) == 0 {
+		return "", constants.ErrSecretNotConfigured
+	}
+
+	aead, err := newGCM(secret)
+	if err != nil {
+		return "", err
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", constants.ErrDecryptToken
+	}
+
+	nonce, data := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, data, nil)
+	if err != nil {
+		return "", constants.ErrDecryptToken
+	}
+
+	return string(plaintext), nil
+}
+
+func newGCM(secret []byte) (cipher.AEAD, error) {
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
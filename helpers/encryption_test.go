@@ -0,0 +1,44 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+func TestEncryptRoundTrip(t *testing.T) {
+	secret := []byte("secret")
+
+	sealed, err := Encrypt(secret, "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := Encrypt(secret, "JBSWY3DPEHPK3PXP")
+	if sealed == again {
+		t.Error("Encrypt is deterministic, want a fresh nonce each time")
+	}
+
+	got, err := Decrypt(secret, sealed)
+	if err != nil || got != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Decrypt = %q, %v; want the plaintext", got, err)
+	}
+
+	if _, err := Decrypt([]byte("other"), sealed); err == nil {
+		t.Error("Decrypt with another secret succeeded")
+	}
+	if _, err := Decrypt(secret, sealed[:len(sealed)-2]+"AA"); err == nil {
+		t.Error("Decrypt of tampered ciphertext succeeded")
+	}
+}
+
+func TestEncryptRefusesEmptySecret(t *testing.T) {
+	if _, err := Encrypt(nil, "plaintext"); !errors.Is(err, constants.ErrSecretNotConfigured) {
+		t.Errorf("Encrypt with empty secret: error = %v, want %v", err, constants.ErrSecretNotConfigured)
+	}
+
+	sealed, _ := Encrypt([]byte("secret"), "plaintext")
+	if _, err := Decrypt(nil, sealed); !errors.Is(err, constants.ErrSecretNotConfigured) {
+		t.Errorf("Decrypt with empty secret: error = %v, want %v", err, constants.ErrSecretNotConfigured)
+	}
+}
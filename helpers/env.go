@@ -0,0 +1,39 @@
+package helpers
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// DurationEnv parses the env var key as a time.Duration such as "15m". It
+// returns fallback when the variable is unset, invalid or not positive.
+func DurationEnv(key string, fallback time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+// IntEnv parses the env var key as an integer. It returns fallback when the
+// variable is unset, invalid or below minimum.
+func IntEnv(key string, fallback, minimum int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil || value < minimum {
+		return fallback
+	}
+	return value
+}
+
+// SecretEnv returns the first of the env vars keys that is set, for secrets
+// that fall back to a shared one such as JWT_SECRET. It is empty when none
+// is set, which SignToken and Encrypt refuse.
+func SecretEnv(keys ...string) []byte {
+	for _, key := range keys {
+		if value := os.Getenv(key); value != "" {
+			return []byte(value)
+		}
+	}
+	return nil
+}
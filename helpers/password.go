@@ -1,17 +1,142 @@
 package helpers
 
-import "golang.org/x/crypto/bcrypt"
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
 
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	defaultArgon2Memory  = 64 * 1024 // KiB
+	defaultArgon2Time    = 3
+	defaultArgon2Threads = 2
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var errInvalidArgon2Hash = errors.New("invalid argon2id hash")
+
+// Argon2Params are the argon2id cost parameters new password hashes are made
+// with. Memory is in KiB.
+type Argon2Params struct {
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+var (
+	argon2ParamsOnce sync.Once
+	argon2Params     Argon2Params
+)
+
+// CurrentArgon2Params reads PASSWORD_ARGON2_MEMORY (KiB, default 65536),
+// PASSWORD_ARGON2_TIME (default 3) and PASSWORD_ARGON2_THREADS (default 2)
+// once. Raising them makes stored hashes with lower values get rehashed at
+// the user's next login, see NeedsRehash.
+func CurrentArgon2Params() Argon2Params {
+	argon2ParamsOnce.Do(func() {
+		argon2Params = Argon2Params{
+			Memory:  uint32(uintEnv("PASSWORD_ARGON2_MEMORY", defaultArgon2Memory, 32)),
+			Time:    uint32(uintEnv("PASSWORD_ARGON2_TIME", defaultArgon2Time, 32)),
+			Threads: uint8(uintEnv("PASSWORD_ARGON2_THREADS", defaultArgon2Threads, 8)),
+		}
+	})
+	return argon2Params
+}
+
+// HashPassword hashes password with argon2id in the PHC string format,
+// $argon2id$v=19$m=<memory>,t=<time>,p=<threads>$<salt>$<hash>.
 func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 4)
-	return string(bytes), err
+	params := CurrentArgon2Params()
+
+	salt := make([]byte, argon2SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, params.Time, params.Memory, params.Threads, argon2KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, params.Memory, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
 }
 
+// CheckPassword compares plainPassword with an argon2id hash, or with a
+// bcrypt hash made before argon2id was introduced.
 func CheckPassword(hashPassword string, plainPassword []byte) (bool, error) {
-	hashP := []byte(hashPassword)
-	if err := bcrypt.CompareHashAndPassword(hashP, plainPassword); err != nil {
+	if !strings.HasPrefix(hashPassword, "$argon2id$") {
+		hashP := []byte(hashPassword)
+		if err := bcrypt.CompareHashAndPassword(hashP, plainPassword); err != nil {
+			return false, err
+		}
+
+		return true, nil
+	}
+
+	params, salt, key, err := decodeArgon2Hash(hashPassword)
+	if err != nil {
 		return false, err
 	}
 
+	other := argon2.IDKey(plainPassword, salt, params.Time, params.Memory, params.Threads, uint32(len(key)))
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, bcrypt.ErrMismatchedHashAndPassword
+	}
+
 	return true, nil
 }
+
+// NeedsRehash reports whether hashPassword was made with bcrypt or with
+// argon2id parameters other than the current ones.
+func NeedsRehash(hashPassword string) bool {
+	params, _, _, err := decodeArgon2Hash(hashPassword)
+	return err != nil || params != CurrentArgon2Params()
+}
+
+func decodeArgon2Hash(hash string) (Argon2Params, []byte, []byte, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2Params{}, nil, nil, errInvalidArgon2Hash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2Params{}, nil, nil, errInvalidArgon2Hash
+	}
+
+	var params Argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Time, &params.Threads); err != nil {
+		return Argon2Params{}, nil, nil, errInvalidArgon2Hash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2Params{}, nil, nil, errInvalidArgon2Hash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return Argon2Params{}, nil, nil, errInvalidArgon2Hash
+	}
+
+	return params, salt, key, nil
+}
+
+func uintEnv(key string, fallback uint64, bits int) uint64 {
+	value, err := strconv.ParseUint(os.Getenv(key), 10, bits)
+	if err != nil || value == 0 {
+		return fallback
+	}
+	return value
+}
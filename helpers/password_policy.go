@@ -0,0 +1,164 @@
+package helpers
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const (
+	defaultPasswordMinLength  = 8
+	defaultPasswordMaxLength  = 128
+	defaultPasswordMinClasses = 2
+
+	// The local part of an email is only checked when at least this long,
+	// so short ones like "jo" do not rule out half the dictionary.
+	minEmailLocalPartCheck = 3
+)
+
+//go:embed common_passwords.txt
+var bundledCommonPasswords string
+
+// PasswordPolicy is what a new password must satisfy. Character classes are
+// lowercase letters, uppercase letters, digits and everything else.
+type PasswordPolicy struct {
+	MinLength  int
+	MaxLength  int
+	MinClasses int
+
+	RequireLower  bool
+	RequireUpper  bool
+	RequireDigit  bool
+	RequireSymbol bool
+
+	denylist map[string]bool
+}
+
+// NewPasswordPolicy reads the policy from the environment:
+// PASSWORD_MIN_LENGTH (default 8), PASSWORD_MAX_LENGTH (default 128),
+// PASSWORD_MIN_CLASSES (default 2) and PASSWORD_REQUIRE_CLASSES, a comma
+// separated list of lower, upper, digit and symbol. Common passwords are
+// rejected using the bundled list, or the file at PASSWORD_DENYLIST_FILE.
+func NewPasswordPolicy() PasswordPolicy {
+	policy := PasswordPolicy{
+		MinLength:  IntEnv("PASSWORD_MIN_LENGTH", defaultPasswordMinLength, 0),
+		MaxLength:  IntEnv("PASSWORD_MAX_LENGTH", defaultPasswordMaxLength, 0),
+		MinClasses: IntEnv("PASSWORD_MIN_CLASSES", defaultPasswordMinClasses, 0),
+	}
+
+	for _, class := range strings.Split(os.Getenv("PASSWORD_REQUIRE_CLASSES"), ",") {
+		switch strings.TrimSpace(class) {
+		case "lower":
+			policy.RequireLower = true
+		case "upper":
+			policy.RequireUpper = true
+		case "digit":
+			policy.RequireDigit = true
+		case "symbol":
+			policy.RequireSymbol = true
+		}
+	}
+
+	list := bundledCommonPasswords
+	if path := os.Getenv("PASSWORD_DENYLIST_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logging.Log.WithError(err).Warnf("failed read password denylist %s, using the bundled one", path)
+		} else {
+			list = string(data)
+		}
+	}
+	policy.denylist = parseDenylist(list)
+
+	return policy
+}
+
+// Validate checks password against the policy. email is the account's
+// address, which the password may not contain. Errors wrap
+// constants.ErrInvalidPassword and say which rule failed.
+func (p PasswordPolicy) Validate(password, email string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("%w: %w (at least %d characters)", constants.ErrInvalidPassword, constants.ErrPasswordTooShort, p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("%w: %w (at most %d characters)", constants.ErrInvalidPassword, constants.ErrPasswordTooLong, p.MaxLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	var missing []string
+	if p.RequireLower && !lower {
+		missing = append(missing, "a lowercase letter")
+	}
+	if p.RequireUpper && !upper {
+		missing = append(missing, "an uppercase letter")
+	}
+	if p.RequireDigit && !digit {
+		missing = append(missing, "a digit")
+	}
+	if p.RequireSymbol && !symbol {
+		missing = append(missing, "a symbol")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: %w (needs %s)", constants.ErrInvalidPassword, constants.ErrPasswordTooSimple, strings.Join(missing, ", "))
+	}
+
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, symbol} {
+		if has {
+			classes++
+		}
+	}
+	if classes < p.MinClasses {
+		return fmt.Errorf("%w: %w (mix at least %d of lowercase, uppercase, digits and symbols)", constants.ErrInvalidPassword, constants.ErrPasswordTooSimple, p.MinClasses)
+	}
+
+	lowered := strings.ToLower(password)
+	if p.denylist[lowered] {
+		return fmt.Errorf("%w: %w", constants.ErrInvalidPassword, constants.ErrPasswordCommon)
+	}
+
+	if email = strings.ToLower(strings.TrimSpace(email)); email != "" {
+		local, _, _ := strings.Cut(email, "@")
+		if strings.Contains(lowered, email) || (len(local) >= minEmailLocalPartCheck && strings.Contains(lowered, local)) {
+			return fmt.Errorf("%w: %w", constants.ErrInvalidPassword, constants.ErrPasswordContainsEmail)
+		}
+	}
+
+	return nil
+}
+
+func parseDenylist(list string) map[string]bool {
+	denylist := make(map[string]bool)
+
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		denylist[strings.ToLower(line)] = true
+	}
+
+	return denylist
+}
@@ -0,0 +1,98 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+func TestPasswordPolicyValidate(t *testing.T) {
+	defaults := PasswordPolicy{
+		MinLength:  8,
+		MaxLength:  128,
+		MinClasses: 2,
+		denylist:   parseDenylist("# comment\n\nPassword1\nqwertyuiop\n"),
+	}
+	strict := defaults
+	strict.RequireLower, strict.RequireUpper, strict.RequireDigit, strict.RequireSymbol = true, true, true, true
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		email    string
+		want     error
+	}{
+		{"valid", defaults, "tulip-garden", "", nil},
+		{"too short", defaults, "ab-12", "", constants.ErrPasswordTooShort},
+		{"length counts runes", defaults, "пароль-1", "", nil},
+		{"too long", defaults, strings.Repeat("a1", 65), "", constants.ErrPasswordTooLong},
+		{"no max length", PasswordPolicy{MinLength: 1}, strings.Repeat("a", 500), "", nil},
+		{"one class", defaults, "abcdefghij", "", constants.ErrPasswordTooSimple},
+		{"two classes", defaults, "abcdefghi9", "", nil},
+		{"required class missing", strict, "Abcdefgh1", "", constants.ErrPasswordTooSimple},
+		{"required classes present", strict, "Abcdefg1!", "", nil},
+		{"common", defaults, "password1", "", constants.ErrPasswordCommon},
+		{"common ignores case", defaults, "QWERTYuiop", "", constants.ErrPasswordCommon},
+		{"contains email", defaults, "x-Jane.Doe@Example.com", "jane.doe@example.com", constants.ErrPasswordContainsEmail},
+		{"contains local part", defaults, "my-janedoe-1", " JaneDoe@example.com ", constants.ErrPasswordContainsEmail},
+		{"short local part ignored", defaults, "jo-tulip-99", "jo@example.com", nil},
+	}
+
+	for _, tt := range tests {
+		err := tt.policy.Validate(tt.password, tt.email)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: Validate = %v, want nil", tt.name, err)
+			}
+			continue
+		}
+		if !errors.Is(err, tt.want) || !errors.Is(err, constants.ErrInvalidPassword) {
+			t.Errorf("%s: Validate = %v, want %v wrapped in %v", tt.name, err, tt.want, constants.ErrInvalidPassword)
+		}
+	}
+}
+
+func TestNewPasswordPolicy(t *testing.T) {
+	t.Setenv("PASSWORD_MIN_LENGTH", "12")
+	t.Setenv("PASSWORD_MAX_LENGTH", "")
+	t.Setenv("PASSWORD_MIN_CLASSES", "3")
+	t.Setenv("PASSWORD_REQUIRE_CLASSES", "upper, symbol,bogus")
+	t.Setenv("PASSWORD_DENYLIST_FILE", "")
+
+	policy := NewPasswordPolicy()
+	if policy.MinLength != 12 || policy.MaxLength != defaultPasswordMaxLength || policy.MinClasses != 3 {
+		t.Errorf("lengths = %d, %d, %d; want 12, %d, 3", policy.MinLength, policy.MaxLength, policy.MinClasses, defaultPasswordMaxLength)
+	}
+	if policy.RequireLower || !policy.RequireUpper || policy.RequireDigit || !policy.RequireSymbol {
+		t.Errorf("required classes = %+v, want upper and symbol", policy)
+	}
+	if !policy.denylist["12345678"] {
+		t.Error("bundled denylist not loaded")
+	}
+}
+
+func TestNewPasswordPolicyDenylistFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+	if err := os.WriteFile(path, []byte("Tulip-Garden\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PASSWORD_DENYLIST_FILE", path)
+
+	policy := NewPasswordPolicy()
+	if err := policy.Validate("tulip-garden", ""); !errors.Is(err, constants.ErrPasswordCommon) {
+		t.Errorf("Validate(listed) = %v, want %v", err, constants.ErrPasswordCommon)
+	}
+	if policy.denylist["12345678"] {
+		t.Error("bundled denylist used alongside PASSWORD_DENYLIST_FILE")
+	}
+
+	t.Setenv("PASSWORD_DENYLIST_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	if policy := NewPasswordPolicy(); !policy.denylist["12345678"] {
+		t.Error("missing PASSWORD_DENYLIST_FILE did not fall back to the bundled list")
+	}
+}
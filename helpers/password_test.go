@@ -0,0 +1,139 @@
+package helpers
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// useArgon2Params makes CurrentArgon2Params read the given values, small
+// ones keeping the tests fast, and restores the defaults afterwards.
+func useArgon2Params(t *testing.T, memory, time, threads string) {
+	t.Helper()
+
+	t.Setenv("PASSWORD_ARGON2_MEMORY", memory)
+	t.Setenv("PASSWORD_ARGON2_TIME", time)
+	t.Setenv("PASSWORD_ARGON2_THREADS", threads)
+	argon2ParamsOnce = sync.Once{}
+	t.Cleanup(func() { argon2ParamsOnce = sync.Once{} })
+}
+
+func TestHashPasswordRoundTrip(t *testing.T) {
+	useArgon2Params(t, "1024", "1", "1")
+
+	tests := []struct {
+		name     string
+		password string
+	}{
+		{"ascii", "correct horse battery staple"},
+		{"unicode", "pässwörd-✓-密码"},
+		{"empty", ""},
+	}
+
+	for _, tt := range tests {
+		hash, err := HashPassword(tt.password)
+		if err != nil {
+			t.Fatalf("%s: HashPassword: %v", tt.name, err)
+		}
+		if !strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$") {
+			t.Errorf("%s: hash = %q, want argon2id with the current parameters", tt.name, hash)
+		}
+
+		if ok, err := CheckPassword(hash, []byte(tt.password)); !ok || err != nil {
+			t.Errorf("%s: CheckPassword(right) = %v, %v; want true", tt.name, ok, err)
+		}
+		if ok, err := CheckPassword(hash, []byte(tt.password+"x")); ok || err == nil {
+			t.Errorf("%s: CheckPassword(wrong) = %v, %v; want false with an error", tt.name, ok, err)
+		}
+	}
+}
+
+func TestHashPasswordSalts(t *testing.T) {
+	useArgon2Params(t, "1024", "1", "1")
+
+	first, _ := HashPassword("password")
+	second, _ := HashPassword("password")
+	if first == second {
+		t.Error("two hashes of the same password are equal")
+	}
+}
+
+func TestCheckPasswordBcrypt(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("legacy-password"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		password string
+		want     bool
+	}{
+		{"right", "legacy-password", true},
+		{"wrong", "Legacy-password", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		if ok, err := CheckPassword(string(hash), []byte(tt.password)); ok != tt.want || (err == nil) != tt.want {
+			t.Errorf("%s: CheckPassword = %v, %v; want %v", tt.name, ok, err, tt.want)
+		}
+	}
+
+	if !NeedsRehash(string(hash)) {
+		t.Error("NeedsRehash(bcrypt) = false, want true")
+	}
+}
+
+func TestCheckPasswordMalformed(t *testing.T) {
+	for _, hash := range []string{
+		"$argon2id$",
+		"$argon2id$v=18$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA$",
+		"not a hash",
+	} {
+		if ok, err := CheckPassword(hash, []byte("password")); ok || err == nil {
+			t.Errorf("CheckPassword(%q) = %v, %v; want false with an error", hash, ok, err)
+		}
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	useArgon2Params(t, "1024", "1", "1")
+
+	hash, err := HashPassword("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if NeedsRehash(hash) {
+		t.Fatal("NeedsRehash right after HashPassword = true, want false")
+	}
+
+	tests := []struct {
+		name                  string
+		memory, time, threads string
+		want                  bool
+	}{
+		{"unchanged", "1024", "1", "1", false},
+		{"memory raised", "2048", "1", "1", true},
+		{"time raised", "1024", "2", "1", true},
+		{"threads raised", "1024", "1", "2", true},
+		{"invalid values fall back to defaults", "x", "0", "", true},
+	}
+
+	for _, tt := range tests {
+		useArgon2Params(t, tt.memory, tt.time, tt.threads)
+		if got := NeedsRehash(hash); got != tt.want {
+			t.Errorf("%s: NeedsRehash = %v, want %v", tt.name, got, tt.want)
+		}
+
+		// The old hash keeps verifying under the new parameters.
+		if ok, err := CheckPassword(hash, []byte("password")); !ok || err != nil {
+			t.Errorf("%s: CheckPassword = %v, %v; want true", tt.name, ok, err)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// TooManyRequestsError is returned when a caller is throttled. RetryAfter is
+// how long the caller should wait before trying again.
+type TooManyRequestsError struct {
+	RetryAfter time.Duration
+	Err        error
+}
+
+func (e *TooManyRequestsError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *TooManyRequestsError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfterSeconds formats d for a Retry-After header, rounding up so the
+// client never retries too early.
+func RetryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(max(int(math.Ceil(d.Seconds())), 1))
+}
@@ -0,0 +1,177 @@
+package helpers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	RECORD_FORMAT_CSV  = "csv"
+	RECORD_FORMAT_JSON = "json"
+)
+
+// DecodeRecords reads a JSON array or a CSV file with a header row into
+// records of type T. CSV columns are matched against the json tags of T,
+// case-insensitively. A record that fails to decode does not abort the file:
+// its error is returned in rowErrs at the same index and the record is left
+// zero. err is only set when the input as a whole cannot be read.
+func DecodeRecords[T any](r io.Reader, format string) (records []T, rowErrs []error, err error) {
+	switch format {
+	case RECORD_FORMAT_JSON:
+		return decodeJSONRecords[T](r)
+	case RECORD_FORMAT_CSV:
+		return decodeCSVRecords[T](r)
+	default:
+		return nil, nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+func decodeJSONRecords[T any](r io.Reader) ([]T, []error, error) {
+	var raws []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raws); err != nil {
+		return nil, nil, fmt.Errorf("failed to unmarshal JSON data: %w", err)
+	}
+
+	records := make([]T, len(raws))
+	rowErrs := make([]error, len(raws))
+	for i, raw := range raws {
+		if err := json.Unmarshal(raw, &records[i]); err != nil {
+			rowErrs[i] = err
+		}
+	}
+
+	return records, rowErrs, nil
+}
+
+func decodeCSVRecords[T any](r io.Reader) ([]T, []error, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+
+	var zero T
+	fields := jsonFieldIndex(reflect.TypeOf(zero))
+
+	columns := make([][]int, len(header))
+	for i, name := range header {
+		columns[i] = fields[strings.ToLower(strings.TrimSpace(name))]
+	}
+
+	var records []T
+	var rowErrs []error
+	for {
+		row, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		var record T
+		if err != nil {
+			records = append(records, record)
+			rowErrs = append(rowErrs, err)
+			continue
+		}
+
+		value := reflect.ValueOf(&record).Elem()
+		var rowErr error
+		for i, cell := range row {
+			if i >= len(columns) || columns[i] == nil || cell == "" {
+				continue
+			}
+
+			if err := setFromString(value.FieldByIndex(columns[i]), cell); err != nil {
+				rowErr = fmt.Errorf("column %s: %w", header[i], err)
+				break
+			}
+		}
+
+		records = append(records, record)
+		rowErrs = append(rowErrs, rowErr)
+	}
+
+	return records, rowErrs, nil
+}
+
+// jsonFieldIndex maps lower-cased json names to field indexes of t.
+func jsonFieldIndex(t reflect.Type) map[string][]int {
+	fields := make(map[string][]int)
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, _, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+		}
+
+		fields[strings.ToLower(name)] = field.Index
+	}
+
+	return fields
+}
+
+func setFromString(field reflect.Value, s string) error {
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setFromString(ptr.Elem(), s); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	s = strings.TrimSpace(s)
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(s)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v, err := strconv.ParseInt(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(v)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(s, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Type())
+	}
+
+	return nil
+}
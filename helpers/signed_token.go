@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+// SignedToken is a stateless token that can be handed out by email. Purpose
+// keeps a token for one flow from being accepted by another; Binding ties it
+// to a value, such as the email address, that must still match when it is
+// used.
+type SignedToken struct {
+	Purpose   string `json:"p"`
+	Subject   string `json:"s"`
+	Binding   string `json:"b,omitempty"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// SignToken encodes t as <payload>.<HMAC-SHA256 signature>, both base64url.
+// It refuses an empty secret, with which anyone could sign tokens.
+func SignToken(secret []byte, t SignedToken) (string, error) {
+	if len(secret) == 0 {
+		return "", constants.ErrSecretNotConfigured
+	}
+
+	payload, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(secret, encoded)), nil
+}
+
+// VerifySignedToken checks the signature, purpose and expiry of token. No
+// token is valid under an empty secret.
+func VerifySignedToken(secret []byte, purpose, token string) (SignedToken, error) {
+	if len(secret) == 0 {
+		return SignedToken{}, constants.ErrTokenInvalid
+	}
+
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return SignedToken{}, constants.ErrTokenInvalid
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sign(secret, encoded)) {
+		return SignedToken{}, constants.ErrTokenInvalid
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return SignedToken{}, constants.ErrTokenInvalid
+	}
+
+	var t SignedToken
+	if err := json.Unmarshal(payload, &t); err != nil || t.Purpose != purpose {
+		return SignedToken{}, constants.ErrTokenInvalid
+	}
+
+	if time.Now().Unix() >= t.ExpiresAt {
+		return SignedToken{}, constants.ErrTokenExpired
+	}
+
+	return t, nil
+}
+
+func sign(secret []byte, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,71 @@
+package helpers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+func TestSignedTokenRoundTrip(t *testing.T) {
+	secret := []byte("secret")
+	want := SignedToken{Purpose: "verify_email", Subject: "user", Binding: "a@example.com", ExpiresAt: time.Now().Add(time.Hour).Unix()}
+
+	token, err := SignToken(secret, want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := VerifySignedToken(secret, "verify_email", token)
+	if err != nil || got != want {
+		t.Fatalf("VerifySignedToken = %+v, %v; want %+v", got, err, want)
+	}
+}
+
+func TestVerifySignedTokenRejects(t *testing.T) {
+	secret := []byte("secret")
+	valid, _ := SignToken(secret, SignedToken{Purpose: "verify_email", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	expired, _ := SignToken(secret, SignedToken{Purpose: "verify_email", ExpiresAt: time.Now().Add(-time.Second).Unix()})
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		purpose string
+		token   string
+		want    error
+	}{
+		{"other secret", []byte("other"), "verify_email", valid, constants.ErrTokenInvalid},
+		{"empty secret", nil, "verify_email", valid, constants.ErrTokenInvalid},
+		{"other purpose", secret, "mfa_login", valid, constants.ErrTokenInvalid},
+		{"tampered", secret, "verify_email", "x" + valid, constants.ErrTokenInvalid},
+		{"malformed", secret, "verify_email", "garbage", constants.ErrTokenInvalid},
+		{"expired", secret, "verify_email", expired, constants.ErrTokenExpired},
+	}
+
+	for _, tt := range tests {
+		if _, err := VerifySignedToken(tt.secret, tt.purpose, tt.token); !errors.Is(err, tt.want) {
+			t.Errorf("%s: error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSignTokenRefusesEmptySecret(t *testing.T) {
+	if _, err := SignToken(nil, SignedToken{Purpose: "verify_email"}); !errors.Is(err, constants.ErrSecretNotConfigured) {
+		t.Errorf("SignToken with empty secret: error = %v, want %v", err, constants.ErrSecretNotConfigured)
+	}
+}
+
+func TestSecretEnv(t *testing.T) {
+	t.Setenv("TEST_SECRET", "")
+	t.Setenv("TEST_FALLBACK_SECRET", "fallback")
+
+	if got := string(SecretEnv("TEST_SECRET", "TEST_FALLBACK_SECRET")); got != "fallback" {
+		t.Errorf("SecretEnv = %q, want the fallback", got)
+	}
+
+	t.Setenv("TEST_FALLBACK_SECRET", "")
+	if got := SecretEnv("TEST_SECRET", "TEST_FALLBACK_SECRET"); len(got) != 0 {
+		t.Errorf("SecretEnv = %q, want empty", got)
+	}
+}
@@ -0,0 +1,45 @@
+package helpers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/constants"
+)
+
+// VersionConflictError is returned when an update was made against a stale
+// version. It carries the stored version so clients can refetch and retry.
+type VersionConflictError struct {
+	CurrentVersion int64 `json:"current_version"`
+}
+
+func (e *VersionConflictError) Error() string {
+	return constants.ErrVersionConflict.Error()
+}
+
+func (e *VersionConflictError) Unwrap() error {
+	return constants.ErrVersionConflict
+}
+
+// FormatETag builds the strong ETag of a versioned record. updated_at is kept
+// at microsecond precision, which is what Postgres stores.
+func FormatETag(version int64, updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(version, 10) + "-" + strconv.FormatInt(updatedAt.UnixMicro(), 36) + `"`
+}
+
+// ParseIfMatch extracts the version from an If-Match header. ETags are
+// "<version>" optionally followed by "-<suffix>"; weak ETags are accepted.
+func ParseIfMatch(header string) (int64, bool) {
+	tag := strings.TrimPrefix(strings.TrimSpace(header), "W/")
+	tag = strings.Trim(tag, `"`)
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/domain/idempotency"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const defaultIdempotencyPurgeInterval = time.Hour
+
+// StartIdempotencyKeyCleanup deletes expired idempotency keys every
+// IDEMPOTENCY_PURGE_INTERVAL (default 1h) until ctx is cancelled.
+func StartIdempotencyKeyCleanup(ctx context.Context, repo idempotency.IIdempotencyRepository) {
+	interval := helpers.DurationEnv("IDEMPOTENCY_PURGE_INTERVAL", defaultIdempotencyPurgeInterval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logging.Log.Infof("idempotency key cleanup started, interval %s", interval)
+
+	for {
+		if count, err := repo.PurgeExpired(ctx, nil, time.Now()); err != nil {
+			logging.Log.WithError(err).Warn("failed purge expired idempotency keys")
+		} else if count > 0 {
+			logging.Log.Infof("idempotency key cleanup purged %d key(s)", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			logging.Log.Info("idempotency key cleanup stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const (
+	defaultTrashRetention     = 30 * 24 * time.Hour
+	defaultTrashPurgeInterval = 24 * time.Hour
+)
+
+// StartTrashRetention permanently deletes users and products that have been
+// in the trash longer than TRASH_RETENTION (default 720h), checking every
+// TRASH_PURGE_INTERVAL (default 24h) until ctx is cancelled.
+func StartTrashRetention(ctx context.Context, userService user.IUserService, productService product.IProductService) {
+	retention := helpers.DurationEnv("TRASH_RETENTION", defaultTrashRetention)
+	interval := helpers.DurationEnv("TRASH_PURGE_INTERVAL", defaultTrashPurgeInterval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	logging.Log.Infof("trash retention started, retention %s, interval %s", retention, interval)
+
+	for {
+		before := time.Now().Add(-retention)
+
+		if count, err := userService.PurgeDeletedUsers(ctx, before); err == nil && count > 0 {
+			logging.Log.Infof("trash retention purged %d user(s)", count)
+		}
+
+		if count, err := productService.PurgeDeletedProducts(ctx, before); err == nil && count > 0 {
+			logging.Log.Infof("trash retention purged %d product(s)", count)
+		}
+
+		select {
+		case <-ctx.Done():
+			logging.Log.Info("trash retention stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
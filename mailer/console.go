@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// ConsoleMailer writes messages to a writer instead of sending them, for
+// development. Links in the body can be copied from the output.
+type ConsoleMailer struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func NewConsoleMailer(w io.Writer) *ConsoleMailer {
+	return &ConsoleMailer{w: w}
+}
+
+// NewFileMailer appends messages to the file at path, creating it on first
+// use.
+func NewFileMailer(path string) *ConsoleMailer {
+	return &ConsoleMailer{w: &appendFile{path: path}}
+}
+
+func (m *ConsoleMailer) Send(ctx context.Context, msg Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err := fmt.Fprintf(m.w, "----- %s\nTo: %s\nSubject: %s\n\n%s\n\n",
+		time.Now().Format(time.RFC3339), msg.To, msg.Subject, msg.Body)
+	return err
+}
+
+type appendFile struct {
+	path string
+}
+
+func (f *appendFile) Write(p []byte) (int, error) {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return file.Write(p)
+}
@@ -0,0 +1,46 @@
+package mailer
+
+import (
+	"context"
+	"os"
+
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const (
+	DRIVER_SMTP    = "smtp"
+	DRIVER_CONSOLE = "console"
+	DRIVER_FILE    = "file"
+)
+
+type (
+	// Mailer delivers plain text emails.
+	Mailer interface {
+		Send(ctx context.Context, msg Message) error
+	}
+
+	Message struct {
+		To      string
+		Subject string
+		Body    string
+	}
+)
+
+// New returns the mailer selected by MAILER_DRIVER: "smtp" (see
+// NewSMTPMailer), "file" to append messages to MAILER_FILE, or "console",
+// the default, to print them to stdout.
+func New() Mailer {
+	switch os.Getenv("MAILER_DRIVER") {
+	case DRIVER_SMTP:
+		return NewSMTPMailer()
+	case DRIVER_FILE:
+		path := os.Getenv("MAILER_FILE")
+		if path == "" {
+			path = "mail.log"
+		}
+		return NewFileMailer(path)
+	default:
+		logging.Log.Info("mailer: printing emails to the console")
+		return NewConsoleMailer(os.Stdout)
+	}
+}
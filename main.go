@@ -1,18 +1,29 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/mferdian/Go-GraphQL/cache"
 	"github.com/mferdian/Go-GraphQL/cmd"
 	"github.com/mferdian/Go-GraphQL/config/database"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/audit"
+	"github.com/mferdian/Go-GraphQL/domain/idempotency"
+	"github.com/mferdian/Go-GraphQL/domain/pricing"
 	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
 	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/domain/wishlist"
+	"github.com/mferdian/Go-GraphQL/jobs"
 	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/mailer"
 	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/oidc"
 	"github.com/mferdian/Go-GraphQL/routes"
 )
 
@@ -40,24 +51,74 @@ func main() {
 		jwtService = jwt.NewJWTService()
 
 		userRepo       = user.NewUserRepository(db)
-		userService    = user.NewUserService(userRepo, jwtService)
+		userService    = user.NewUserService(userRepo, jwtService, mailer.New(), oidc.New())
 		userController = user.NewUserController(userService)
 
-		productRepo = product.NewProductRepository(db)
+		productRepo = product.NewCachedProductRepository(product.NewProductRepository(db), cache.NewLRU(cache.DEFAULT_LRU_CAPACITY))
 		productService = product.NewProductService(productRepo, jwtService)
 		productController = product.NewProductController(productService)
+
+		wishlistRepo       = wishlist.NewWishlistRepository(db)
+		wishlistService    = wishlist.NewWishlistService(wishlistRepo, productRepo)
+		wishlistController = wishlist.NewWishlistController(wishlistService)
+
+		pricingRepo       = pricing.NewPricingRepository(db)
+		pricingService    = pricing.NewPricingService(pricingRepo, productRepo, productService)
+		pricingController = pricing.NewPricingController(pricingService)
+
+		auditRepo       = audit.NewAuditRepository(db)
+		auditService    = audit.NewAuditService(auditRepo)
+		auditController = audit.NewAuditController(auditService)
+
+		idempotencyRepo = idempotency.NewIdempotencyRepository(db)
+
+		rbacRepo       = rbac.NewCachedRBACRepository(rbac.NewRBACRepository(db), cache.NewLRU(cache.DEFAULT_LRU_CAPACITY))
+		rbacService    = rbac.NewRBACService(rbacRepo)
+		rbacController = rbac.NewRBACController(rbacService)
+
+		apiKeyRepo       = apikey.NewAPIKeyRepository(db)
+		apiKeyService    = apikey.NewAPIKeyService(apiKeyRepo, rbacRepo)
+		apiKeyController = apikey.NewAPIKeyController(apiKeyService)
+
+		cacheController = cache.NewCacheController(map[string]cache.StatsProvider{
+			"products":    productRepo,
+			"permissions": rbacRepo,
+		})
 	)
 
+	jwtService.SetRevocationCheck(userService.TokensRevoked)
+
+	productService.RegisterPriceObserver(pricingService)
+	productService.RegisterPriceObserver(wishlistService)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go pricingService.StartScheduler(ctx)
+	go productService.WarmSuggestCache(ctx)
+	go jobs.StartTrashRetention(ctx, userService, productService)
+	go jobs.StartIdempotencyKeyCleanup(ctx, idempotencyRepo)
+
 	server := gin.Default()
+	if err := server.SetTrustedProxies(middleware.TrustedProxies()); err != nil {
+		log.Fatalf("error trusted proxies: %v", err)
+	}
 	server.Use(middleware.CORSMiddleware())
+	server.Use(middleware.ClientIP())
 
 	
 
-	routes.PublicRoutes(server, userController)
-	routes.AdminRoutes(server, userController, jwtService)
-	routes.UserRoutes(server, userController, jwtService)
-	routes.ProductRoutes(server, productController, jwtService)
-	routes.GraphQLRoutes(server, productService, jwtService)
+	routes.PublicRoutes(server, userController, idempotencyRepo)
+	routes.AdminRoutes(server, userController, rbacService, apiKeyService, jwtService)
+	routes.UserRoutes(server, userController, apiKeyService, jwtService)
+	routes.ProductRoutes(server, productController, idempotencyRepo, rbacService, apiKeyService, jwtService)
+	routes.WishlistRoutes(server, wishlistController, apiKeyService, jwtService)
+	routes.PricingRoutes(server, pricingController, rbacService, apiKeyService, jwtService)
+	routes.AuditRoutes(server, auditController, rbacService, apiKeyService, jwtService)
+	routes.CacheRoutes(server, cacheController, rbacService, apiKeyService, jwtService)
+	routes.RBACRoutes(server, rbacController, rbacService, apiKeyService, jwtService)
+	routes.APIKeyRoutes(server, apiKeyController, rbacService, apiKeyService, jwtService)
+	routes.GraphQLRoutes(server, productService, userService, wishlistService, pricingService, rbacService, jwtService)
 
 
 	server.Static("/assets", "./assets")
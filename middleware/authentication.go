@@ -1,20 +1,31 @@
 package middleware
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
 	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/helpers"
 	"github.com/mferdian/Go-GraphQL/logging"
 	"github.com/mferdian/Go-GraphQL/utils"
 )
 
-func Authentication(jwtService jwt.InterfaceJWTService) gin.HandlerFunc {
+// Authentication accepts a Bearer token or, when there is no Authorization
+// header, an X-API-Key. Both set "id" and "role"; API keys also set "scopes",
+// which RequirePermission checks on top of the role.
+func Authentication(jwtService jwt.InterfaceJWTService, apiKeys apikey.Authenticator) gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		authHeader := ctx.GetHeader("Authorization")
 		if authHeader == "" {
+			if key := ctx.GetHeader("X-API-Key"); key != "" {
+				authenticateAPIKey(ctx, apiKeys, key)
+				return
+			}
+
 			logging.Log.Warn("Authorization header not found")
 			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_PROSES_REQUEST, constants.MESSAGE_FAILED_TOKEN_NOT_FOUND, nil)
 			ctx.AbortWithStatusJSON(http.StatusUnauthorized, res)
@@ -43,7 +54,35 @@ func Authentication(jwtService jwt.InterfaceJWTService) gin.HandlerFunc {
 		ctx.Set("Authorization", tokenStr)
 		ctx.Set("id", claims.UserID)
 		ctx.Set("role", claims.Role)
+		ctx.Set("session_id", claims.SessionID)
+		reqCtx := helpers.WithActor(ctx.Request.Context(), claims.UserID, claims.Role)
+		ctx.Request = ctx.Request.WithContext(helpers.WithSessionID(reqCtx, claims.SessionID))
 
 		ctx.Next()
 	}
 }
+
+func authenticateAPIKey(ctx *gin.Context, apiKeys apikey.Authenticator, key string) {
+	principal, err := apiKeys.AuthenticateAPIKey(ctx.Request.Context(), key)
+	if errors.Is(err, constants.ErrAPIKeyInvalid) {
+		logging.Log.Warn("Invalid api key")
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_PROSES_REQUEST, constants.MESSAGE_FAILED_API_KEY_NOT_VALID, nil)
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, res)
+		return
+	}
+	if err != nil {
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusInternalServerError, res)
+		return
+	}
+
+	logging.Log.Infof("Authenticated request - UserID: %s, Role: %s, API key: %s", principal.UserID, principal.Role, principal.KeyID)
+
+	ctx.Set("id", principal.UserID)
+	ctx.Set("role", principal.Role)
+	ctx.Set("scopes", principal.Scopes)
+	ctx.Set("api_key_id", principal.KeyID)
+	ctx.Request = ctx.Request.WithContext(helpers.WithActor(ctx.Request.Context(), principal.UserID, principal.Role))
+
+	ctx.Next()
+}
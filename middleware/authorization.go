@@ -2,10 +2,14 @@ package middleware
 
 import (
 	"net/http"
+	"slices"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
 	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/utils"
 )
 
 func AuthorizeRole(allowedRoles ...string) gin.HandlerFunc {
@@ -36,3 +40,47 @@ func AuthorizeRole(allowedRoles ...string) gin.HandlerFunc {
 		})
 	}
 }
+
+// RequirePermission lets the request through when the caller's role grants
+// permission, see rbac.PermissionChecker, and for API keys when the key is
+// scoped to it as well. It must run after Authentication.
+func RequirePermission(checker rbac.PermissionChecker, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+
+		allowed, err := checker.HasPermission(c.Request.Context(), role, permission)
+		if err != nil {
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_PROSES_REQUEST, err.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusInternalServerError, res)
+			return
+		}
+
+		if scopes, ok := c.Get("scopes"); ok && !slices.Contains(scopes.([]string), permission) {
+			allowed = false
+		}
+
+		if !allowed {
+			logging.Log.Warnf("Forbidden access attempt: role=%s lacks permission=%s", role, permission)
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_ACCESS_DENIED, constants.ErrDeniedAccess.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, res)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireSession turns away requests made with an API key, for routes where
+// a person manages their own account. It must run after Authentication.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := c.Get("scopes"); ok {
+			logging.Log.Warnf("Forbidden access attempt: api key used on %s", c.FullPath())
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_ACCESS_DENIED, constants.ErrAPIKeyNotAllowed.Error(), nil)
+			c.AbortWithStatusJSON(http.StatusForbidden, res)
+			return
+		}
+
+		c.Next()
+	}
+}
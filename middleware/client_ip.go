@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/helpers"
+)
+
+// ClientIP puts the caller's address and user agent on the request context
+// so services and resolvers, which never see the gin.Context, can use them.
+func ClientIP() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		reqCtx := helpers.WithClientIP(ctx.Request.Context(), ctx.ClientIP())
+		reqCtx = helpers.WithUserAgent(reqCtx, ctx.Request.UserAgent())
+		ctx.Request = ctx.Request.WithContext(reqCtx)
+		ctx.Next()
+	}
+}
+
+// TrustedProxies are the addresses or CIDRs in TRUSTED_PROXIES, comma
+// separated, whose X-Forwarded-For header ClientIP believes. None by default,
+// so clients cannot pick the IP they are rate limited and throttled by.
+func TrustedProxies() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
@@ -0,0 +1,186 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/helpers"
+)
+
+// CacheControl returns the Cache-Control policy configured in envKey, or
+// fallback when it is unset, so each route group can be tuned per deployment.
+func CacheControl(envKey, fallback string) string {
+	if value := os.Getenv(envKey); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// ConditionalGET buffers successful GET responses and adds ETag,
+// Last-Modified and Cache-Control headers, answering 304 Not Modified when
+// the client's If-None-Match or If-Modified-Since still matches.
+//
+// Records exposing "version" and "updated_at" in data get a strong ETag built
+// from both (see helpers.FormatETag); any other payload is tagged with a hash
+// of its data.
+func ConditionalGET(cacheControl string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		method := ctx.Request.Method
+		if method != http.MethodGet && method != http.MethodHead {
+			ctx.Next()
+			return
+		}
+
+		original := ctx.Writer
+		writer := &bufferedWriter{ResponseWriter: original, status: http.StatusOK}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		ctx.Writer = original
+
+		if writer.streaming {
+			return
+		}
+
+		if writer.status != http.StatusOK {
+			writer.flush()
+			return
+		}
+
+		etag, lastModified := validators(writer.body.Bytes())
+
+		header := original.Header()
+		// Handlers serving volatile data set their own policy.
+		if header.Get("Cache-Control") == "" {
+			header.Set("Cache-Control", cacheControl)
+		}
+		header.Set("ETag", etag)
+		if !lastModified.IsZero() {
+			header.Set("Last-Modified", lastModified.UTC().Format(http.TimeFormat))
+		}
+
+		if notModified(ctx.Request, etag, lastModified) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			original.WriteHeader(http.StatusNotModified)
+			original.WriteHeaderNow()
+			return
+		}
+
+		writer.flush()
+	}
+}
+
+// bufferedWriter holds the response back until the validators are known.
+// A handler that flushes is streaming, so the writer hands everything through
+// from then on and the response gets no validators.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	body      bytes.Buffer
+	status    int
+	streaming bool
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.streaming {
+		return
+	}
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {}
+
+func (w *bufferedWriter) Write(data []byte) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	if w.streaming {
+		return w.ResponseWriter.WriteString(s)
+	}
+	return w.body.WriteString(s)
+}
+
+func (w *bufferedWriter) Flush() {
+	if !w.streaming {
+		w.streaming = true
+		w.flush()
+		w.body.Reset()
+	}
+	w.ResponseWriter.Flush()
+}
+
+func (w *bufferedWriter) Status() int {
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.streaming {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.streaming
+}
+
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.Write(w.body.Bytes())
+}
+
+func validators(body []byte) (string, time.Time) {
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || len(envelope.Data) == 0 {
+		envelope.Data = body
+	}
+
+	var record struct {
+		Version   *int64     `json:"version"`
+		UpdatedAt *time.Time `json:"updated_at"`
+	}
+	if err := json.Unmarshal(envelope.Data, &record); err == nil && record.Version != nil && record.UpdatedAt != nil {
+		return helpers.FormatETag(*record.Version, *record.UpdatedAt), *record.UpdatedAt
+	}
+
+	// The envelope also carries a per-response timestamp, so only the data
+	// is hashed.
+	sum := sha256.Sum256(envelope.Data)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, time.Time{}
+}
+
+func notModified(req *http.Request, etag string, lastModified time.Time) bool {
+	if inm := req.Header.Get("If-None-Match"); inm != "" {
+		for _, candidate := range strings.Split(inm, ",") {
+			candidate = strings.TrimSpace(candidate)
+			if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+				return true
+			}
+		}
+		return false
+	}
+
+	if ims := req.Header.Get("If-Modified-Since"); ims != "" && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
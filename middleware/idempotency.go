@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/idempotency"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+	"github.com/mferdian/Go-GraphQL/utils"
+)
+
+const (
+	defaultIdempotencyKeyTTL      = 24 * time.Hour
+	defaultIdempotencyLockTimeout = time.Minute
+	maxIdempotencyKeyLength       = 255
+)
+
+// Idempotency makes unsafe requests carrying an Idempotency-Key header safe
+// to retry. The first request with a key runs normally and its response is
+// stored; retries with the same method, path and body get that response
+// replayed with Idempotent-Replayed: true. Reusing a key for a different
+// request is rejected with 422, and a retry that arrives while the original
+// is still running gets 409.
+//
+// Keys expire after IDEMPOTENCY_KEY_TTL (default 24h). Responses with a 5xx
+// status are not stored so the client can retry them. Register it after
+// Authentication so keys are scoped to the caller.
+func Idempotency(repo idempotency.IIdempotencyRepository) gin.HandlerFunc {
+	ttl := helpers.DurationEnv("IDEMPOTENCY_KEY_TTL", defaultIdempotencyKeyTTL)
+	lockTimeout := helpers.DurationEnv("IDEMPOTENCY_LOCK_TIMEOUT", defaultIdempotencyLockTimeout)
+
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader("Idempotency-Key")
+		method := ctx.Request.Method
+		if key == "" || method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			ctx.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_IDEMPOTENT_REQUEST, constants.ErrIdempotencyKeyInvalid.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			logging.Log.WithError(err).Warn(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY)
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_GET_DATA_FROM_BODY, err.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusBadRequest, res)
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := helpers.GetUserID(ctx.Request.Context())
+		if scope == "" {
+			scope = idempotency.SCOPE_ANONYMOUS
+		}
+
+		sum := fingerprint(method, ctx.Request.URL.RequestURI(), body)
+
+		now := time.Now()
+		record, acquired, err := repo.Acquire(ctx.Request.Context(), nil, idempotency.IdempotencyKey{
+			Key:         key,
+			Scope:       scope,
+			Fingerprint: sum,
+			Status:      idempotency.STATUS_IN_PROGRESS,
+			LockedUntil: now.Add(lockTimeout),
+			ExpiresAt:   now.Add(ttl),
+		})
+		if err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_IDEMPOTENT_REQUEST)
+			res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_IDEMPOTENT_REQUEST, constants.ErrIdempotencyStore.Error(), nil)
+			ctx.AbortWithStatusJSON(http.StatusInternalServerError, res)
+			return
+		}
+
+		if !acquired {
+			replayOrReject(ctx, record, sum)
+			return
+		}
+
+		// The outcome is stored even if the client has gone away meanwhile.
+		storeCtx := context.WithoutCancel(ctx.Request.Context())
+
+		defer func() {
+			if r := recover(); r != nil {
+				if err := repo.Release(storeCtx, nil, key, scope); err != nil {
+					logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_IDEMPOTENT_REQUEST)
+				}
+				panic(r)
+			}
+		}()
+
+		writer := &recordingWriter{ResponseWriter: ctx.Writer}
+		ctx.Writer = writer
+
+		ctx.Next()
+
+		status := writer.Status()
+		if status >= http.StatusInternalServerError {
+			err = repo.Release(storeCtx, nil, key, scope)
+		} else {
+			err = repo.Complete(storeCtx, nil, idempotency.IdempotencyKey{
+				Key:                 key,
+				Scope:               scope,
+				ResponseStatus:      status,
+				ResponseContentType: writer.Header().Get("Content-Type"),
+				ResponseBody:        writer.body.Bytes(),
+			})
+		}
+		if err != nil {
+			logging.Log.WithError(err).Error(constants.MESSAGE_FAILED_IDEMPOTENT_REQUEST)
+		}
+	}
+}
+
+func replayOrReject(ctx *gin.Context, record idempotency.IdempotencyKey, fingerprint string) {
+	switch {
+	case record.Fingerprint != fingerprint:
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_IDEMPOTENT_REQUEST, constants.ErrIdempotencyKeyReused.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusUnprocessableEntity, res)
+
+	case record.Status == idempotency.STATUS_COMPLETED:
+		ctx.Header("Idempotent-Replayed", "true")
+		ctx.Data(record.ResponseStatus, record.ResponseContentType, record.ResponseBody)
+		ctx.Abort()
+
+	default:
+		retryAfter := int(time.Until(record.LockedUntil).Seconds()) + 1
+		ctx.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		res := utils.BuildResponseFailed(constants.MESSAGE_FAILED_IDEMPOTENT_REQUEST, constants.ErrIdempotencyInFlight.Error(), nil)
+		ctx.AbortWithStatusJSON(http.StatusConflict, res)
+	}
+}
+
+// fingerprint identifies what a request asks for, so a key cannot be
+// replayed for a different one.
+func fingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(uri))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recordingWriter passes the response through and keeps a copy of the body.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
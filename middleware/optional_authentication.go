@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+// OptionalAuthentication resolves the viewer when a valid Bearer token is
+// present but lets anonymous requests through, for endpoints like GraphQL
+// that serve both.
+func OptionalAuthentication(jwtService jwt.InterfaceJWTService) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		authHeader := ctx.GetHeader("Authorization")
+		if !strings.HasPrefix(authHeader, "Bearer ") {
+			ctx.Next()
+			return
+		}
+
+		tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+
+		token, claims, err := jwtService.ValidateToken(tokenStr)
+		if err != nil || !token.Valid {
+			logging.Log.Warnf("Ignoring invalid token on optional auth: %v", err)
+			ctx.Next()
+			return
+		}
+
+		ctx.Set("Authorization", tokenStr)
+		ctx.Set("id", claims.UserID)
+		ctx.Set("role", claims.Role)
+		ctx.Set("session_id", claims.SessionID)
+		reqCtx := helpers.WithActor(ctx.Request.Context(), claims.UserID, claims.Role)
+		ctx.Request = ctx.Request.WithContext(helpers.WithSessionID(reqCtx, claims.SessionID))
+
+		ctx.Next()
+	}
+}
@@ -1,18 +1,55 @@
 package migrations
 
 import (
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/audit"
+	"github.com/mferdian/Go-GraphQL/domain/idempotency"
+	"github.com/mferdian/Go-GraphQL/domain/pricing"
 	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
 	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/domain/wishlist"
 	"gorm.io/gorm"
 )
 
 func Migrate(db *gorm.DB) error {
 	if err := db.AutoMigrate(
 		&user.User{},
+		&user.PasswordResetToken{},
+		&user.RecoveryCode{},
+		&user.MFAPolicy{},
+		&user.Session{},
+		&user.ExternalIdentity{},
 		&product.Product{},
+		&wishlist.Wishlist{},
+		&wishlist.PriceDropNotification{},
+		&pricing.PriceHistory{},
+		&pricing.PriceSchedule{},
+		&audit.AuditLog{},
+		&idempotency.IdempotencyKey{},
+		&rbac.Role{},
+		&rbac.Permission{},
+		&rbac.RolePermission{},
+		&apikey.APIKey{},
 	); err != nil {
 		return err
 	}
 
+	if err := MigrateProductSearch(db); err != nil {
+		return err
+	}
+
+	if err := MigrateProductSuggest(db); err != nil {
+		return err
+	}
+
+	if err := MigrateProductOwner(db); err != nil {
+		return err
+	}
+
+	if err := MigrateRBAC(db); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,19 @@
+package migrations
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// MigrateProductOwner gives products created before owner_id existed their
+// creator as owner. Products without a recorded creator stay ownerless and
+// can only be changed by admins.
+func MigrateProductOwner(db *gorm.DB) error {
+	err := db.Exec(`UPDATE products SET owner_id = created_by WHERE owner_id IS NULL AND created_by IS NOT NULL`).Error
+	if err != nil {
+		return fmt.Errorf("failed to migrate product owner: %w", err)
+	}
+
+	return nil
+}
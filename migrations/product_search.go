@@ -0,0 +1,51 @@
+package migrations
+
+import (
+	"fmt"
+
+	"github.com/mferdian/Go-GraphQL/domain/product"
+	"gorm.io/gorm"
+)
+
+// MigrateProductSearch adds the full-text search_vector column to products
+// together with its GIN index. The column is a stored generated column, so
+// adding it computes the vector for every existing row and Postgres keeps it
+// up to date on every insert and update afterwards.
+func MigrateProductSearch(db *gorm.DB) error {
+	statements := []string{
+		fmt.Sprintf(`ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('%[1]s', coalesce(name, '')), 'A') ||
+				setweight(to_tsvector('%[1]s', coalesce(merk, '')), 'A') ||
+				setweight(to_tsvector('%[1]s', coalesce(material, '')), 'B') ||
+				setweight(to_tsvector('%[1]s', coalesce(description, '')), 'C')
+			) STORED`, product.SEARCH_CONFIG),
+		`CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector)`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate product search: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// MigrateProductSuggest enables pg_trgm and indexes product names and brands
+// for the typo tolerant suggestion lookup.
+func MigrateProductSuggest(db *gorm.DB) error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		`CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops)`,
+		`CREATE INDEX IF NOT EXISTS idx_products_merk_trgm ON products USING GIN (merk gin_trgm_ops)`,
+	}
+
+	for _, statement := range statements {
+		if err := db.Exec(statement).Error; err != nil {
+			return fmt.Errorf("failed to migrate product suggest: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package migrations
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"gorm.io/gorm"
+)
+
+// MigrateRBAC creates the system roles and the default permissions that do
+// not exist yet. A new permission is granted to its default roles; grants of
+// existing permissions are left alone, so changes made through the role API
+// survive later migrations.
+func MigrateRBAC(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		roleIDs := make(map[string]uuid.UUID, len(rbac.SystemRoles))
+		for _, role := range rbac.SystemRoles {
+			var existing rbac.Role
+			err := tx.Where("name = ?", role.Name).Take(&existing).Error
+			switch {
+			case err == nil:
+				role = existing
+			case errors.Is(err, gorm.ErrRecordNotFound):
+				role.ID = uuid.New()
+				if err := tx.Create(&role).Error; err != nil {
+					return fmt.Errorf("failed to migrate role %s: %w", role.Name, err)
+				}
+			default:
+				return fmt.Errorf("failed to migrate role %s: %w", role.Name, err)
+			}
+			roleIDs[role.Name] = role.ID
+		}
+
+		for _, def := range rbac.DefaultPermissions {
+			var existing rbac.Permission
+			err := tx.Where("name = ?", def.Name).Take(&existing).Error
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("failed to migrate permission %s: %w", def.Name, err)
+			}
+
+			permission := rbac.Permission{ID: uuid.New(), Name: def.Name, Description: def.Description}
+			if err := tx.Create(&permission).Error; err != nil {
+				return fmt.Errorf("failed to migrate permission %s: %w", def.Name, err)
+			}
+
+			for _, name := range def.Roles {
+				grant := rbac.RolePermission{RoleID: roleIDs[name], PermissionID: permission.ID}
+				if err := tx.Create(&grant).Error; err != nil {
+					return fmt.Errorf("failed to grant %s to %s: %w", def.Name, name, err)
+				}
+			}
+		}
+
+		return nil
+	})
+}
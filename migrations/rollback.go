@@ -1,13 +1,34 @@
 package migrations
 
 import (
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/audit"
+	"github.com/mferdian/Go-GraphQL/domain/idempotency"
+	"github.com/mferdian/Go-GraphQL/domain/pricing"
 	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
 	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/domain/wishlist"
 	"gorm.io/gorm"
 )
 
 func Rollback(db *gorm.DB) error {
 	tables := []interface{}{
+		&apikey.APIKey{},
+		&audit.AuditLog{},
+		&idempotency.IdempotencyKey{},
+		&rbac.RolePermission{},
+		&rbac.Permission{},
+		&rbac.Role{},
+		&pricing.PriceSchedule{},
+		&pricing.PriceHistory{},
+		&wishlist.PriceDropNotification{},
+		&wishlist.Wishlist{},
+		&user.ExternalIdentity{},
+		&user.Session{},
+		&user.MFAPolicy{},
+		&user.RecoveryCode{},
+		&user.PasswordResetToken{},
 		&user.User{},
 		&product.Product{},
 	}
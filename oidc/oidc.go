@@ -0,0 +1,139 @@
+// Package oidc signs users in with an external identity provider using the
+// authorization code flow with PKCE. Providers are configured from the
+// environment, see New.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/logging"
+)
+
+const (
+	TYPE_OIDC   = "oidc"
+	TYPE_GITHUB = "github"
+
+	defaultRedirectBaseURL = "http://localhost:8000/api/auth/oidc"
+	httpTimeout            = 10 * time.Second
+	randomTokenBytes       = 32
+)
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,30}$`)
+
+type (
+	// Provider is an identity provider users can sign in with.
+	Provider interface {
+		// AuthCodeURL is where to send the user to sign in. state comes back
+		// on the callback, nonce in the ID token, and challenge is the PKCE
+		// S256 challenge of the verifier passed to Exchange.
+		AuthCodeURL(ctx context.Context, state, nonce, challenge string) (string, error)
+		// Exchange trades the code from the callback for the user's
+		// identity. Providers that issue ID tokens check their nonce.
+		Exchange(ctx context.Context, code, verifier, nonce string) (Identity, error)
+	}
+
+	// Identity is who the provider says signed in. Subject is stable and
+	// unique per provider; Email may change and is only trusted when
+	// EmailVerified is set.
+	Identity struct {
+		Subject       string
+		Email         string
+		EmailVerified bool
+		Name          string
+	}
+
+	// Providers are the configured providers by name, as used in routes.
+	Providers map[string]Provider
+
+	Config struct {
+		Issuer       string
+		ClientID     string
+		ClientSecret string
+		RedirectURL  string
+		Scopes       []string
+	}
+)
+
+// New returns the providers named in OIDC_PROVIDERS, a comma separated list
+// such as "google,github". Each is configured by OIDC_<NAME>_TYPE ("oidc",
+// the default, for any OpenID Connect provider, or "github"),
+// OIDC_<NAME>_ISSUER, OIDC_<NAME>_CLIENT_ID, OIDC_<NAME>_CLIENT_SECRET and
+// optionally OIDC_<NAME>_SCOPES. The callback is
+// OIDC_REDIRECT_BASE_URL/<name>/callback. Incomplete providers are skipped.
+func New() Providers {
+	base := strings.TrimRight(os.Getenv("OIDC_REDIRECT_BASE_URL"), "/")
+	if base == "" {
+		base = defaultRedirectBaseURL
+	}
+
+	providers := Providers{}
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !providerNamePattern.MatchString(name) {
+			logging.Log.Warnf("oidc: skipping provider with invalid name %q", name)
+			continue
+		}
+
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		cfg := Config{
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  base + "/" + name + "/callback",
+			Scopes:       strings.Fields(os.Getenv(prefix + "SCOPES")),
+		}
+		if cfg.ClientID == "" || cfg.ClientSecret == "" {
+			logging.Log.Warnf("oidc: skipping provider %s without client credentials", name)
+			continue
+		}
+
+		switch kind := os.Getenv(prefix + "TYPE"); kind {
+		case TYPE_GITHUB:
+			providers[name] = NewGitHubProvider(cfg)
+		case "", TYPE_OIDC:
+			if cfg.Issuer == "" {
+				logging.Log.Warnf("oidc: skipping provider %s without an issuer", name)
+				continue
+			}
+			providers[name] = NewProvider(cfg)
+		default:
+			logging.Log.Warnf("oidc: skipping provider %s of unknown type %q", name, kind)
+			continue
+		}
+
+		logging.Log.Infof("oidc: sign in with %s enabled", name)
+	}
+
+	return providers
+}
+
+// RandomToken returns a base64url encoded random value for states, nonces
+// and PKCE verifiers.
+func RandomToken() (string, error) {
+	raw := make([]byte, randomTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// S256Challenge is the PKCE code challenge of verifier.
+func S256Challenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{Timeout: httpTimeout}
+}
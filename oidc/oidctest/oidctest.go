@@ -0,0 +1,231 @@
+// Package oidctest runs a fake OpenID Connect provider for tests. It serves
+// discovery, a JWKS with one RSA key, an authorization endpoint that signs
+// the user in at once and a token endpoint that checks PKCE.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mferdian/Go-GraphQL/oidc"
+)
+
+const (
+	ClientID     = "client-id"
+	ClientSecret = "client-secret"
+	RedirectURL  = "http://localhost/api/auth/oidc/test/callback"
+	KeyID        = "test-key"
+
+	Subject = "subject-1"
+	Email   = "alice@example.com"
+)
+
+type (
+	// Issuer is the fake provider. Its ID tokens are valid for the client
+	// in Config and carry Subject and Email, verified, unless Claims says
+	// otherwise.
+	Issuer struct {
+		*httptest.Server
+
+		mu         sync.Mutex
+		key        *rsa.PrivateKey
+		claims     jwt.MapClaims
+		tokenKeyID string
+		grants     map[string]grant
+	}
+
+	grant struct {
+		challenge   string
+		nonce       string
+		redirectURL string
+	}
+)
+
+func NewIssuer(t testing.TB) *Issuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("oidctest: generate key: %v", err)
+	}
+
+	i := &Issuer{key: key, tokenKeyID: KeyID, grants: make(map[string]grant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", i.discovery)
+	mux.HandleFunc("GET /jwks", i.jwks)
+	mux.HandleFunc("GET /authorize", i.authorize)
+	mux.HandleFunc("POST /token", i.token)
+
+	i.Server = httptest.NewServer(mux)
+	t.Cleanup(i.Close)
+
+	return i
+}
+
+// Config is the client configuration the issuer accepts.
+func (i *Issuer) Config() oidc.Config {
+	return oidc.Config{
+		Issuer:       i.URL,
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		RedirectURL:  RedirectURL,
+	}
+}
+
+// SetClaims overrides claims of the ID tokens issued from now on, e.g.
+// "aud" to send the wrong audience or "email_verified" as a string.
+func (i *Issuer) SetClaims(claims jwt.MapClaims) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.claims = claims
+}
+
+// SetTokenKeyID makes ID tokens name kid as their signing key.
+func (i *Issuer) SetTokenKeyID(kid string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.tokenKeyID = kid
+}
+
+// Authorize plays the user signing in at authURL and returns the code and
+// state the issuer redirects back with.
+func (i *Issuer) Authorize(t testing.TB, authURL string) (code, state string) {
+	t.Helper()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	res, err := client.Get(authURL)
+	if err != nil {
+		t.Fatalf("oidctest: authorize: %v", err)
+	}
+	res.Body.Close()
+
+	location, err := url.Parse(res.Header.Get("Location"))
+	if res.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("oidctest: authorize: %s, location %q", res.Status, res.Header.Get("Location"))
+	}
+
+	return location.Query().Get("code"), location.Query().Get("state")
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 i.URL,
+		"authorization_endpoint": i.URL + "/authorize",
+		"token_endpoint":         i.URL + "/token",
+		"jwks_uri":               i.URL + "/jwks",
+	})
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": KeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(i.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(i.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("response_type") != "code" || query.Get("client_id") != ClientID ||
+		query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	i.mu.Lock()
+	code := "code-" + strconv.Itoa(len(i.grants)+1)
+	i.grants[code] = grant{
+		challenge:   query.Get("code_challenge"),
+		nonce:       query.Get("nonce"),
+		redirectURL: query.Get("redirect_uri"),
+	}
+	i.mu.Unlock()
+
+	callback := url.Values{"code": {code}, "state": {query.Get("state")}}
+	http.Redirect(w, r, query.Get("redirect_uri")+"?"+callback.Encode(), http.StatusFound)
+}
+
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	id, secret, _ := r.BasicAuth()
+	if id != ClientID || secret != ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	// Codes work once, whatever the outcome.
+	code := r.PostForm.Get("code")
+	g, ok := i.grants[code]
+	delete(i.grants, code)
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || r.PostForm.Get("grant_type") != "authorization_code" || r.PostForm.Get("redirect_uri") != g.redirectURL ||
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            i.URL,
+		"sub":            Subject,
+		"aud":            ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Minute).Unix(),
+		"nonce":          g.nonce,
+		"email":          Email,
+		"email_verified": true,
+		"name":           "Alice",
+	}
+	for name, value := range i.claims {
+		claims[name] = value
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.tokenKeyID
+	idToken, err := token.SignedString(i.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{
+		"access_token": "access-token",
+		"token_type":   "Bearer",
+		"id_token":     idToken,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,119 @@
+package oidc_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/mferdian/Go-GraphQL/oidc"
+	"github.com/mferdian/Go-GraphQL/oidc/oidctest"
+)
+
+// signIn starts a sign in with p, lets the issuer approve it and exchanges
+// the code. tamper may change the verifier and nonce passed to Exchange.
+func signIn(t *testing.T, issuer *oidctest.Issuer, p oidc.Provider, tamper func(verifier, nonce *string)) (oidc.Identity, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	state, _ := oidc.RandomToken()
+	nonce, _ := oidc.RandomToken()
+	verifier, _ := oidc.RandomToken()
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, oidc.S256Challenge(verifier))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+
+	code, gotState := issuer.Authorize(t, authURL)
+	if gotState != state {
+		t.Fatalf("state = %q, want %q", gotState, state)
+	}
+
+	if tamper != nil {
+		tamper(&verifier, &nonce)
+	}
+	return p.Exchange(ctx, code, verifier, nonce)
+}
+
+func TestProviderExchange(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+
+	tests := []struct {
+		name         string
+		claims       jwt.MapClaims
+		keyID        string
+		tamper       func(verifier, nonce *string)
+		wantErr      string
+		wantVerified bool
+	}{
+		{name: "valid", wantVerified: true},
+		{name: "email_verified false", claims: jwt.MapClaims{"email_verified": false}},
+		{name: "email_verified missing", claims: jwt.MapClaims{"email_verified": nil}},
+		{name: `email_verified "true"`, claims: jwt.MapClaims{"email_verified": "true"}, wantVerified: true},
+		{name: `email_verified "false"`, claims: jwt.MapClaims{"email_verified": "false"}},
+		{name: "verifier fails S256", tamper: func(verifier, _ *string) { *verifier += "x" }, wantErr: "invalid_grant"},
+		{name: "nonce mismatch", tamper: func(_, nonce *string) { *nonce += "x" }, wantErr: "nonce mismatch"},
+		{name: "wrong issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}, wantErr: "invalid issuer"},
+		{name: "wrong audience", claims: jwt.MapClaims{"aud": "other-client"}, wantErr: "invalid audience"},
+		{name: "expired", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Hour).Unix()}, wantErr: "expired"},
+		{name: "unknown key", keyID: "other-key", wantErr: "unknown signing key"},
+		{name: "no subject", claims: jwt.MapClaims{"sub": ""}, wantErr: "without subject"},
+	}
+
+	for _, tt := range tests {
+		keyID := tt.keyID
+		if keyID == "" {
+			keyID = oidctest.KeyID
+		}
+		issuer.SetClaims(tt.claims)
+		issuer.SetTokenKeyID(keyID)
+
+		identity, err := signIn(t, issuer, oidc.NewProvider(issuer.Config()), tt.tamper)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: Exchange = %+v, %v; want error containing %q", tt.name, identity, err, tt.wantErr)
+			}
+			continue
+		}
+
+		want := oidc.Identity{Subject: oidctest.Subject, Email: oidctest.Email, EmailVerified: tt.wantVerified, Name: "Alice"}
+		if err != nil || identity != want {
+			t.Errorf("%s: Exchange = %+v, %v; want %+v", tt.name, identity, err, want)
+		}
+	}
+}
+
+func TestProviderCodeWorksOnce(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	p := oidc.NewProvider(issuer.Config())
+	ctx := context.Background()
+
+	verifier, _ := oidc.RandomToken()
+	authURL, err := p.AuthCodeURL(ctx, "state", "nonce", oidc.S256Challenge(verifier))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _ := issuer.Authorize(t, authURL)
+
+	if _, err := p.Exchange(ctx, code, verifier, "nonce"); err != nil {
+		t.Fatalf("first Exchange: %v", err)
+	}
+	if _, err := p.Exchange(ctx, code, verifier, "nonce"); err == nil {
+		t.Error("second Exchange of the same code succeeded")
+	}
+}
+
+func TestProviderDiscoveryChecksIssuer(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+
+	// The same server under another name announces a different issuer.
+	cfg := issuer.Config()
+	cfg.Issuer = strings.Replace(cfg.Issuer, "127.0.0.1", "localhost", 1)
+
+	_, err := oidc.NewProvider(cfg).AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	if err == nil || !strings.Contains(err.Error(), "issuer") {
+		t.Errorf("AuthCodeURL = %v, want an issuer mismatch", err)
+	}
+}
@@ -0,0 +1,92 @@
+// Package policy decides whether the actor of a request, see
+// helpers.WithActor, may act on a given record. Services call it so REST and
+// GraphQL enforce the same rules.
+package policy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/helpers"
+)
+
+type systemContextKey struct{}
+
+// AsSystem marks ctx as an internal call, e.g. by a scheduler acting on its
+// own, which every policy lets through.
+func AsSystem(ctx context.Context) context.Context {
+	return context.WithValue(ctx, systemContextKey{}, true)
+}
+
+func isSystem(ctx context.Context) bool {
+	system, _ := ctx.Value(systemContextKey{}).(bool)
+	return system
+}
+
+// IsAdmin reports whether the actor is an admin.
+func IsAdmin(ctx context.Context) bool {
+	return helpers.GetRole(ctx) == constants.ENUM_ROLE_ADMIN
+}
+
+// SelfOrAdmin lets the user userID act on their own account, and admins on
+// any account.
+func SelfOrAdmin(ctx context.Context, userID string) error {
+	if isSystem(ctx) {
+		return nil
+	}
+
+	actorID := helpers.GetUserID(ctx)
+	if actorID == "" {
+		return constants.ErrGetIDFromToken
+	}
+
+	if actorID != userID && !IsAdmin(ctx) {
+		return constants.ErrDeniedAccess
+	}
+
+	return nil
+}
+
+// OwnerOrAdmin lets the owner act on a record, and admins on any record.
+// Records without an owner are left to admins.
+func OwnerOrAdmin(ctx context.Context, ownerID *uuid.UUID) error {
+	if isSystem(ctx) {
+		return nil
+	}
+
+	actorID := helpers.GetUserID(ctx)
+	if actorID == "" {
+		return constants.ErrGetIDFromToken
+	}
+
+	if IsAdmin(ctx) || (ownerID != nil && ownerID.String() == actorID) {
+		return nil
+	}
+
+	return constants.ErrDeniedAccess
+}
+
+// Owner returns the actor as the owner of a record it creates, nil when there
+// is no actor.
+func Owner(ctx context.Context) *uuid.UUID {
+	id, err := uuid.Parse(helpers.GetUserID(ctx))
+	if err != nil {
+		return nil
+	}
+	return &id
+}
+
+// StatusCode returns the HTTP status for an error from a policy, or fallback
+// for any other error.
+func StatusCode(err error, fallback int) int {
+	switch {
+	case errors.Is(err, constants.ErrGetIDFromToken):
+		return http.StatusUnauthorized
+	case errors.Is(err, constants.ErrDeniedAccess):
+		return http.StatusForbidden
+	}
+	return fallback
+}
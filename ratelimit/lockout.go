@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/mferdian/Go-GraphQL/helpers"
+)
+
+const (
+	defaultLockoutThreshold   = 5
+	defaultLockoutDuration    = 5 * time.Minute
+	defaultLockoutMaxDuration = 24 * time.Hour
+)
+
+// AccountLockout decides when an account is locked after failed logins and
+// for how long. Unlike LoginThrottle it only describes the policy; the
+// counters are stored with the account so they survive restarts.
+type AccountLockout struct {
+	Threshold   int
+	Duration    time.Duration
+	MaxDuration time.Duration
+}
+
+// NewAccountLockout locks an account after ACCOUNT_LOCKOUT_THRESHOLD
+// (default 5) failures in a row, for ACCOUNT_LOCKOUT_DURATION (default 5m)
+// doubled for every earlier lockout and capped at
+// ACCOUNT_LOCKOUT_MAX_DURATION (default 24h).
+func NewAccountLockout() AccountLockout {
+	return AccountLockout{
+		Threshold:   helpers.IntEnv("ACCOUNT_LOCKOUT_THRESHOLD", defaultLockoutThreshold, 1),
+		Duration:    helpers.DurationEnv("ACCOUNT_LOCKOUT_DURATION", defaultLockoutDuration),
+		MaxDuration: helpers.DurationEnv("ACCOUNT_LOCKOUT_MAX_DURATION", defaultLockoutMaxDuration),
+	}
+}
+
+// LockFor returns how long to lock an account that has already been locked
+// lockouts times since its last successful login.
+func (al AccountLockout) LockFor(lockouts int) time.Duration {
+	d := al.Duration
+	for i := 0; i < lockouts && d < al.MaxDuration; i++ {
+		d *= 2
+	}
+	return min(d, al.MaxDuration)
+}
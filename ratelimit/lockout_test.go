@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAccountLockoutLockFor(t *testing.T) {
+	lockout := AccountLockout{Threshold: 5, Duration: 5 * time.Minute, MaxDuration: time.Hour}
+
+	tests := []struct {
+		lockouts int
+		want     time.Duration
+	}{
+		{0, 5 * time.Minute},
+		{1, 10 * time.Minute},
+		{2, 20 * time.Minute},
+		{3, 40 * time.Minute},
+		{4, time.Hour},
+		{50, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := lockout.LockFor(tt.lockouts); got != tt.want {
+			t.Errorf("LockFor(%d) = %s, want %s", tt.lockouts, got, tt.want)
+		}
+	}
+}
+
+func TestAccountLockoutLockForBaseAboveMax(t *testing.T) {
+	lockout := AccountLockout{Duration: 2 * time.Hour, MaxDuration: time.Hour}
+
+	if got := lockout.LockFor(0); got != time.Hour {
+		t.Errorf("LockFor(0) = %s, want the cap %s", got, time.Hour)
+	}
+}
+
+func TestNewAccountLockoutEnv(t *testing.T) {
+	t.Setenv("ACCOUNT_LOCKOUT_THRESHOLD", "3")
+	t.Setenv("ACCOUNT_LOCKOUT_DURATION", "1m")
+	t.Setenv("ACCOUNT_LOCKOUT_MAX_DURATION", "invalid")
+
+	got := NewAccountLockout()
+	want := AccountLockout{Threshold: 3, Duration: time.Minute, MaxDuration: defaultLockoutMaxDuration}
+	if got != want {
+		t.Errorf("NewAccountLockout() = %+v, want %+v", got, want)
+	}
+}
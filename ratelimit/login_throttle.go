@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"github.com/mferdian/Go-GraphQL/helpers"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLoginFailuresPerAccount = 5
+	defaultLoginFailuresPerIP      = 20
+	defaultLoginFailureWindow      = 15 * time.Minute
+)
+
+// LoginThrottle blocks login attempts after too many recent failures for the
+// same account or from the same IP. Blocking by IP as well stops one client
+// from spraying passwords across many accounts.
+type LoginThrottle struct {
+	accounts *SlidingWindow
+	ips      *SlidingWindow
+}
+
+// NewLoginThrottle allows LOGIN_MAX_FAILURES_PER_ACCOUNT (default 5) and
+// LOGIN_MAX_FAILURES_PER_IP (default 20) failures per LOGIN_FAILURE_WINDOW
+// (default 15m).
+func NewLoginThrottle() *LoginThrottle {
+	window := helpers.DurationEnv("LOGIN_FAILURE_WINDOW", defaultLoginFailureWindow)
+
+	return &LoginThrottle{
+		accounts: NewSlidingWindow(helpers.IntEnv("LOGIN_MAX_FAILURES_PER_ACCOUNT", defaultLoginFailuresPerAccount, 1), window),
+		ips:      NewSlidingWindow(helpers.IntEnv("LOGIN_MAX_FAILURES_PER_IP", defaultLoginFailuresPerIP, 1), window),
+	}
+}
+
+// Check reports whether a login for account from ip must be refused and for
+// how long.
+func (lt *LoginThrottle) Check(account, ip string) (time.Duration, bool) {
+	retryAfter, blocked := lt.accounts.Blocked(normalizeAccount(account))
+	if ip == "" {
+		return retryAfter, blocked
+	}
+
+	if ipRetryAfter, ipBlocked := lt.ips.Blocked(ip); ipBlocked {
+		return max(retryAfter, ipRetryAfter), true
+	}
+
+	return retryAfter, blocked
+}
+
+func (lt *LoginThrottle) Fail(account, ip string) {
+	lt.accounts.Add(normalizeAccount(account))
+	if ip != "" {
+		lt.ips.Add(ip)
+	}
+}
+
+// Succeed forgets the failures of account. Failures from the IP are kept so
+// logging into an own account does not reset a password spray.
+func (lt *LoginThrottle) Succeed(account string) {
+	lt.accounts.Reset(normalizeAccount(account))
+}
+
+func normalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimSpace(account))
+}
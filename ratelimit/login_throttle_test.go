@@ -0,0 +1,29 @@
+package ratelimit
+
+import "testing"
+
+func TestLoginThrottle(t *testing.T) {
+	t.Setenv("LOGIN_MAX_FAILURES_PER_ACCOUNT", "2")
+	t.Setenv("LOGIN_MAX_FAILURES_PER_IP", "3")
+	lt := NewLoginThrottle()
+
+	lt.Fail("Alice@Example.com ", "10.0.0.1")
+	lt.Fail("alice@example.com", "10.0.0.2")
+
+	if _, blocked := lt.Check("ALICE@example.com", "10.0.0.3"); !blocked {
+		t.Error("account not blocked after 2 failures from different IPs")
+	}
+
+	lt.Succeed("alice@example.com")
+	if _, blocked := lt.Check("alice@example.com", "10.0.0.3"); blocked {
+		t.Error("account still blocked after a successful login")
+	}
+
+	// Password spraying: one IP, many accounts.
+	for _, account := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		lt.Fail(account, "10.0.0.9")
+	}
+	if _, blocked := lt.Check("d@example.com", "10.0.0.9"); !blocked {
+		t.Error("IP not blocked after 3 failures against different accounts")
+	}
+}
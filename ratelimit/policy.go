@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	POLICY_PUBLIC  = "public"
+	POLICY_API     = "api"
+	POLICY_ADMIN   = "admin"
+	POLICY_GRAPHQL = "graphql"
+)
+
+// Policy allows Requests per Period, refilled continuously, with bursts of up
+// to Requests.
+type Policy struct {
+	Name     string
+	Requests int
+	Period   time.Duration
+}
+
+var defaultPolicies = map[string]Policy{
+	POLICY_PUBLIC:  {Name: POLICY_PUBLIC, Requests: 20, Period: time.Minute},
+	POLICY_API:     {Name: POLICY_API, Requests: 300, Period: time.Minute},
+	POLICY_ADMIN:   {Name: POLICY_ADMIN, Requests: 120, Period: time.Minute},
+	POLICY_GRAPHQL: {Name: POLICY_GRAPHQL, Requests: 120, Period: time.Minute},
+}
+
+// LoadPolicy returns the named policy, overridden by RATE_LIMIT_<NAME> when
+// that is set to "<requests>/<period>", e.g. "100/1m".
+func LoadPolicy(name string) Policy {
+	policy, ok := defaultPolicies[name]
+	if !ok {
+		policy = defaultPolicies[POLICY_API]
+		policy.Name = name
+	}
+
+	if value := os.Getenv("RATE_LIMIT_" + strings.ToUpper(name)); value != "" {
+		if parsed, err := ParsePolicy(value); err == nil {
+			parsed.Name = name
+			return parsed
+		}
+	}
+
+	return policy
+}
+
+func ParsePolicy(value string) (Policy, error) {
+	requests, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %q: want <requests>/<period>", value)
+	}
+
+	n, err := strconv.Atoi(strings.TrimSpace(requests))
+	if err != nil || n <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid request count", value)
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(period))
+	if err != nil || d <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid period", value)
+	}
+
+	return Policy{Requests: n, Period: d}, nil
+}
+
+// String formats the policy for the RateLimit-Policy header.
+func (p Policy) String() string {
+	return strconv.Itoa(p.Requests) + ";w=" + strconv.Itoa(int(p.Period.Seconds()))
+}
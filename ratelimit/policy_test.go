@@ -0,0 +1,55 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    Policy
+		wantErr bool
+	}{
+		{value: "100/1m", want: Policy{Requests: 100, Period: time.Minute}},
+		{value: " 5 / 30s ", want: Policy{Requests: 5, Period: 30 * time.Second}},
+		{value: "100", wantErr: true},
+		{value: "0/1m", wantErr: true},
+		{value: "-1/1m", wantErr: true},
+		{value: "ten/1m", wantErr: true},
+		{value: "10/0s", wantErr: true},
+		{value: "10/minute", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParsePolicy(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParsePolicy(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParsePolicy(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	t.Setenv("RATE_LIMIT_PUBLIC", "7/10s")
+	t.Setenv("RATE_LIMIT_API", "broken")
+
+	if got := LoadPolicy(POLICY_PUBLIC); got != (Policy{Name: POLICY_PUBLIC, Requests: 7, Period: 10 * time.Second}) {
+		t.Errorf("LoadPolicy(public) = %+v, want the override", got)
+	}
+	if got := LoadPolicy(POLICY_API); got != defaultPolicies[POLICY_API] {
+		t.Errorf("LoadPolicy(api) = %+v, want the default for an invalid override", got)
+	}
+	if got := LoadPolicy("unknown"); got.Name != "unknown" || got.Requests != defaultPolicies[POLICY_API].Requests {
+		t.Errorf("LoadPolicy(unknown) = %+v, want the api default under its own name", got)
+	}
+}
+
+func TestPolicyString(t *testing.T) {
+	if got := (Policy{Requests: 100, Period: time.Minute}).String(); got != "100;w=60" {
+		t.Errorf("String() = %q, want %q", got, "100;w=60")
+	}
+}
@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// SlidingWindow counts events per key over the last Window and reports a key
+// as blocked once Max events fall inside it. Unlike a token bucket it never
+// lets a burst through right after the limit was hit.
+type SlidingWindow struct {
+	Max    int
+	Window time.Duration
+
+	mu     sync.Mutex
+	events map[string][]time.Time
+}
+
+func NewSlidingWindow(max int, window time.Duration) *SlidingWindow {
+	return &SlidingWindow{
+		Max:    max,
+		Window: window,
+		events: make(map[string][]time.Time),
+	}
+}
+
+// Blocked reports whether key has reached the limit and, if so, how long
+// until the oldest event leaves the window.
+func (sw *SlidingWindow) Blocked(key string) (time.Duration, bool) {
+	now := time.Now()
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	events := sw.trim(key, now)
+	if len(events) < sw.Max {
+		return 0, false
+	}
+
+	return events[len(events)-sw.Max].Add(sw.Window).Sub(now), true
+}
+
+func (sw *SlidingWindow) Add(key string) {
+	now := time.Now()
+
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	events := sw.trim(key, now)
+
+	// Only the last Max events can ever matter.
+	if len(events) >= sw.Max {
+		events = events[len(events)-sw.Max+1:]
+	}
+	sw.events[key] = append(events, now)
+}
+
+func (sw *SlidingWindow) Reset(key string) {
+	sw.mu.Lock()
+	defer sw.mu.Unlock()
+
+	delete(sw.events, key)
+}
+
+func (sw *SlidingWindow) trim(key string, now time.Time) []time.Time {
+	events := sw.events[key]
+
+	cutoff := now.Add(-sw.Window)
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	events = events[i:]
+
+	if len(events) == 0 {
+		delete(sw.events, key)
+		return nil
+	}
+	sw.events[key] = events
+	return events
+}
@@ -0,0 +1,69 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSlidingWindowBlocksAtMax(t *testing.T) {
+	sw := NewSlidingWindow(3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if _, blocked := sw.Blocked("a"); blocked {
+			t.Fatalf("blocked after %d events, want 3", i)
+		}
+		sw.Add("a")
+	}
+
+	retryAfter, blocked := sw.Blocked("a")
+	if !blocked {
+		t.Fatal("not blocked after 3 events")
+	}
+	if retryAfter <= 0 || retryAfter > time.Minute {
+		t.Errorf("retry after %s, want within the window", retryAfter)
+	}
+
+	if _, blocked := sw.Blocked("b"); blocked {
+		t.Error("other key blocked")
+	}
+}
+
+func TestSlidingWindowEventsExpire(t *testing.T) {
+	sw := NewSlidingWindow(2, 50*time.Millisecond)
+	sw.Add("a")
+	sw.Add("a")
+
+	if _, blocked := sw.Blocked("a"); !blocked {
+		t.Fatal("not blocked after 2 events")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, blocked := sw.Blocked("a"); blocked {
+		t.Error("still blocked after the window passed")
+	}
+	if _, ok := sw.events["a"]; ok {
+		t.Error("expired events kept in memory")
+	}
+}
+
+func TestSlidingWindowKeepsOnlyMaxEvents(t *testing.T) {
+	sw := NewSlidingWindow(2, time.Minute)
+	for i := 0; i < 10; i++ {
+		sw.Add("a")
+	}
+
+	if got := len(sw.events["a"]); got != 2 {
+		t.Errorf("kept %d events, want 2", got)
+	}
+}
+
+func TestSlidingWindowReset(t *testing.T) {
+	sw := NewSlidingWindow(1, time.Minute)
+	sw.Add("a")
+	sw.Reset("a")
+
+	if _, blocked := sw.Blocked("a"); blocked {
+		t.Error("blocked after reset")
+	}
+}
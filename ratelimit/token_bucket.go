@@ -0,0 +1,95 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Result describes the state of a key after a request was counted.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// TokenBucket is an in-memory token bucket limiter. Buckets that have been
+// full for a whole period are dropped so memory stays bounded by the number
+// of active clients.
+type TokenBucket struct {
+	policy Policy
+	rate   float64 // tokens per second
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func NewTokenBucket(policy Policy) *TokenBucket {
+	return &TokenBucket{
+		policy:    policy,
+		rate:      float64(policy.Requests) / policy.Period.Seconds(),
+		buckets:   make(map[string]*bucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (tb *TokenBucket) Policy() Policy {
+	return tb.policy
+}
+
+// Allow takes a token for key if one is available.
+func (tb *TokenBucket) Allow(key string) Result {
+	now := time.Now()
+	capacity := float64(tb.policy.Requests)
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.sweep(now)
+
+	b, ok := tb.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, last: now}
+		tb.buckets[key] = b
+	}
+
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.last).Seconds()*tb.rate)
+	b.last = now
+
+	result := Result{Limit: tb.policy.Requests}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = tb.duration(1 - b.tokens)
+	}
+
+	result.Remaining = int(b.tokens)
+	result.Reset = tb.duration(capacity - b.tokens)
+	return result
+}
+
+func (tb *TokenBucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / tb.rate * float64(time.Second))
+}
+
+func (tb *TokenBucket) sweep(now time.Time) {
+	if now.Sub(tb.lastSweep) < tb.policy.Period {
+		return
+	}
+	tb.lastSweep = now
+
+	for key, b := range tb.buckets {
+		if now.Sub(b.last) >= tb.policy.Period {
+			delete(tb.buckets, key)
+		}
+	}
+}
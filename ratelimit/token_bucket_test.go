@@ -0,0 +1,64 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketAllowsBurstThenThrottles(t *testing.T) {
+	tb := NewTokenBucket(Policy{Name: "test", Requests: 3, Period: time.Minute})
+
+	for i, wantRemaining := range []int{2, 1, 0} {
+		result := tb.Allow("a")
+		if !result.Allowed {
+			t.Fatalf("request %d throttled", i+1)
+		}
+		if result.Remaining != wantRemaining || result.Limit != 3 {
+			t.Errorf("request %d: remaining %d of %d, want %d of 3", i+1, result.Remaining, result.Limit, wantRemaining)
+		}
+	}
+
+	result := tb.Allow("a")
+	if result.Allowed {
+		t.Fatal("request over the limit allowed")
+	}
+	// One token comes back every 20s.
+	if result.RetryAfter <= 19*time.Second || result.RetryAfter > 20*time.Second {
+		t.Errorf("retry after %s, want about 20s", result.RetryAfter)
+	}
+	if result.Reset <= 59*time.Second || result.Reset > time.Minute {
+		t.Errorf("reset %s, want about 1m", result.Reset)
+	}
+
+	if !tb.Allow("b").Allowed {
+		t.Error("other key throttled")
+	}
+}
+
+func TestTokenBucketRefills(t *testing.T) {
+	tb := NewTokenBucket(Policy{Name: "test", Requests: 2, Period: 100 * time.Millisecond})
+	tb.Allow("a")
+	tb.Allow("a")
+
+	if tb.Allow("a").Allowed {
+		t.Fatal("request over the limit allowed")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if !tb.Allow("a").Allowed {
+		t.Error("request throttled after a token was refilled")
+	}
+}
+
+func TestTokenBucketSweepsIdleBuckets(t *testing.T) {
+	tb := NewTokenBucket(Policy{Name: "test", Requests: 1, Period: 20 * time.Millisecond})
+	tb.Allow("a")
+
+	time.Sleep(30 * time.Millisecond)
+	tb.Allow("b")
+
+	if _, ok := tb.buckets["a"]; ok {
+		t.Error("idle bucket not dropped")
+	}
+}
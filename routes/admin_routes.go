@@ -3,18 +3,33 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
-	"github.com/mferdian/Go-GraphQL/constants"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
 	"github.com/mferdian/Go-GraphQL/domain/user"
 	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
 )
 
 func AdminRoutes(r *gin.Engine, userController user.IUserController,
-	jwtService jwt.InterfaceJWTService) {
+	rbacService rbac.PermissionChecker, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
 	admin := r.Group("/api/users")
-	admin.Use(middleware.Authentication(jwtService))
-	admin.Use(middleware.AuthorizeRole(constants.ENUM_ROLE_ADMIN))
+	admin.Use(middleware.Authentication(jwtService, apiKeyService))
+	admin.Use(middleware.RateLimit(ratelimit.POLICY_ADMIN))
+
+	read := middleware.RequirePermission(rbacService, rbac.PERMISSION_USER_READ)
+	write := middleware.RequirePermission(rbacService, rbac.PERMISSION_USER_WRITE)
+	trash := middleware.RequirePermission(rbacService, rbac.PERMISSION_USER_TRASH)
 
 	// User management
-	admin.POST("", userController.CreateUser)
-	admin.GET("", userController.GetAllUser)
+	admin.POST("", write, userController.CreateUser)
+	admin.GET("", read, userController.GetAllUser)
+	admin.GET("/export", read, userController.ExportUsers)
+	admin.POST("/:id/unlock", write, userController.UnlockUser)
+	admin.GET("/mfa-policy", read, userController.GetMFAPolicies)
+	admin.PUT("/mfa-policy/:role", write, userController.SetMFAPolicy)
+
+	// Trash
+	admin.GET("/trash", trash, userController.GetTrashedUsers)
+	admin.POST("/trash/:id/restore", trash, userController.RestoreUser)
+	admin.DELETE("/trash/:id", trash, userController.PurgeUser)
 }
@@ -0,0 +1,34 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
+)
+
+func APIKeyRoutes(r *gin.Engine, apiKeyController apikey.IAPIKeyController, rbacService rbac.PermissionChecker, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
+	// API keys are managed by a signed in user, never with another key.
+	me := r.Group("/api/users/me/api-keys")
+	me.Use(middleware.Authentication(jwtService, apiKeyService))
+	me.Use(middleware.RequireSession())
+	me.Use(middleware.RateLimit(ratelimit.POLICY_API))
+
+	me.GET("", apiKeyController.GetAPIKeys)
+	me.POST("", apiKeyController.CreateAPIKey)
+	me.DELETE("/:key_id", apiKeyController.RevokeAPIKey)
+
+	admin := r.Group("/api/service-accounts")
+	admin.Use(middleware.Authentication(jwtService, apiKeyService))
+	admin.Use(middleware.RequireSession())
+	admin.Use(middleware.RateLimit(ratelimit.POLICY_ADMIN))
+	admin.Use(middleware.RequirePermission(rbacService, rbac.PERMISSION_SERVICE_ACCOUNT_MANAGE))
+
+	admin.GET("", apiKeyController.GetServiceAccounts)
+	admin.POST("", apiKeyController.CreateServiceAccount)
+	admin.GET("/:id/api-keys", apiKeyController.GetAPIKeys)
+	admin.POST("/:id/api-keys", apiKeyController.CreateAPIKey)
+	admin.DELETE("/:id/api-keys/:key_id", apiKeyController.RevokeAPIKey)
+}
@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/audit"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
+)
+
+func AuditRoutes(r *gin.Engine, auditController audit.IAuditController, rbacService rbac.PermissionChecker, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
+	admin := r.Group("/api/audit-logs")
+	admin.Use(middleware.Authentication(jwtService, apiKeyService))
+	admin.Use(middleware.RateLimit(ratelimit.POLICY_ADMIN))
+	admin.Use(middleware.RequirePermission(rbacService, rbac.PERMISSION_AUDIT_READ))
+
+	admin.GET("", auditController.GetAuditLogs)
+	admin.GET("/entities/:entity_type/:entity_id", auditController.GetEntityAuditLogs)
+	admin.GET("/actors/:actor_id", auditController.GetActorAuditLogs)
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/cache"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
+)
+
+func CacheRoutes(r *gin.Engine, cacheController cache.ICacheController, rbacService rbac.PermissionChecker, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
+	admin := r.Group("/api/metrics")
+	admin.Use(middleware.Authentication(jwtService, apiKeyService))
+	admin.Use(middleware.RateLimit(ratelimit.POLICY_ADMIN))
+	admin.Use(middleware.RequirePermission(rbacService, rbac.PERMISSION_METRICS_READ))
+
+	admin.GET("/cache", cacheController.GetCacheStats)
+}
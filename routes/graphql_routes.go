@@ -7,21 +7,36 @@ import (
 
 	"github.com/mferdian/Go-GraphQL/graphql/generated"
 	"github.com/mferdian/Go-GraphQL/graphql/resolver"
+	"github.com/mferdian/Go-GraphQL/domain/pricing"
 	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/domain/wishlist"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
 	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
 )
 
 func GraphQLRoutes(
 	r *gin.Engine,
 	productService product.IProductService,
+	userService user.IUserService,
+	wishlistService wishlist.IWishlistService,
+	pricingService pricing.IPricingService,
+	rbacService rbac.PermissionChecker,
 	jwtService jwt.InterfaceJWTService,
 ) {
 	graphqlHandler := handler.NewDefaultServer(
 		generated.NewExecutableSchema(
 			generated.Config{
 				Resolvers: &resolver.Resolver{
-					ProductService: productService,
+					ProductService:  productService,
+					UserService:     userService,
+					WishlistService: wishlistService,
+					PricingService:  pricingService,
+				},
+				Directives: generated.DirectiveRoot{
+					HasPermission: resolver.HasPermission(rbacService),
 				},
 			},
 		),
@@ -29,8 +44,9 @@ func GraphQLRoutes(
 
 	group := r.Group("/graphql")
 	group.Use(middleware.CORSMiddleware())
-	// ⬇️ optional kalau mau auth
-	// group.Use(middleware.Authentication(jwtService))
+	// Anonymous queries are allowed; a valid token only resolves the viewer
+	group.Use(middleware.OptionalAuthentication(jwtService))
+	group.Use(middleware.RateLimit(ratelimit.POLICY_GRAPHQL))
 
 	group.POST("", func(c *gin.Context) {
 		graphqlHandler.ServeHTTP(c.Writer, c.Request)
@@ -0,0 +1,26 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/pricing"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
+)
+
+func PricingRoutes(r *gin.Engine, pricingController pricing.IPricingController, rbacService rbac.PermissionChecker, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
+	products := r.Group("/api/products")
+	products.Use(middleware.Authentication(jwtService, apiKeyService))
+	products.Use(middleware.RateLimit(ratelimit.POLICY_API))
+
+	products.GET("/:id/price-history", middleware.RequirePermission(rbacService, rbac.PERMISSION_PRODUCT_READ), pricingController.GetPriceHistory)
+
+	admin := products.Group("")
+	admin.Use(middleware.RequirePermission(rbacService, rbac.PERMISSION_PRICE_SCHEDULE))
+
+	admin.GET("/:id/price-schedules", pricingController.GetPriceSchedules)
+	admin.POST("/:id/price-schedules", pricingController.CreatePriceSchedule)
+	admin.DELETE("/price-schedules/:schedule_id", pricingController.CancelPriceSchedule)
+}
@@ -3,16 +3,47 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/idempotency"
 	"github.com/mferdian/Go-GraphQL/domain/product"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
 	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
 )
 
-func ProductRoutes(r *gin.Engine,productController product.IProductController, jwtService jwt.InterfaceJWTService) {
+func ProductRoutes(r *gin.Engine,productController product.IProductController, idempotencyRepo idempotency.IIdempotencyRepository, rbacService rbac.PermissionChecker, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
 	user := r.Group("/api/products")
-	user.Use(middleware.Authentication(jwtService))
+	user.Use(middleware.Authentication(jwtService, apiKeyService))
+	user.Use(middleware.RateLimit(ratelimit.POLICY_API))
+	user.Use(middleware.Idempotency(idempotencyRepo))
+	user.Use(middleware.ConditionalGET(middleware.CacheControl("PRODUCT_CACHE_CONTROL", "private, max-age=30, must-revalidate")))
 	
-	user.POST("", productController.CreateProduct)
-	user.GET("/:id", productController.UpdateProduct)
-	user.DELETE("/:id", productController.DeleteProduct)
+	read := middleware.RequirePermission(rbacService, rbac.PERMISSION_PRODUCT_READ)
+	write := middleware.RequirePermission(rbacService, rbac.PERMISSION_PRODUCT_WRITE)
+	remove := middleware.RequirePermission(rbacService, rbac.PERMISSION_PRODUCT_DELETE)
+
+	user.POST("", write, productController.CreateProduct)
+	// A batch may create, update and delete
+	user.POST("/batch", write, remove, productController.BatchProducts)
+	user.GET("", read, productController.GetAllProduct)
+	user.GET("/search", read, productController.SearchProduct)
+	user.GET("/suggest", read, productController.SuggestProduct)
+	user.GET("/export", read, productController.ExportProducts)
+	user.GET("/:id", read, productController.GetProductByID)
+	user.PATCH("/:id", write, productController.UpdateProduct)
+	user.DELETE("/:id", remove, productController.DeleteProduct)
+
+	admin := user.Group("/trash")
+	admin.Use(middleware.RequirePermission(rbacService, rbac.PERMISSION_PRODUCT_TRASH))
+
+	admin.GET("", productController.GetTrashedProducts)
+	admin.POST("/:id/restore", productController.RestoreProduct)
+	admin.DELETE("/:id", productController.PurgeProduct)
+
+	importer := user.Group("/import")
+	importer.Use(middleware.RequirePermission(rbacService, rbac.PERMISSION_PRODUCT_IMPORT))
+
+	importer.POST("", productController.ImportProducts)
+	importer.GET("/:job_id", productController.GetImportJob)
 
 }
@@ -2,11 +2,24 @@ package routes
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/domain/idempotency"
 	"github.com/mferdian/Go-GraphQL/domain/user"
+	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
 )
 
-func PublicRoutes(r *gin.Engine, userController user.IUserController) {
+func PublicRoutes(r *gin.Engine, userController user.IUserController, idempotencyRepo idempotency.IIdempotencyRepository) {
 	public := r.Group("/api")
-	public.POST("/register", userController.Register)
+	public.Use(middleware.RateLimit(ratelimit.POLICY_PUBLIC))
+	public.POST("/register", middleware.Idempotency(idempotencyRepo), userController.Register)
 	public.POST("/login", userController.Login)
+	public.POST("/login/mfa", userController.VerifyMFA)
+	public.POST("/login/mfa/enroll", userController.StartMFAEnrollment)
+	public.POST("/login/mfa/enroll/confirm", userController.ConfirmMFAEnrollment)
+	public.POST("/verify-email", userController.VerifyEmail)
+	public.POST("/verify-email/resend", userController.ResendVerificationEmail)
+	public.POST("/password/forgot", userController.ForgotPassword)
+	public.POST("/password/reset", userController.ResetPassword)
+	public.GET("/auth/oidc/:provider", userController.StartOIDCLogin)
+	public.GET("/auth/oidc/:provider/callback", userController.OIDCCallback)
 }
@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/rbac"
+	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
+)
+
+func RBACRoutes(r *gin.Engine, rbacController rbac.IRBACController, rbacService rbac.PermissionChecker, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
+	admin := r.Group("/api")
+	admin.Use(middleware.Authentication(jwtService, apiKeyService))
+	admin.Use(middleware.RateLimit(ratelimit.POLICY_ADMIN))
+	admin.Use(middleware.RequirePermission(rbacService, rbac.PERMISSION_ROLE_MANAGE))
+
+	admin.GET("/roles", rbacController.GetRoles)
+	admin.POST("/roles", rbacController.CreateRole)
+	admin.PATCH("/roles/:name", rbacController.UpdateRole)
+	admin.DELETE("/roles/:name", rbacController.DeleteRole)
+	admin.GET("/permissions", rbacController.GetPermissions)
+	admin.PUT("/users/:id/role", rbacController.AssignRole)
+}
@@ -3,16 +3,31 @@ package routes
 import (
 	"github.com/gin-gonic/gin"
 	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
 	"github.com/mferdian/Go-GraphQL/domain/user"
 	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
 )
 
-func UserRoutes(r *gin.Engine,userController user.IUserController,jwtService jwt.InterfaceJWTService) {
+func UserRoutes(r *gin.Engine,userController user.IUserController,apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
 	user := r.Group("/api/users")
-	user.Use(middleware.Authentication(jwtService))
+	user.Use(middleware.Authentication(jwtService, apiKeyService))
+	user.Use(middleware.RequireSession())
+	user.Use(middleware.RateLimit(ratelimit.POLICY_API))
+	user.Use(middleware.ConditionalGET(middleware.CacheControl("USER_CACHE_CONTROL", "private, no-cache")))
 	
 	user.PATCH("/:id", userController.UpdateUser)
 	user.GET("/:id", userController.GetUserByID)
 	user.DELETE("/:id", userController.DeleteUser)
 
+	// Two-factor authentication
+	user.POST("/me/mfa/totp", userController.EnrollTOTP)
+	user.POST("/me/mfa/totp/confirm", userController.ConfirmTOTP)
+	user.DELETE("/me/mfa/totp", userController.DisableTOTP)
+
+	// Sessions
+	user.GET("/me/sessions", userController.GetSessions)
+	user.DELETE("/me/sessions", userController.RevokeOtherSessions)
+	user.DELETE("/me/sessions/:session_id", userController.RevokeSession)
+
 }
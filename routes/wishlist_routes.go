@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/mferdian/Go-GraphQL/config/jwt"
+	"github.com/mferdian/Go-GraphQL/domain/apikey"
+	"github.com/mferdian/Go-GraphQL/domain/wishlist"
+	"github.com/mferdian/Go-GraphQL/middleware"
+	"github.com/mferdian/Go-GraphQL/ratelimit"
+)
+
+func WishlistRoutes(r *gin.Engine, wishlistController wishlist.IWishlistController, apiKeyService apikey.Authenticator, jwtService jwt.InterfaceJWTService) {
+	me := r.Group("/api/users/me")
+	me.Use(middleware.Authentication(jwtService, apiKeyService))
+	me.Use(middleware.RequireSession())
+	me.Use(middleware.RateLimit(ratelimit.POLICY_API))
+
+	me.GET("/wishlist", wishlistController.GetWishlist)
+	me.POST("/wishlist", wishlistController.AddWishlist)
+	me.DELETE("/wishlist/:product_id", wishlistController.RemoveWishlist)
+	me.GET("/wishlist/notifications", wishlistController.GetNotifications)
+}
@@ -0,0 +1,105 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA1 seed of RFC 6238 appendix B, "12345678901234567890",
+// base32 encoded.
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// The RFC lists 8-digit codes; 6-digit codes are their last six digits.
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "94287082"},
+	{1111111109, "07081804"},
+	{1111111111, "14050471"},
+	{1234567890, "89005924"},
+	{2000000000, "69279037"},
+	{20000000000, "65353130"},
+}
+
+func TestCodeRFC6238(t *testing.T) {
+	for _, tt := range rfcVectors {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := tt.code[2:]; got != want {
+			t.Errorf("Code at %d = %s, want %s", tt.unix, got, want)
+		}
+	}
+}
+
+func TestCodeLowercaseSecret(t *testing.T) {
+	got, err := Code(strings.ToLower(rfcSecret), Step(time.Unix(59, 0)))
+	if err != nil || got != "287082" {
+		t.Errorf("Code with lowercase secret = %s, %v; want 287082", got, err)
+	}
+}
+
+func TestCodeInvalidSecret(t *testing.T) {
+	if _, err := Code("not base32!", 1); err == nil {
+		t.Error("Code with invalid secret succeeded")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code := func(step int64) string {
+		c, _ := Code(rfcSecret, step)
+		return c
+	}
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current", code(step), step, true},
+		{"with spaces", code(step)[:3] + " " + code(step)[3:], step, true},
+		{"previous period", code(step - 1), step - 1, true},
+		{"next period", code(step + 1), step + 1, true},
+		{"too old", code(step - 2), 0, false},
+		{"too new", code(step + 2), 0, false},
+		{"wrong", "000000", 0, false},
+		{"too short", code(step)[:5], 0, false},
+		{"too long", code(step) + "0", 0, false},
+	}
+
+	for _, tt := range tests {
+		gotStep, ok := Validate(rfcSecret, tt.code, now)
+		if ok != tt.wantOK || gotStep != tt.wantStep {
+			t.Errorf("%s: Validate = %d, %t; want %d, %t", tt.name, gotStep, ok, tt.wantStep, tt.wantOK)
+		}
+	}
+}
+
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GenerateSecret()
+
+	if len(a) != 32 || a == b {
+		t.Errorf("GenerateSecret = %q, %q; want two different 32 character secrets", a, b)
+	}
+	if _, err := Code(a, 1); err != nil {
+		t.Errorf("generated secret not usable: %v", err)
+	}
+}
+
+func TestURI(t *testing.T) {
+	got := URI("Go GraphQL", "alice@example.com", rfcSecret)
+	want := "otpauth://totp/Go%20GraphQL:alice@example.com?algorithm=SHA1&digits=6&issuer=Go+GraphQL&period=30&secret=" + rfcSecret
+	if got != want {
+		t.Errorf("URI = %s, want %s", got, want)
+	}
+}